@@ -0,0 +1,264 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditOptions holds the options of NewAuditHooks.
+type AuditOptions struct {
+	// Classify reports whether query modifies the data and should be audited.
+	// If it is nil, IsModifyingQuery is used.
+	Classify func(query string) bool
+
+	// Identity returns the user and the tenant who executes the statement.
+	// If it is nil, the "user" and "tenant" labels associated with the context by WithLabels are used.
+	Identity func(c context.Context) (user, tenant string)
+
+	// IncludeArgs includes the arguments of the statements into the records.
+	// They are omitted by default because they may contain sensitive data.
+	IncludeArgs bool
+
+	// ValueFormatter formats the values of the arguments.
+	// If it is set, the values are recorded as the formatted strings,
+	// e.g. RedactedValueFormatter hides them.
+	ValueFormatter ValueFormatter
+
+	// MaxArgLength is the maximum length of the formatted arguments in bytes.
+	// The longer arguments are elided with an ellipsis and their lengths.
+	// If it is zero, the arguments are not elided.
+	MaxArgLength int
+
+	// Now returns the current time.
+	// If it is nil, time.Now is used. It is intended for deterministic tests.
+	Now func() time.Time
+
+	// OnError is called when a record can't be encoded or written into the writer.
+	// The statements are not affected by the failures.
+	// If it is nil, the failures are ignored.
+	OnError func(c context.Context, r *AuditRecord, err error)
+}
+
+// AuditRecord is a record written by the hooks created by NewAuditHooks.
+type AuditRecord struct {
+	// Time is the time when the statement started.
+	Time time.Time `json:"time"`
+
+	// User is the user who executed the statement.
+	User string `json:"user,omitempty"`
+
+	// Tenant is the tenant of the user.
+	Tenant string `json:"tenant,omitempty"`
+
+	// Operation is the kind of the operation: Exec, Query, Commit or Rollback.
+	Operation Operation `json:"operation"`
+
+	// ConnID is the ID of the connection.
+	ConnID int64 `json:"conn_id,omitempty"`
+
+	// TxID is the ID of the transaction.
+	// It is zero if the statement is not executed in a transaction.
+	TxID int64 `json:"tx_id,omitempty"`
+
+	// Statement is the query string.
+	// It is empty for Commit and Rollback.
+	Statement string `json:"statement,omitempty"`
+
+	// Args is the arguments of the statement.
+	// It is reported only if AuditOptions.IncludeArgs is enabled.
+	Args []EventArg `json:"args,omitempty"`
+
+	// RowsAffected is the number of the rows affected by Exec.
+	// It is nil if it is unknown, e.g. the statement failed or is executed by Query.
+	RowsAffected *int64 `json:"rows_affected,omitempty"`
+
+	// Outcome is "success" or "failure".
+	Outcome string `json:"outcome"`
+
+	// Error is the error message of the statement.
+	Error string `json:"error,omitempty"`
+
+	// Labels is the labels associated with the context by WithLabels.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// the outcomes of AuditRecord.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// IsModifyingQuery reports whether query starts with the keywords of the statements that modify the data or the schema,
+// e.g. INSERT, UPDATE, DELETE, CREATE and GRANT.
+func IsModifyingQuery(query string) bool {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	i := 0
+	for i < len(query) && isIdentChar(query[i]) {
+		i++
+	}
+	switch strings.ToUpper(query[:i]) {
+	case "INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE", "UPSERT", "TRUNCATE", "COPY", "LOAD",
+		"CREATE", "ALTER", "DROP", "RENAME", "GRANT", "REVOKE":
+		return true
+	}
+	return false
+}
+
+// NewAuditHooks creates new HooksContext which writes an audit record for every data-modifying statement into w,
+// for compliance requirements rather than debugging.
+// The records are written as JSON lines of AuditRecord, one Write call per record, after the statements finish.
+// The statements are classified by AuditOptions.Classify.
+// The commits and the rollbacks of the transactions that include the audited statements are also recorded,
+// so the auditors can tell whether the statements took effect.
+func NewAuditHooks(w io.Writer, opt AuditOptions) *HooksContext {
+	classify := opt.Classify
+	if classify == nil {
+		classify = IsModifyingQuery
+	}
+	identity := opt.Identity
+	if identity == nil {
+		identity = identityFromLabels
+	}
+	now := opt.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	var mu sync.Mutex
+	txs := make(map[int64]int64) // the IDs of the connections of the transactions that include the audited statements
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	write := func(c context.Context, r *AuditRecord, err error) {
+		r.User, r.Tenant = identity(c)
+		r.TxID, _ = TxIDFromContext(c)
+		r.Labels = LabelsFromContext(c)
+		r.Outcome = AuditSuccess
+		if err != nil {
+			r.Outcome = AuditFailure
+			r.Error = err.Error()
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Operation {
+		case OpExec, OpQuery:
+			if r.TxID != 0 {
+				txs[r.TxID] = r.ConnID
+			}
+		case OpCommit, OpRollback:
+			if _, ok := txs[r.TxID]; !ok {
+				return
+			}
+			delete(txs, r.TxID)
+		}
+		buf.Reset()
+		werr := enc.Encode(r)
+		if werr == nil {
+			var n int
+			n, werr = w.Write(buf.Bytes())
+			if werr == nil && n < buf.Len() {
+				werr = io.ErrShortWrite
+			}
+		}
+		if werr != nil && opt.OnError != nil {
+			opt.OnError(c, r, werr)
+		}
+	}
+	start := func(query string) (interface{}, error) {
+		if query != "" && !classify(query) {
+			return nil, nil
+		}
+		return now(), nil
+	}
+	newRecord := func(op Operation, ctx interface{}, conn *Conn, query string, args []driver.NamedValue) *AuditRecord {
+		t, ok := ctx.(time.Time)
+		if !ok {
+			return nil
+		}
+		r := &AuditRecord{
+			Time:      t,
+			Operation: op,
+			Statement: query,
+		}
+		if conn != nil {
+			r.ConnID = conn.id
+		}
+		if opt.IncludeArgs {
+			r.Args = newEventArgs(formatNamedValues(args, opt.ValueFormatter, opt.MaxArgLength))
+		}
+		return r
+	}
+
+	return &HooksContext{
+		PreExec: func(_ context.Context, stmt *Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return start(stmt.QueryString)
+		},
+		PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			r := newRecord(OpExec, ctx, stmt.Conn, stmt.QueryString, args)
+			if r == nil || err == driver.ErrSkip {
+				return nil
+			}
+			if err == nil && result != nil {
+				if n, err := result.RowsAffected(); err == nil {
+					r.RowsAffected = &n
+				}
+			}
+			write(c, r, err)
+			return nil
+		},
+		PreQuery: func(_ context.Context, stmt *Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return start(stmt.QueryString)
+		},
+		PostQuery: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, _ driver.Rows, err error) error {
+			r := newRecord(OpQuery, ctx, stmt.Conn, stmt.QueryString, args)
+			if r == nil || err == driver.ErrSkip {
+				return nil
+			}
+			write(c, r, err)
+			return nil
+		},
+		PreCommit: func(_ context.Context, _ *Tx) (interface{}, error) {
+			return start("")
+		},
+		PostCommit: func(c context.Context, ctx interface{}, tx *Tx, err error) error {
+			if r := newRecord(OpCommit, ctx, tx.Conn, "", nil); r != nil {
+				write(c, r, err)
+			}
+			return nil
+		},
+		PreRollback: func(_ context.Context, _ *Tx) (interface{}, error) {
+			return start("")
+		},
+		PostRollback: func(c context.Context, ctx interface{}, tx *Tx, err error) error {
+			if r := newRecord(OpRollback, ctx, tx.Conn, "", nil); r != nil {
+				write(c, r, err)
+			}
+			return nil
+		},
+		PostClose: func(_ context.Context, _ interface{}, conn *Conn, _ error) error {
+			// the transactions that are never finished are discarded with their connections.
+			mu.Lock()
+			defer mu.Unlock()
+			for txID, connID := range txs {
+				if connID == conn.id {
+					delete(txs, txID)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// identityFromLabels returns the "user" and "tenant" labels associated with c.
+func identityFromLabels(c context.Context) (user, tenant string) {
+	labels := LabelsFromContext(c)
+	return labels["user"], labels["tenant"]
+}
@@ -0,0 +1,234 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeAuditRecords(t *testing.T, buf *bytes.Buffer) []AuditRecord {
+	t.Helper()
+	var records []AuditRecord
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var r AuditRecord
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestNewAuditHooks(t *testing.T) {
+	now := time.Date(2006, time.January, 2, 15, 4, 5, 0, time.UTC)
+	var buf bytes.Buffer
+	sql.Register("fakedb-audit", NewProxyContext(fdriver, NewAuditHooks(&buf, AuditOptions{
+		IncludeArgs: true,
+		Now: func() time.Time {
+			return now
+		},
+	})))
+	db, err := sql.Open("fakedb-audit", `{"Name":"audit","ConnType":"fakeConnCtx","RowsAffected":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := WithLabels(context.Background(), map[string]string{"user": "alice", "tenant": "acme"})
+	if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.QueryContext(ctx, "SELECT id FROM t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	// the transaction without audited statements is not recorded.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err = db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM t1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	records := decodeAuditRecords(t, &buf)
+	if len(records) != 3 {
+		t.Fatalf("want 3 records, got %v", records)
+	}
+
+	insert := records[0]
+	if !insert.Time.Equal(now) || insert.User != "alice" || insert.Tenant != "acme" ||
+		insert.Operation != OpExec || insert.Statement != "INSERT INTO t1 (id) VALUES(?)" ||
+		insert.Outcome != AuditSuccess || insert.TxID != 0 || insert.ConnID == 0 {
+		t.Errorf("unexpected record: %#v", insert)
+	}
+	if insert.RowsAffected == nil || *insert.RowsAffected != 1 {
+		t.Errorf("want 1 affected row, got %v", insert.RowsAffected)
+	}
+	if insert.Labels["user"] != "alice" || insert.Labels["tenant"] != "acme" {
+		t.Errorf("unexpected labels: %v", insert.Labels)
+	}
+	if len(insert.Args) != 1 || insert.Args[0].Value != float64(1) {
+		t.Errorf("unexpected args: %#v", insert.Args)
+	}
+
+	del, rollback := records[1], records[2]
+	if del.Statement != "DELETE FROM t1" || del.TxID == 0 {
+		t.Errorf("unexpected record: %#v", del)
+	}
+	if rollback.Operation != OpRollback || rollback.TxID != del.TxID || rollback.Outcome != AuditSuccess {
+		t.Errorf("unexpected record: %#v", rollback)
+	}
+}
+
+func TestNewAuditHooks_Failure(t *testing.T) {
+	var buf bytes.Buffer
+	sql.Register("fakedb-audit-failure", NewProxyContext(fdriver, NewAuditHooks(&buf, AuditOptions{
+		Classify: func(query string) bool {
+			return strings.HasPrefix(query, "SELECT")
+		},
+		Identity: func(c context.Context) (string, string) {
+			return "bob", ""
+		},
+	})))
+	db, err := sql.Open("fakedb-audit-failure", `{"Name":"audit-failure","ConnType":"fakeConnCtx","FailQuery":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.QueryContext(ctx, "SELECT id FROM t1"); err == nil {
+		t.Fatal("want error, got nil")
+	}
+
+	records := decodeAuditRecords(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("want 1 record, got %v", records)
+	}
+	r := records[0]
+	if r.User != "bob" || r.Operation != OpQuery || r.Outcome != AuditFailure || r.Error == "" || r.RowsAffected != nil || r.Args != nil {
+		t.Errorf("unexpected record: %#v", r)
+	}
+}
+
+func TestNewAuditHooks_ValueFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	sql.Register("fakedb-audit-value-formatter", NewProxyContext(fdriver, NewAuditHooks(&buf, AuditOptions{
+		IncludeArgs:    true,
+		ValueFormatter: RedactedValueFormatter,
+	})))
+	db, err := sql.Open("fakedb-audit-value-formatter", `{"Name":"audit-value-formatter","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	records := decodeAuditRecords(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("want 1 record, got %v", records)
+	}
+	if args := records[0].Args; len(args) != 1 || args[0].Ordinal != 1 || args[0].Value != "<redacted>" {
+		t.Errorf("unexpected args: %#v", args)
+	}
+}
+
+func TestIsModifyingQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"INSERT INTO t1 (id) VALUES(1)", true},
+		{"  update t1 SET id = 2", true},
+		{"DELETE FROM t1", true},
+		{"CREATE TABLE t2 (id INT)", true},
+		{"GRANT SELECT ON t1 TO bob", true},
+		{"SELECT id FROM t1", false},
+		{"(SELECT 1)", false},
+		{"BEGIN", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsModifyingQuery(tt.query); got != tt.want {
+			t.Errorf("%q: want %t, got %t", tt.query, tt.want, got)
+		}
+	}
+}
+
+type failingAuditWriter struct{}
+
+func (failingAuditWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestNewAuditHooks_OnError(t *testing.T) {
+	var got []error
+	sql.Register("fakedb-audit-on-error", NewProxyContext(fdriver, NewAuditHooks(failingAuditWriter{}, AuditOptions{
+		OnError: func(c context.Context, r *AuditRecord, err error) {
+			if r.Operation != OpExec {
+				t.Errorf("unexpected operation: %v", r.Operation)
+			}
+			got = append(got, err)
+		},
+	})))
+	db, err := sql.Open("fakedb-audit-on-error", `{"Name":"audit-on-error","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the failures of the audit log don't affect the statements.
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Error() != "disk full" {
+		t.Errorf("unexpected errors: %v", got)
+	}
+}
+
+func TestNewAuditHooks_CloseDiscardsTx(t *testing.T) {
+	var buf bytes.Buffer
+	hooks := NewAuditHooks(&buf, AuditOptions{})
+	conn := &Conn{id: 1}
+	tx := &Tx{Conn: conn}
+	c := withTxID(context.Background(), 2)
+
+	ctx, _ := hooks.PreExec(c, &Stmt{Conn: conn, QueryString: "INSERT INTO t1 (id) VALUES(1)"}, nil)
+	hooks.PostExec(c, ctx, &Stmt{Conn: conn, QueryString: "INSERT INTO t1 (id) VALUES(1)"}, nil, nil, nil)
+	hooks.PostClose(context.Background(), nil, conn, nil)
+
+	// the transaction is forgotten with the connection, so its end isn't audited.
+	ctx, _ = hooks.PreRollback(c, tx)
+	hooks.PostRollback(c, ctx, tx, nil)
+
+	records := decodeAuditRecords(t, &buf)
+	if len(records) != 1 || records[0].Operation != OpExec {
+		t.Errorf("unexpected records: %#v", records)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build go1.27
+// +build go1.27
+
+package proxy
+
+// useColumnConverter reports whether database/sql converts the argument at index
+// with the ColumnConverter of a statement that has want placeholders.
+// database/sql from Go 1.27 also uses the converter if the number of placeholders is unknown (-1).
+func useColumnConverter(want, index int) bool {
+	return want < 0 || want > index
+}
@@ -0,0 +1,783 @@
+package proxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// Compose returns a HooksContext that calls all of hs.
+// The Pre hooks and the hooks are called in the order of hs,
+// and the Post hooks are called in the reverse order.
+// The priorities of hs are ignored. Use ComposeHooks to honor them.
+// Nil elements in hs are ignored.
+func Compose(hs ...*HooksContext) *HooksContext {
+	hooksSlice := make([]hooks, 0, len(hs))
+	for _, hk := range hs {
+		if hk != nil {
+			hooksSlice = append(hooksSlice, hk)
+		}
+	}
+	return newHooksContext(multipleHooks(hooksSlice))
+}
+
+// ComposeHooks merges hs into one HooksContext, with the same semantics as passing hs to NewProxyContext.
+// Nil elements in hs are ignored.
+//
+// The hook sets are ordered by their priorities in descending order, and then in the order of hs.
+// For each operation:
+//
+//   - The Pre hooks are called in the order. All of them are called even if some of them return errors,
+//     and the first error is returned, which aborts the operation.
+//   - The hooks, e.g. Exec, are called in the order. They stop at the first error, which is returned.
+//   - The Post hooks are called in the reverse order, and all of them are called.
+//     Each of them receives the error of the operation. If the operation succeeded,
+//     the Post hooks called later receive the first error returned by the Post hooks.
+//     The first error returned by the Post hooks is returned.
+//   - PostRows, PostLastInsertId, PostRowsAffected and OnCanceled are called like the Post hooks.
+//   - Columns and OncePerConn are called in the order, and stop at the first error.
+//   - ColumnConverter chains the converters in the order.
+//
+// The merged HooksContext has the zero priority.
+func ComposeHooks(hs ...*HooksContext) *HooksContext {
+	hooksSlice := make([]hooks, 0, len(hs))
+	for _, hk := range hs {
+		if hk != nil {
+			hooksSlice = append(hooksSlice, hk)
+		}
+	}
+	sortHooks(hooksSlice)
+	return newHooksContext(multipleHooks(hooksSlice))
+}
+
+// When returns a HooksContext that calls h only if pred returns true.
+// pred is evaluated once per operation, before the Pre hook is called.
+// The IsValid hooks don't have any context, so pred receives context.Background() for them.
+func When(pred func(c context.Context) bool, h *HooksContext) *HooksContext {
+	if h == nil {
+		return nil
+	}
+	return Where(func(c context.Context, _ Operation, _ string) bool {
+		return pred(c)
+	}, h)
+}
+
+// Predicate reports whether the hooks should be called for the operation op.
+// query is the query of the operation, and it is empty for the operations that have no query, e.g. OpBegin.
+type Predicate func(c context.Context, op Operation, query string) bool
+
+// Where returns a HooksContext that calls h only if pred returns true.
+// It is the same as When, but pred also receives the operation and the query,
+// e.g. for calling h only for SELECT queries.
+// pred is evaluated once per operation, before the Pre hook is called, so h doesn't allocate any state for the skipped operations.
+func Where(pred Predicate, h *HooksContext) *HooksContext {
+	if h == nil {
+		return nil
+	}
+	hk := newHooksContext(&conditionalHooks{
+		pred:  pred,
+		hooks: h,
+	})
+	hk.Name = h.Name
+	hk.Priority = h.Priority
+	hk.tracerFilter = h.tracerFilter
+	return hk
+}
+
+// Tap returns a HooksContext that calls h, but ignores the errors returned by h.
+// It is useful for observing events without affecting the operations.
+func Tap(h *HooksContext) *HooksContext {
+	return MapError(h, func(err error) error {
+		return nil
+	})
+}
+
+// MapError returns a HooksContext that calls h, and converts the errors returned by h with f.
+// f is called only when h returns a non-nil error.
+func MapError(h *HooksContext, f func(err error) error) *HooksContext {
+	if h == nil {
+		return nil
+	}
+	hk := newHooksContext(&mapErrorHooks{
+		f:     f,
+		hooks: h,
+	})
+	hk.Name = h.Name
+	hk.Priority = h.Priority
+	hk.tracerFilter = h.tracerFilter
+	return hk
+}
+
+// newHooksContext converts h into HooksContext.
+func newHooksContext(h hooks) *HooksContext {
+	return &HooksContext{
+		PrePing:            h.prePing,
+		Ping:               h.ping,
+		PostPing:           h.postPing,
+		PreOpen:            h.preOpen,
+		Open:               h.open,
+		PostOpen:           h.postOpen,
+		PrePrepare:         h.prePrepare,
+		Prepare:            h.prepare,
+		PostPrepare:        h.postPrepare,
+		PreExec:            h.preExec,
+		Exec:               h.exec,
+		PostExec:           h.postExec,
+		PreQuery:           h.preQuery,
+		Query:              h.query,
+		PostQuery:          h.postQuery,
+		PreBegin:           h.preBegin,
+		Begin:              h.begin,
+		PostBegin:          h.postBegin,
+		PreCommit:          h.preCommit,
+		Commit:             h.commit,
+		PostCommit:         h.postCommit,
+		PreRollback:        h.preRollback,
+		Rollback:           h.rollback,
+		PostRollback:       h.postRollback,
+		PreClose:           h.preClose,
+		Close:              h.close,
+		PostClose:          h.postClose,
+		PreResetSession:    h.preResetSession,
+		ResetSession:       h.resetSession,
+		PostResetSession:   h.postResetSession,
+		PreIsValid:         h.preIsValid,
+		IsValid:            h.isValid,
+		PostIsValid:        h.postIsValid,
+		PreStmtClose:       h.preStmtClose,
+		StmtClose:          h.stmtClose,
+		PostStmtClose:      h.postStmtClose,
+		PreRowsClose:       h.preRowsClose,
+		RowsClose:          h.rowsClose,
+		PostRowsClose:      h.postRowsClose,
+		PostRows:           h.postRows,
+		PostLastInsertId:   h.postLastInsertId,
+		PostRowsAffected:   h.postRowsAffected,
+		PreConnect:         h.preConnect,
+		Connect:            h.connect,
+		PostConnect:        h.postConnect,
+		PreConnectorClose:  h.preConnectorClose,
+		PostConnectorClose: h.postConnectorClose,
+		OnCanceled:         h.onCanceled,
+		Columns: func(c context.Context, rows *Rows, _ []Column) error {
+			return h.columns(c, rows)
+		},
+		PreSavepoint:    h.preSavepoint,
+		Savepoint:       h.savepoint,
+		PostSavepoint:   h.postSavepoint,
+		ColumnConverter: h.columnConverter,
+		OncePerConn:     h.oncePerConn,
+		RewriteDSN:      h.rewriteDSN,
+	}
+}
+
+// skippedContext is the ctx value for the operations skipped by conditionalHooks.
+type skippedContext struct{}
+
+// conditionalHooks calls the hooks only if pred returns true.
+type conditionalHooks struct {
+	pred  Predicate
+	hooks hooks
+}
+
+func (h *conditionalHooks) preDo(c context.Context, op Operation, query string, f func() (interface{}, error)) (interface{}, error) {
+	if !h.pred(c, op, query) {
+		return skippedContext{}, nil
+	}
+	return f()
+}
+
+func (h *conditionalHooks) do(ctx interface{}, f func() error) error {
+	if _, ok := ctx.(skippedContext); ok {
+		return nil
+	}
+	return f()
+}
+
+func (h *conditionalHooks) prePing(c context.Context, conn *Conn) (interface{}, error) {
+	return h.preDo(c, OpPing, "", func() (interface{}, error) {
+		return h.hooks.prePing(c, conn)
+	})
+}
+
+func (h *conditionalHooks) ping(c context.Context, ctx interface{}, conn *Conn) error {
+	return h.do(ctx, func() error {
+		return h.hooks.ping(c, ctx, conn)
+	})
+}
+
+func (h *conditionalHooks) postPing(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postPing(c, ctx, conn, err)
+	})
+}
+
+func (h *conditionalHooks) preOpen(c context.Context, name string) (interface{}, error) {
+	return h.preDo(c, OpOpen, "", func() (interface{}, error) {
+		return h.hooks.preOpen(c, name)
+	})
+}
+
+func (h *conditionalHooks) open(c context.Context, ctx interface{}, conn *Conn) error {
+	return h.do(ctx, func() error {
+		return h.hooks.open(c, ctx, conn)
+	})
+}
+
+func (h *conditionalHooks) postOpen(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postOpen(c, ctx, conn, err)
+	})
+}
+
+func (h *conditionalHooks) prePrepare(c context.Context, stmt *Stmt) (interface{}, error) {
+	return h.preDo(c, OpPrepare, stmt.query(), func() (interface{}, error) {
+		return h.hooks.prePrepare(c, stmt)
+	})
+}
+
+func (h *conditionalHooks) prepare(c context.Context, ctx interface{}, stmt *Stmt) error {
+	return h.do(ctx, func() error {
+		return h.hooks.prepare(c, ctx, stmt)
+	})
+}
+
+func (h *conditionalHooks) postPrepare(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postPrepare(c, ctx, stmt, err)
+	})
+}
+
+func (h *conditionalHooks) preExec(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+	return h.preDo(c, OpExec, stmt.query(), func() (interface{}, error) {
+		return h.hooks.preExec(c, stmt, args)
+	})
+}
+
+func (h *conditionalHooks) exec(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result) error {
+	return h.do(ctx, func() error {
+		return h.hooks.exec(c, ctx, stmt, args, result)
+	})
+}
+
+func (h *conditionalHooks) postExec(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postExec(c, ctx, stmt, args, result, err)
+	})
+}
+
+func (h *conditionalHooks) preQuery(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+	return h.preDo(c, OpQuery, stmt.query(), func() (interface{}, error) {
+		return h.hooks.preQuery(c, stmt, args)
+	})
+}
+
+func (h *conditionalHooks) query(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows) error {
+	return h.do(ctx, func() error {
+		return h.hooks.query(c, ctx, stmt, args, rows)
+	})
+}
+
+func (h *conditionalHooks) postQuery(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postQuery(c, ctx, stmt, args, rows, err)
+	})
+}
+
+func (h *conditionalHooks) preBegin(c context.Context, conn *Conn) (interface{}, error) {
+	return h.preDo(c, OpBegin, "", func() (interface{}, error) {
+		return h.hooks.preBegin(c, conn)
+	})
+}
+
+func (h *conditionalHooks) begin(c context.Context, ctx interface{}, conn *Conn) error {
+	return h.do(ctx, func() error {
+		return h.hooks.begin(c, ctx, conn)
+	})
+}
+
+func (h *conditionalHooks) postBegin(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postBegin(c, ctx, conn, err)
+	})
+}
+
+func (h *conditionalHooks) preCommit(c context.Context, tx *Tx) (interface{}, error) {
+	return h.preDo(c, OpCommit, "", func() (interface{}, error) {
+		return h.hooks.preCommit(c, tx)
+	})
+}
+
+func (h *conditionalHooks) commit(c context.Context, ctx interface{}, tx *Tx) error {
+	return h.do(ctx, func() error {
+		return h.hooks.commit(c, ctx, tx)
+	})
+}
+
+func (h *conditionalHooks) postCommit(c context.Context, ctx interface{}, tx *Tx, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postCommit(c, ctx, tx, err)
+	})
+}
+
+func (h *conditionalHooks) preRollback(c context.Context, tx *Tx) (interface{}, error) {
+	return h.preDo(c, OpRollback, "", func() (interface{}, error) {
+		return h.hooks.preRollback(c, tx)
+	})
+}
+
+func (h *conditionalHooks) rollback(c context.Context, ctx interface{}, tx *Tx) error {
+	return h.do(ctx, func() error {
+		return h.hooks.rollback(c, ctx, tx)
+	})
+}
+
+func (h *conditionalHooks) postRollback(c context.Context, ctx interface{}, tx *Tx, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postRollback(c, ctx, tx, err)
+	})
+}
+
+func (h *conditionalHooks) preClose(c context.Context, conn *Conn) (interface{}, error) {
+	return h.preDo(c, OpClose, "", func() (interface{}, error) {
+		return h.hooks.preClose(c, conn)
+	})
+}
+
+func (h *conditionalHooks) close(c context.Context, ctx interface{}, conn *Conn) error {
+	return h.do(ctx, func() error {
+		return h.hooks.close(c, ctx, conn)
+	})
+}
+
+func (h *conditionalHooks) postClose(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postClose(c, ctx, conn, err)
+	})
+}
+
+func (h *conditionalHooks) preResetSession(c context.Context, conn *Conn) (interface{}, error) {
+	return h.preDo(c, OpResetSession, "", func() (interface{}, error) {
+		return h.hooks.preResetSession(c, conn)
+	})
+}
+
+func (h *conditionalHooks) resetSession(c context.Context, ctx interface{}, conn *Conn) error {
+	return h.do(ctx, func() error {
+		return h.hooks.resetSession(c, ctx, conn)
+	})
+}
+
+func (h *conditionalHooks) postResetSession(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postResetSession(c, ctx, conn, err)
+	})
+}
+
+func (h *conditionalHooks) preIsValid(conn *Conn) (interface{}, error) {
+	return h.preDo(context.Background(), OpIsValid, "", func() (interface{}, error) {
+		return h.hooks.preIsValid(conn)
+	})
+}
+
+func (h *conditionalHooks) isValid(ctx interface{}, conn *Conn) error {
+	return h.do(ctx, func() error {
+		return h.hooks.isValid(ctx, conn)
+	})
+}
+
+func (h *conditionalHooks) postIsValid(ctx interface{}, conn *Conn, valid bool) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postIsValid(ctx, conn, valid)
+	})
+}
+
+func (h *conditionalHooks) preStmtClose(c context.Context, stmt *Stmt) (interface{}, error) {
+	return h.preDo(c, OpStmtClose, stmt.query(), func() (interface{}, error) {
+		return h.hooks.preStmtClose(c, stmt)
+	})
+}
+
+func (h *conditionalHooks) stmtClose(c context.Context, ctx interface{}, stmt *Stmt) error {
+	return h.do(ctx, func() error {
+		return h.hooks.stmtClose(c, ctx, stmt)
+	})
+}
+
+func (h *conditionalHooks) postStmtClose(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postStmtClose(c, ctx, stmt, err)
+	})
+}
+
+func (h *conditionalHooks) preRowsClose(c context.Context, rows *Rows) (interface{}, error) {
+	return h.preDo(c, OpRowsClose, rows.query(), func() (interface{}, error) {
+		return h.hooks.preRowsClose(c, rows)
+	})
+}
+
+func (h *conditionalHooks) rowsClose(c context.Context, ctx interface{}, rows *Rows) error {
+	return h.do(ctx, func() error {
+		return h.hooks.rowsClose(c, ctx, rows)
+	})
+}
+
+func (h *conditionalHooks) postRowsClose(c context.Context, ctx interface{}, rows *Rows, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postRowsClose(c, ctx, rows, err)
+	})
+}
+
+func (h *conditionalHooks) postRows(c context.Context, rows *Rows, count int64, d time.Duration) error {
+	if !h.pred(c, OpQuery, rows.query()) {
+		return nil
+	}
+	return h.hooks.postRows(c, rows, count, d)
+}
+
+func (h *conditionalHooks) postLastInsertId(c context.Context, result *Result, id int64, err error) error {
+	if !h.pred(c, OpExec, result.query()) {
+		return nil
+	}
+	return h.hooks.postLastInsertId(c, result, id, err)
+}
+
+func (h *conditionalHooks) postRowsAffected(c context.Context, result *Result, n int64, err error) error {
+	if !h.pred(c, OpExec, result.query()) {
+		return nil
+	}
+	return h.hooks.postRowsAffected(c, result, n, err)
+}
+
+func (h *conditionalHooks) preConnect(c context.Context, connector *Connector) (interface{}, error) {
+	return h.preDo(c, OpOpen, "", func() (interface{}, error) {
+		return h.hooks.preConnect(c, connector)
+	})
+}
+
+func (h *conditionalHooks) connect(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error {
+	return h.do(ctx, func() error {
+		return h.hooks.connect(c, ctx, connector, conn)
+	})
+}
+
+func (h *conditionalHooks) postConnect(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postConnect(c, ctx, connector, conn, err)
+	})
+}
+
+func (h *conditionalHooks) preConnectorClose(c context.Context, connector *Connector) (interface{}, error) {
+	return h.preDo(c, OpConnectorClose, "", func() (interface{}, error) {
+		return h.hooks.preConnectorClose(c, connector)
+	})
+}
+
+func (h *conditionalHooks) postConnectorClose(c context.Context, ctx interface{}, connector *Connector, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postConnectorClose(c, ctx, connector, err)
+	})
+}
+
+func (h *conditionalHooks) onCanceled(c context.Context, op Operation, d time.Duration, err error) error {
+	if !h.pred(c, op, "") {
+		return nil
+	}
+	return h.hooks.onCanceled(c, op, d, err)
+}
+
+func (h *conditionalHooks) columns(c context.Context, rows *Rows) error {
+	if !h.pred(c, OpQuery, rows.query()) {
+		return nil
+	}
+	return h.hooks.columns(c, rows)
+}
+
+func (h *conditionalHooks) preSavepoint(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error) {
+	return h.preDo(c, OpExec, stmt.query(), func() (interface{}, error) {
+		return h.hooks.preSavepoint(c, stmt, sp)
+	})
+}
+
+func (h *conditionalHooks) savepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error {
+	return h.do(ctx, func() error {
+		return h.hooks.savepoint(c, ctx, stmt, sp)
+	})
+}
+
+func (h *conditionalHooks) postSavepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error {
+	return h.do(ctx, func() error {
+		return h.hooks.postSavepoint(c, ctx, stmt, sp, err)
+	})
+}
+
+func (h *conditionalHooks) columnConverter(stmt *Stmt, idx int, conv driver.ValueConverter) driver.ValueConverter {
+	if !h.pred(context.Background(), OpExec, stmt.query()) {
+		return conv
+	}
+	return h.hooks.columnConverter(stmt, idx, conv)
+}
+
+func (h *conditionalHooks) oncePerConn(c context.Context, conn *Conn) error {
+	if !h.pred(c, OpInitConn, "") {
+		return nil
+	}
+	return h.hooks.oncePerConn(c, conn)
+}
+
+func (h *conditionalHooks) rewriteDSN(c context.Context, name string) (string, error) {
+	if !h.pred(c, OpOpen, "") {
+		return name, nil
+	}
+	return h.hooks.rewriteDSN(c, name)
+}
+
+// mapErrorHooks converts the errors returned by the hooks.
+type mapErrorHooks struct {
+	f     func(err error) error
+	hooks hooks
+}
+
+func (h *mapErrorHooks) mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return h.f(err)
+}
+
+func (h *mapErrorHooks) prePing(c context.Context, conn *Conn) (interface{}, error) {
+	ctx, err := h.hooks.prePing(c, conn)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) ping(c context.Context, ctx interface{}, conn *Conn) error {
+	return h.mapError(h.hooks.ping(c, ctx, conn))
+}
+
+func (h *mapErrorHooks) postPing(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return h.mapError(h.hooks.postPing(c, ctx, conn, err))
+}
+
+func (h *mapErrorHooks) preOpen(c context.Context, name string) (interface{}, error) {
+	ctx, err := h.hooks.preOpen(c, name)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) open(c context.Context, ctx interface{}, conn *Conn) error {
+	return h.mapError(h.hooks.open(c, ctx, conn))
+}
+
+func (h *mapErrorHooks) postOpen(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return h.mapError(h.hooks.postOpen(c, ctx, conn, err))
+}
+
+func (h *mapErrorHooks) prePrepare(c context.Context, stmt *Stmt) (interface{}, error) {
+	ctx, err := h.hooks.prePrepare(c, stmt)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) prepare(c context.Context, ctx interface{}, stmt *Stmt) error {
+	return h.mapError(h.hooks.prepare(c, ctx, stmt))
+}
+
+func (h *mapErrorHooks) postPrepare(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+	return h.mapError(h.hooks.postPrepare(c, ctx, stmt, err))
+}
+
+func (h *mapErrorHooks) preExec(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+	ctx, err := h.hooks.preExec(c, stmt, args)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) exec(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result) error {
+	return h.mapError(h.hooks.exec(c, ctx, stmt, args, result))
+}
+
+func (h *mapErrorHooks) postExec(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+	return h.mapError(h.hooks.postExec(c, ctx, stmt, args, result, err))
+}
+
+func (h *mapErrorHooks) preQuery(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+	ctx, err := h.hooks.preQuery(c, stmt, args)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) query(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows) error {
+	return h.mapError(h.hooks.query(c, ctx, stmt, args, rows))
+}
+
+func (h *mapErrorHooks) postQuery(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+	return h.mapError(h.hooks.postQuery(c, ctx, stmt, args, rows, err))
+}
+
+func (h *mapErrorHooks) preBegin(c context.Context, conn *Conn) (interface{}, error) {
+	ctx, err := h.hooks.preBegin(c, conn)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) begin(c context.Context, ctx interface{}, conn *Conn) error {
+	return h.mapError(h.hooks.begin(c, ctx, conn))
+}
+
+func (h *mapErrorHooks) postBegin(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return h.mapError(h.hooks.postBegin(c, ctx, conn, err))
+}
+
+func (h *mapErrorHooks) preCommit(c context.Context, tx *Tx) (interface{}, error) {
+	ctx, err := h.hooks.preCommit(c, tx)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) commit(c context.Context, ctx interface{}, tx *Tx) error {
+	return h.mapError(h.hooks.commit(c, ctx, tx))
+}
+
+func (h *mapErrorHooks) postCommit(c context.Context, ctx interface{}, tx *Tx, err error) error {
+	return h.mapError(h.hooks.postCommit(c, ctx, tx, err))
+}
+
+func (h *mapErrorHooks) preRollback(c context.Context, tx *Tx) (interface{}, error) {
+	ctx, err := h.hooks.preRollback(c, tx)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) rollback(c context.Context, ctx interface{}, tx *Tx) error {
+	return h.mapError(h.hooks.rollback(c, ctx, tx))
+}
+
+func (h *mapErrorHooks) postRollback(c context.Context, ctx interface{}, tx *Tx, err error) error {
+	return h.mapError(h.hooks.postRollback(c, ctx, tx, err))
+}
+
+func (h *mapErrorHooks) preClose(c context.Context, conn *Conn) (interface{}, error) {
+	ctx, err := h.hooks.preClose(c, conn)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) close(c context.Context, ctx interface{}, conn *Conn) error {
+	return h.mapError(h.hooks.close(c, ctx, conn))
+}
+
+func (h *mapErrorHooks) postClose(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return h.mapError(h.hooks.postClose(c, ctx, conn, err))
+}
+
+func (h *mapErrorHooks) preResetSession(c context.Context, conn *Conn) (interface{}, error) {
+	ctx, err := h.hooks.preResetSession(c, conn)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) resetSession(c context.Context, ctx interface{}, conn *Conn) error {
+	return h.mapError(h.hooks.resetSession(c, ctx, conn))
+}
+
+func (h *mapErrorHooks) postResetSession(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return h.mapError(h.hooks.postResetSession(c, ctx, conn, err))
+}
+
+func (h *mapErrorHooks) preIsValid(conn *Conn) (interface{}, error) {
+	ctx, err := h.hooks.preIsValid(conn)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) isValid(ctx interface{}, conn *Conn) error {
+	return h.mapError(h.hooks.isValid(ctx, conn))
+}
+
+func (h *mapErrorHooks) postIsValid(ctx interface{}, conn *Conn, valid bool) error {
+	return h.mapError(h.hooks.postIsValid(ctx, conn, valid))
+}
+
+func (h *mapErrorHooks) preStmtClose(c context.Context, stmt *Stmt) (interface{}, error) {
+	ctx, err := h.hooks.preStmtClose(c, stmt)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) stmtClose(c context.Context, ctx interface{}, stmt *Stmt) error {
+	return h.mapError(h.hooks.stmtClose(c, ctx, stmt))
+}
+
+func (h *mapErrorHooks) postStmtClose(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+	return h.mapError(h.hooks.postStmtClose(c, ctx, stmt, err))
+}
+
+func (h *mapErrorHooks) preRowsClose(c context.Context, rows *Rows) (interface{}, error) {
+	ctx, err := h.hooks.preRowsClose(c, rows)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) rowsClose(c context.Context, ctx interface{}, rows *Rows) error {
+	return h.mapError(h.hooks.rowsClose(c, ctx, rows))
+}
+
+func (h *mapErrorHooks) postRowsClose(c context.Context, ctx interface{}, rows *Rows, err error) error {
+	return h.mapError(h.hooks.postRowsClose(c, ctx, rows, err))
+}
+
+func (h *mapErrorHooks) postRows(c context.Context, rows *Rows, count int64, d time.Duration) error {
+	return h.mapError(h.hooks.postRows(c, rows, count, d))
+}
+
+func (h *mapErrorHooks) postLastInsertId(c context.Context, result *Result, id int64, err error) error {
+	return h.mapError(h.hooks.postLastInsertId(c, result, id, err))
+}
+
+func (h *mapErrorHooks) postRowsAffected(c context.Context, result *Result, n int64, err error) error {
+	return h.mapError(h.hooks.postRowsAffected(c, result, n, err))
+}
+
+func (h *mapErrorHooks) preConnect(c context.Context, connector *Connector) (interface{}, error) {
+	ctx, err := h.hooks.preConnect(c, connector)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) connect(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error {
+	return h.mapError(h.hooks.connect(c, ctx, connector, conn))
+}
+
+func (h *mapErrorHooks) postConnect(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error {
+	return h.mapError(h.hooks.postConnect(c, ctx, connector, conn, err))
+}
+
+func (h *mapErrorHooks) preConnectorClose(c context.Context, connector *Connector) (interface{}, error) {
+	ctx, err := h.hooks.preConnectorClose(c, connector)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) postConnectorClose(c context.Context, ctx interface{}, connector *Connector, err error) error {
+	return h.mapError(h.hooks.postConnectorClose(c, ctx, connector, err))
+}
+
+func (h *mapErrorHooks) onCanceled(c context.Context, op Operation, d time.Duration, err error) error {
+	return h.mapError(h.hooks.onCanceled(c, op, d, err))
+}
+
+func (h *mapErrorHooks) columns(c context.Context, rows *Rows) error {
+	return h.mapError(h.hooks.columns(c, rows))
+}
+
+func (h *mapErrorHooks) preSavepoint(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error) {
+	ctx, err := h.hooks.preSavepoint(c, stmt, sp)
+	return ctx, h.mapError(err)
+}
+
+func (h *mapErrorHooks) savepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error {
+	return h.mapError(h.hooks.savepoint(c, ctx, stmt, sp))
+}
+
+func (h *mapErrorHooks) postSavepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error {
+	return h.mapError(h.hooks.postSavepoint(c, ctx, stmt, sp, err))
+}
+
+func (h *mapErrorHooks) columnConverter(stmt *Stmt, idx int, conv driver.ValueConverter) driver.ValueConverter {
+	return h.hooks.columnConverter(stmt, idx, conv)
+}
+
+func (h *mapErrorHooks) oncePerConn(c context.Context, conn *Conn) error {
+	return h.mapError(h.hooks.oncePerConn(c, conn))
+}
+
+func (h *mapErrorHooks) rewriteDSN(c context.Context, name string) (string, error) {
+	name, err := h.hooks.rewriteDSN(c, name)
+	return name, h.mapError(err)
+}
@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newErrorHooksContext(err error) *HooksContext {
+	return &HooksContext{
+		PrePing: func(c context.Context, conn *Conn) (interface{}, error) {
+			return nil, err
+		},
+		Ping: func(c context.Context, ctx interface{}, conn *Conn) error {
+			return err
+		},
+		PostPing: func(c context.Context, ctx interface{}, conn *Conn, err0 error) error {
+			return err
+		},
+	}
+}
+
+func TestCompose(t *testing.T) {
+	hooks1, ctx1 := newTestHooksContext(t)
+	hooks2, ctx2 := newTestHooksContext(t)
+	hooks := Compose(hooks1, nil, hooks2)
+	testHooksInterface(t, hooks, &multipleHooksContext{values: []interface{}{ctx1, ctx2}})
+
+	// empty Compose has no effect.
+	testHooksInterface(t, Compose(), nil)
+}
+
+func TestComposeHooks(t *testing.T) {
+	var got []string
+	newHooks := func(name string, priority int, err error) *HooksContext {
+		return &HooksContext{
+			Priority: priority,
+			PreExec: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+				got = append(got, "PreExec "+name)
+				return name, err
+			},
+			PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err0 error) error {
+				got = append(got, fmt.Sprintf("PostExec %s %v %v", name, ctx, err0))
+				return err
+			},
+		}
+	}
+	errB := errors.New("error b")
+	hooks := ComposeHooks(newHooks("a", 0, nil), nil, newHooks("b", 0, errB), newHooks("c", 1, nil))
+
+	ctx, err := hooks.preExec(context.Background(), nil, nil)
+	if err != errB {
+		t.Errorf("want %v, got %v", errB, err)
+	}
+	if err := hooks.postExec(context.Background(), ctx, nil, nil, nil, nil); err != errB {
+		t.Errorf("want %v, got %v", errB, err)
+	}
+	want := []string{
+		"PreExec c", "PreExec a", "PreExec b",
+		"PostExec b b <nil>", "PostExec a a error b", "PostExec c c error b",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestWhen(t *testing.T) {
+	t.Run("true", func(t *testing.T) {
+		hooks, ctx0 := newTestHooksContext(t)
+		testHooksInterface(t, When(func(c context.Context) bool { return true }, hooks), ctx0)
+	})
+
+	t.Run("false", func(t *testing.T) {
+		hooks := newErrorHooksContext(errors.New("never returned"))
+		testHooksInterface(t, When(func(c context.Context) bool { return false }, hooks), skippedContext{})
+	})
+}
+
+func TestWhere(t *testing.T) {
+	var got []string
+	onlySelect := func(c context.Context, op Operation, query string) bool {
+		return op == OpQuery && strings.HasPrefix(query, "SELECT")
+	}
+	p := NewProxyContext(fdriver, Where(onlySelect, &HooksContext{
+		PreExec: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+			got = append(got, "PreExec "+stmt.QueryString)
+			return nil, nil
+		},
+		PreQuery: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+			got = append(got, "PreQuery "+stmt.QueryString)
+			return nil, nil
+		},
+		PostQuery: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+			got = append(got, "PostQuery "+stmt.QueryString)
+			return nil
+		},
+	}))
+	conn := newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+	c := context.Background()
+
+	if _, err := conn.ExecContext(c, "INSERT INTO t1 (id) VALUES(1)", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.QueryContext(c, "SELECT id FROM t1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.QueryContext(c, "SHOW TABLES", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"PreQuery SELECT id FROM t1", "PostQuery SELECT id FROM t1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestTap(t *testing.T) {
+	hooks := Tap(newErrorHooksContext(errors.New("ignored")))
+	testHooksInterface(t, hooks, nil)
+}
+
+func TestMapError(t *testing.T) {
+	errOriginal := errors.New("original error")
+	errMapped := errors.New("mapped error")
+	hooks := MapError(newErrorHooksContext(errOriginal), func(err error) error {
+		if err != errOriginal {
+			t.Errorf("want %v, got %v", errOriginal, err)
+		}
+		return errMapped
+	})
+
+	c := context.Background()
+	if _, err := hooks.prePing(c, nil); err != errMapped {
+		t.Errorf("want %v, got %v", errMapped, err)
+	}
+	if err := hooks.ping(c, nil, nil); err != errMapped {
+		t.Errorf("want %v, got %v", errMapped, err)
+	}
+	if err := hooks.postPing(c, nil, nil, nil); err != errMapped {
+		t.Errorf("want %v, got %v", errMapped, err)
+	}
+
+	// nil errors are not passed to the function.
+	if _, err := hooks.preOpen(c, ""); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+}
@@ -2,28 +2,144 @@ package proxy
 
 import (
 	"context"
-	"database/sql"
 	"database/sql/driver"
-	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Conn adds hook points into "database/sql/driver".Conn.
 type Conn struct {
 	Conn  driver.Conn
 	Proxy *Proxy
+
+	id   int64
+	txID int64 // the ID of the running transaction
+
+	// initMu guards initialized, and serializes the OncePerConn hooks.
+	initMu      sync.Mutex
+	initialized bool
+
+	// release returns the borrowed connection instead of closing it.
+	// See InstrumentDB.
+	release func() error
+
+	// hooks is the hook sets of the connection, which are merged when it is opened.
+	hooks *connHooks
+
+	// openStats is the statistics which count conn as an open connection.
+	openStats *proxyStats
+
+	// callbacks tracks the callbacks running in other goroutines. See HookTimingOptions.Timeout.
+	callbacks       sync.WaitGroup
+	pendingCallback int32
+}
+
+func newConn(conn driver.Conn, p *Proxy) *Conn {
+	return &Conn{
+		Conn:  conn,
+		Proxy: p,
+		id:    p.newConnID(),
+	}
+}
+
+// connHooks returns the hook sets of the connection, or nil if conn is nil.
+func (conn *Conn) connHooks() *connHooks {
+	if conn == nil {
+		return nil
+	}
+	return conn.hooks
+}
+
+// ID returns the ID of the connection.
+// It is assigned when the connection is opened, and is unique in the process.
+// It is also reported by ConnIDFromContext and the tracing proxy.
+func (conn *Conn) ID() int64 {
+	return conn.id
+}
+
+// withMetadata returns a copy of c in which the IDs of the connection, the transaction and the operation associated.
+func (conn *Conn) withMetadata(c context.Context) context.Context {
+	return withMetadata(c, metadata{
+		connID: conn.id,
+		txID:   conn.txID,
+		opID:   newOpID(),
+	})
+}
+
+// init calls the OncePerConn hooks if the connection is not initialized yet.
+func (conn *Conn) init(c context.Context) error {
+	conn.initMu.Lock()
+	defer conn.initMu.Unlock()
+	if conn.initialized {
+		return nil
+	}
+	if hooks := conn.Proxy.getHooks(c, OpInitConn, conn.hooks); hooks != nil {
+		if err := hooks.oncePerConn(conn.withMetadata(c), conn); err != nil {
+			return err
+		}
+	}
+	conn.initialized = true
+	return nil
+}
+
+// closeDriverConn closes the underlying connection, or returns it if it is borrowed.
+func (conn *Conn) closeDriverConn() error {
+	if conn.hasPendingCallbacks() {
+		// the callbacks that timed out may still use the connection.
+		go func() {
+			conn.callbacks.Wait()
+			conn.closeDriverConnNow()
+		}()
+		return nil
+	}
+	return conn.closeDriverConnNow()
+}
+
+func (conn *Conn) closeDriverConnNow() error {
+	if conn.release != nil {
+		return conn.release()
+	}
+	return conn.Conn.Close()
+}
+
+// acquireCallback marks a callback running in another goroutine, and returns the function to unmark it.
+// It is safe to call it with nil conn.
+func (conn *Conn) acquireCallback() (release func()) {
+	if conn == nil {
+		return func() {}
+	}
+	atomic.AddInt32(&conn.pendingCallback, 1)
+	conn.callbacks.Add(1)
+	return func() {
+		atomic.AddInt32(&conn.pendingCallback, -1)
+		conn.callbacks.Done()
+	}
+}
+
+// hasPendingCallbacks reports whether any callbacks are still running in other goroutines.
+func (conn *Conn) hasPendingCallbacks() bool {
+	return atomic.LoadInt32(&conn.pendingCallback) > 0
 }
 
 // Ping verifies a connection to the database is still alive.
 // It will trigger PrePing, Ping, PostPing hooks.
 //
 // If the original connection does not satisfy "database/sql/driver".Pinger, it does nothing.
-func (conn *Conn) Ping(c context.Context) error {
-	var err error
+func (conn *Conn) Ping(c context.Context) (err error) {
+	if err := conn.init(c); err != nil {
+		return err
+	}
 	var ctx interface{}
-	hooks := conn.Proxy.getHooks(c)
+	start := time.Now()
+	defer conn.Proxy.stats.observe(OpPing, start, &err)
+	defer conn.Proxy.wrapError(&err, OpPing, conn, "", nil, start)
+	hooks := conn.Proxy.getHooks(c, OpPing, conn.hooks)
 
 	if hooks != nil {
-		defer func() { hooks.postPing(c, ctx, conn, err) }()
+		c = conn.withMetadata(c)
+		defer func() { conn.Proxy.postError(&err, hooks.postPing(withDuration(c, start), ctx, conn, err)) }()
+		defer func() { notifyCanceled(c, hooks, OpPing, start, err) }()
 		if ctx, err = hooks.prePing(c, conn); err != nil {
 			return err
 		}
@@ -43,41 +159,42 @@ func (conn *Conn) Ping(c context.Context) error {
 }
 
 // Prepare returns a prepared statement which is wrapped by Stmt.
-// NOT SUPPORTED: use PrepareContext instead
+// It is the same as PrepareContext with the background context.
 func (conn *Conn) Prepare(query string) (driver.Stmt, error) {
-	panic("not supported")
+	return conn.PrepareContext(context.Background(), query)
 }
 
 // PrepareContext returns a prepared statement which is wrapped by Stmt.
-func (conn *Conn) PrepareContext(c context.Context, query string) (driver.Stmt, error) {
+func (conn *Conn) PrepareContext(c context.Context, query string) (ret driver.Stmt, err error) {
+	if err := conn.init(c); err != nil {
+		return nil, err
+	}
 	var ctx interface{}
 	var stmt = &Stmt{
 		QueryString: query,
 		Proxy:       conn.Proxy,
 		Conn:        conn,
+		prepared:    true,
 	}
-	var err error
-	hooks := conn.Proxy.getHooks(c)
+	start := time.Now()
+	defer conn.Proxy.stats.observe(OpPrepare, start, &err)
+	defer conn.Proxy.wrapError(&err, OpPrepare, conn, query, nil, start)
+	hooks := conn.Proxy.getHooks(c, OpPrepare, conn.hooks)
 	if hooks != nil {
-		defer func() { hooks.postPrepare(c, ctx, stmt, err) }()
+		c = conn.withMetadata(c)
+		defer func() {
+			if conn.Proxy.postError(&err, hooks.postPrepare(withDuration(c, start), ctx, stmt, err)) {
+				stmt.Stmt.Close()
+				ret = nil
+			}
+		}()
+		defer func() { notifyCanceled(c, hooks, OpPrepare, start, err) }()
 		if ctx, err = hooks.prePrepare(c, stmt); err != nil {
 			return nil, err
 		}
 	}
 
-	if connCtx, ok := conn.Conn.(driver.ConnPrepareContext); ok {
-		stmt.Stmt, err = connCtx.PrepareContext(c, stmt.QueryString)
-	} else {
-		stmt.Stmt, err = conn.Conn.Prepare(stmt.QueryString)
-		if err == nil {
-			select {
-			default:
-			case <-c.Done():
-				stmt.Stmt.Close()
-				return nil, c.Err()
-			}
-		}
-	}
+	stmt.Stmt, err = conn.Proxy.interceptors.prepare(prepareConn)(c, stmt)
 	if err != nil {
 		return nil, err
 	}
@@ -91,24 +208,29 @@ func (conn *Conn) PrepareContext(c context.Context, query string) (driver.Stmt,
 }
 
 // Close calls the original Close method.
-func (conn *Conn) Close() error {
+func (conn *Conn) Close() (err error) {
 	ctx := context.Background()
-	var err error
 	var myctx interface{}
+	start := time.Now()
+	defer conn.Proxy.stats.observe(OpClose, start, &err)
+	// the connection is discarded even if it fails to close.
+	defer conn.countClose()
 
-	if hooks := conn.Proxy.hooks; hooks != nil {
-		defer func() { hooks.postClose(ctx, myctx, conn, err) }()
+	hooks := conn.Proxy.hooksFor(OpClose, conn.hooks)
+	if hooks != nil {
+		ctx = conn.withMetadata(ctx)
+		defer func() { conn.Proxy.postError(&err, hooks.postClose(withDuration(ctx, start), myctx, conn, err)) }()
 		if myctx, err = hooks.preClose(ctx, conn); err != nil {
 			return err
 		}
 	}
 
-	err = conn.Conn.Close()
+	err = conn.closeDriverConn()
 	if err != nil {
 		return err
 	}
 
-	if hooks := conn.Proxy.hooks; hooks != nil {
+	if hooks != nil {
 		err = hooks.close(ctx, myctx, conn)
 	}
 	return err
@@ -116,122 +238,127 @@ func (conn *Conn) Close() error {
 
 // Begin starts and returns a new transaction which is wrapped by Tx.
 // It will trigger PreBegin, Begin, PostBegin hooks.
-// NOT SUPPORTED: use BeginContext instead
+// It is the same as BeginTx with the background context and the default options.
 func (conn *Conn) Begin() (driver.Tx, error) {
-	panic("not supported")
+	return conn.BeginTx(context.Background(), driver.TxOptions{})
 }
 
 // BeginTx starts and returns a new transaction which is wrapped by Tx.
 // It will trigger PreBegin, Begin, PostBegin hooks.
-func (conn *Conn) BeginTx(c context.Context, opts driver.TxOptions) (driver.Tx, error) {
+// The hooks can get opts by TxOptionsFromContext.
+func (conn *Conn) BeginTx(c context.Context, opts driver.TxOptions) (ret driver.Tx, err error) {
+	if err := conn.init(c); err != nil {
+		return nil, err
+	}
 	// set the hooks.
-	var err error
 	var ctx interface{}
 	var tx driver.Tx
-	hooks := conn.Proxy.getHooks(c)
+	start := time.Now()
+	defer conn.Proxy.stats.observe(OpBegin, start, &err)
+	defer conn.Proxy.wrapError(&err, OpBegin, conn, "", nil, start)
+	hooks := conn.Proxy.getHooks(c, OpBegin, conn.hooks)
 	if hooks != nil {
-		defer func() { hooks.postBegin(c, ctx, conn, err) }()
+		c = withTxOptions(conn.withMetadata(c), opts)
+		defer func() {
+			if conn.Proxy.postError(&err, hooks.postBegin(withDuration(c, start), ctx, conn, err)) {
+				conn.txID = 0
+				tx.Rollback()
+				ret = nil
+			}
+		}()
+		defer func() { notifyCanceled(c, hooks, OpBegin, start, err) }()
 		if ctx, err = hooks.preBegin(c, conn); err != nil {
 			return nil, err
 		}
 	}
 
 	// call the original method.
-	if connCtx, ok := conn.Conn.(driver.ConnBeginTx); ok {
-		tx, err = connCtx.BeginTx(c, opts)
-	} else {
-		if c.Done() != context.Background().Done() {
-			// the original driver does not support non-default transaction options.
-			// so return error if non-default transaction is requested.
-			if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
-				return nil, errors.New("proxy: driver does not support non-default isolation level")
-			}
-			if opts.ReadOnly {
-				return nil, errors.New("proxy: driver does not support read-only transactions")
-			}
-		}
-		tx, err = conn.Conn.Begin()
-		if err == nil {
-			// check the context is already done.
-			select {
-			default:
-			case <-c.Done():
-				tx.Rollback()
-				err = c.Err()
-			}
-		}
-	}
+	tx, err = conn.Proxy.interceptors.begin(beginConn)(c, conn, opts)
 	if err != nil {
 		return nil, err
 	}
 
+	myTx := &Tx{
+		Tx:    tx,
+		Proxy: conn.Proxy,
+		Conn:  conn,
+		id:    newTxID(),
+		start: start,
+	}
+	conn.txID = myTx.id
 	if hooks != nil {
+		c = withTxID(c, myTx.id)
 		if err = hooks.begin(c, ctx, conn); err != nil {
+			conn.txID = 0
 			tx.Rollback()
 			return nil, err
 		}
 	}
+	myTx.ctx = c
 
-	return &Tx{
-		Tx:    tx,
-		Proxy: conn.Proxy,
-		Conn:  conn,
-		ctx:   c,
-	}, nil
+	return myTx, nil
 }
 
 // Exec calls the original Exec method of the connection.
 // It will trigger PreExec, Exec, PostExec hooks.
 //
 // If the original connection does not satisfy "database/sql/driver".Execer, it return ErrSkip error.
-// NOT SUPPORTED: use ExecContext instead
+// It is the same as ExecContext with the background context.
 func (conn *Conn) Exec(query string, args []driver.Value) (driver.Result, error) {
-	panic("not supported")
+	return conn.ExecContext(context.Background(), query, valuesToNamedValues(args))
 }
 
 // ExecContext calls the original ExecContext (or Exec as a fallback) method of the connection.
 // It will trigger PreExec, Exec, PostExec hooks.
 //
 // If the original connection does not satisfy "database/sql/driver".ExecerContext nor "database/sql/driver".Execer, it return ErrSkip error.
-func (conn *Conn) ExecContext(c context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
-	execer, exOk := conn.Conn.(driver.Execer)
-	execerCtx, exCtxOk := conn.Conn.(driver.ExecerContext)
-	if !exOk && !exCtxOk {
+func (conn *Conn) ExecContext(c context.Context, query string, args []driver.NamedValue) (ret driver.Result, err error) {
+	if !conn.canExec() {
 		return nil, driver.ErrSkip
 	}
-
+	if err := conn.init(c); err != nil {
+		return nil, err
+	}
 	// set the hooks.
 	var stmt = &Stmt{
 		QueryString: query,
 		Proxy:       conn.Proxy,
 		Conn:        conn,
 	}
+	stmt.use()
 	var ctx interface{}
-	var err error
 	var result driver.Result
-	hooks := conn.Proxy.getHooks(c)
+	var spctx interface{}
+	start := time.Now()
+	defer conn.Proxy.stats.observe(OpExec, start, &err)
+	defer conn.Proxy.wrapError(&err, OpExec, conn, query, args, start)
+	hooks := conn.Proxy.getHooks(c, OpExec, conn.hooks)
 	if hooks != nil {
-		defer func() { hooks.postExec(c, ctx, stmt, args, result, err) }()
+		c = conn.withMetadata(c)
+		if stmt.savepoint = ParseSavepoint(stmt.QueryString); stmt.savepoint != nil {
+			sp := stmt.savepoint
+			defer func() {
+				if conn.Proxy.postError(&err, hooks.postSavepoint(withDuration(c, start), spctx, stmt, sp, err)) {
+					ret = nil
+				}
+			}()
+			if spctx, err = hooks.preSavepoint(c, stmt, sp); err != nil {
+				return nil, err
+			}
+		}
+		defer func() {
+			if conn.Proxy.postError(&err, hooks.postExec(withDuration(c, start), ctx, stmt, args, result, err)) {
+				ret = nil
+			}
+		}()
+		defer func() { notifyCanceled(c, hooks, OpExec, start, err) }()
 		if ctx, err = hooks.preExec(c, stmt, args); err != nil {
 			return nil, err
 		}
 	}
 
 	// call the original method.
-	if execerCtx != nil {
-		result, err = execerCtx.ExecContext(c, stmt.QueryString, args)
-	} else {
-		select {
-		default:
-		case <-c.Done():
-			return nil, c.Err()
-		}
-		dargs, err0 := namedValuesToValues(args)
-		if err0 != nil {
-			return nil, err0
-		}
-		result, err = execer.Exec(stmt.QueryString, dargs)
-	}
+	result, err = conn.Proxy.interceptors.exec(execConn)(c, stmt, args)
 	if err != nil {
 		return nil, err
 	}
@@ -240,6 +367,14 @@ func (conn *Conn) ExecContext(c context.Context, query string, args []driver.Nam
 		if err = hooks.exec(c, ctx, stmt, args, result); err != nil {
 			return nil, err
 		}
+		if stmt.savepoint != nil {
+			if err = hooks.savepoint(c, spctx, stmt, stmt.savepoint); err != nil {
+				return nil, err
+			}
+		}
+		if result != nil {
+			return newResult(c, hooks, stmt, result), nil
+		}
 	}
 
 	return result, nil
@@ -249,53 +384,50 @@ func (conn *Conn) ExecContext(c context.Context, query string, args []driver.Nam
 // It wil trigger PreQuery, Query, PostQuery hooks.
 //
 // If the original connection does not satisfy "database/sql/driver".Queryer, it return ErrSkip error.
-// NOT SUPPORTED: use QueryContext instead
+// It is the same as QueryContext with the background context.
 func (conn *Conn) Query(query string, args []driver.Value) (driver.Rows, error) {
-	panic("not supported")
+	return conn.QueryContext(context.Background(), query, valuesToNamedValues(args))
 }
 
 // QueryContext executes a query that may return rows.
 // It wil trigger PreQuery, Query, PostQuery hooks.
 //
 // If the original connection does not satisfy "database/sql/driver".QueryerContext nor "database/sql/driver".Queryer, it return ErrSkip error.
-func (conn *Conn) QueryContext(c context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
-	queryer, qok := conn.Conn.(driver.Queryer)
-	queryerCtx, qCtxOk := conn.Conn.(driver.QueryerContext)
-	if !qok && !qCtxOk {
+func (conn *Conn) QueryContext(c context.Context, query string, args []driver.NamedValue) (ret driver.Rows, err error) {
+	if !conn.canQuery() {
 		return nil, driver.ErrSkip
 	}
-
+	if err := conn.init(c); err != nil {
+		return nil, err
+	}
 	var stmt = &Stmt{
 		QueryString: query,
 		Proxy:       conn.Proxy,
 		Conn:        conn,
 	}
+	stmt.use()
 	var ctx interface{}
-	var err error
 	var rows driver.Rows
-	hooks := conn.Proxy.getHooks(c)
+	start := time.Now()
+	defer conn.Proxy.stats.observe(OpQuery, start, &err)
+	defer conn.Proxy.wrapError(&err, OpQuery, conn, query, args, start)
+	hooks := conn.Proxy.getHooks(c, OpQuery, conn.hooks)
 	if hooks != nil {
-		defer func() { hooks.postQuery(c, ctx, stmt, args, rows, err) }()
+		c = conn.withMetadata(c)
+		defer func() {
+			if conn.Proxy.postError(&err, hooks.postQuery(withDuration(c, start), ctx, stmt, args, rows, err)) {
+				rows.Close()
+				ret = nil
+			}
+		}()
+		defer func() { notifyCanceled(c, hooks, OpQuery, start, err) }()
 		if ctx, err = hooks.preQuery(c, stmt, args); err != nil {
 			return nil, err
 		}
 	}
 
 	// call the original method.
-	if queryerCtx != nil {
-		rows, err = queryerCtx.QueryContext(c, stmt.QueryString, args)
-	} else {
-		select {
-		default:
-		case <-c.Done():
-			return nil, c.Err()
-		}
-		dargs, err0 := namedValuesToValues(args)
-		if err0 != nil {
-			return nil, err0
-		}
-		rows, err = queryer.Query(stmt.QueryString, dargs)
-	}
+	rows, err = conn.Proxy.interceptors.query(queryConn)(c, stmt, args)
 	if err != nil {
 		return nil, err
 	}
@@ -305,11 +437,43 @@ func (conn *Conn) QueryContext(c context.Context, query string, args []driver.Na
 			rows.Close()
 			return nil, err
 		}
+		myrows := newRows(c, hooks, stmt, args, rows, start)
+		if err = hooks.columns(c, myrows); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		return myrows.driverRows(), nil
 	}
 
 	return rows, nil
 }
 
+// canExec reports whether the connection supports the fast-path of Exec.
+// A Conn of a stacked proxy always has Exec methods,
+// so the innermost connection is checked.
+func (conn *Conn) canExec() bool {
+	switch c := conn.Conn.(type) {
+	case *Conn:
+		return c.canExec()
+	case driver.ExecerContext, driver.Execer:
+		return true
+	}
+	return false
+}
+
+// canQuery reports whether the connection supports the fast-path of Query.
+// A Conn of a stacked proxy always has Query methods,
+// so the innermost connection is checked.
+func (conn *Conn) canQuery() bool {
+	switch c := conn.Conn.(type) {
+	case *Conn:
+		return c.canQuery()
+	case driver.QueryerContext, driver.Queryer:
+		return true
+	}
+	return false
+}
+
 // copied from sql/driver/convert.go
 // defaultCheckNamedValue wraps the default ColumnConverter to have the same
 // function signature as the CheckNamedValue in the driver.NamedValueChecker
@@ -343,13 +507,16 @@ type sessionResetter interface {
 }
 
 // ResetSession resets the state of Conn.
-func (conn *Conn) ResetSession(ctx context.Context) error {
-	var err error
+func (conn *Conn) ResetSession(ctx context.Context) (err error) {
 	var myctx interface{}
-	hooks := conn.Proxy.getHooks(ctx)
+	start := time.Now()
+	defer conn.Proxy.stats.observe(OpResetSession, start, &err)
+	hooks := conn.Proxy.getHooks(ctx, OpResetSession, conn.hooks)
 
 	if hooks != nil {
-		defer func() { hooks.postResetSession(ctx, myctx, conn, err) }()
+		ctx = conn.withMetadata(ctx)
+		defer func() { conn.Proxy.postError(&err, hooks.postResetSession(withDuration(ctx, start), myctx, conn, err)) }()
+		defer func() { notifyCanceled(ctx, hooks, OpResetSession, start, err) }()
 		if myctx, err = hooks.preResetSession(ctx, conn); err != nil {
 			return err
 		}
@@ -382,7 +549,7 @@ type validator interface {
 func (conn *Conn) IsValid() bool {
 	valid := true
 	var myctx interface{}
-	hooks := conn.Proxy.hooks
+	hooks := conn.Proxy.hooksFor(OpIsValid, conn.hooks)
 	if hooks != nil {
 		// Setup PostIsValid. This needs to be a closure like this
 		// or otherwise changes to the `ctx` and `conn` parameters
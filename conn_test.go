@@ -1,6 +1,15 @@
 package proxy
 
-import "database/sql/driver"
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
 
 var _ driver.Conn = (*Conn)(nil)
 var _ driver.ConnBeginTx = (*Conn)(nil)
@@ -13,3 +22,183 @@ var _ driver.QueryerContext = (*Conn)(nil)
 var _ namedValueChecker = (*Conn)(nil)
 var _ sessionResetter = (*Conn)(nil)
 var _ validator = (*Conn)(nil)
+
+func TestConnLegacyMethods(t *testing.T) {
+	db := &fakeDB{log: &bytes.Buffer{}}
+	buf := &bytes.Buffer{}
+	conn := &Conn{
+		Conn:  &fakeConnExt{db: db, opt: &fakeConnOption{}},
+		Proxy: &Proxy{Driver: fdriver, hooks: newLoggingHook(buf)},
+	}
+
+	stmt, err := conn.Prepare("SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stmt.Exec([]driver.Value{int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stmt.Query([]driver.Value{int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("INSERT INTO t1 VALUES (?)", []driver.Value{int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Query("SELECT * FROM t1 WHERE id = ?", []driver.Value{int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "[PrePrepare]\n[Prepare]\n[PostPrepare]\n" +
+		"[PreExec]\n[Exec]\n[PostExec]\n" +
+		"[PreQuery]\n[Query]\n[PostQuery]\n" +
+		"[PreExec]\n[Exec]\n[PostExec]\n" +
+		"[PreQuery]\n[Query]\n[PostQuery]\n" +
+		"[PreBegin]\n[Begin]\n[PostBegin]\n" +
+		"[PreCommit]\n[Commit]\n[PostCommit]\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
+
+func TestConnLegacyMethods_ErrSkip(t *testing.T) {
+	// the minimum implementation doesn't satisfy driver.Execer nor driver.Queryer.
+	conn := &Conn{
+		Conn:  &fakeConn{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}},
+		Proxy: NewProxyContext(fdriver),
+	}
+	if _, err := conn.Exec("INSERT INTO t1 VALUES (?)", []driver.Value{int64(1)}); err != driver.ErrSkip {
+		t.Errorf("want %v, got %v", driver.ErrSkip, err)
+	}
+	if _, err := conn.Query("SELECT * FROM t1 WHERE id = ?", []driver.Value{int64(1)}); err != driver.ErrSkip {
+		t.Errorf("want %v, got %v", driver.ErrSkip, err)
+	}
+}
+
+func TestConnIsValidHooks(t *testing.T) {
+	var log []string
+	invalidate := false
+	conn := &Conn{
+		Conn: &fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}},
+		Proxy: NewProxyContext(fdriver, &HooksContext{
+			PreIsValid: func(conn *Conn) (interface{}, error) {
+				log = append(log, "PreIsValid")
+				return nil, nil
+			},
+			IsValid: func(ctx interface{}, conn *Conn) error {
+				log = append(log, "IsValid")
+				if invalidate {
+					return ErrInvalidConnection
+				}
+				return nil
+			},
+			PostIsValid: func(ctx interface{}, conn *Conn, valid bool) error {
+				log = append(log, fmt.Sprintf("PostIsValid %t", valid))
+				return nil
+			},
+		}),
+	}
+
+	if !conn.IsValid() {
+		t.Error("want valid, got invalid")
+	}
+	invalidate = true
+	if conn.IsValid() {
+		t.Error("the IsValid hook should mark the connection as invalid")
+	}
+
+	want := []string{
+		"PreIsValid", "IsValid", "PostIsValid true",
+		"PreIsValid", "IsValid", "PostIsValid false",
+	}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("want %v, got %v", want, log)
+	}
+}
+
+func TestConnOnCanceled(t *testing.T) {
+	var log []string
+	conn := &Conn{
+		Conn: &fakeConnExt{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}},
+		Proxy: NewProxyContext(fdriver, &HooksContext{
+			OnCanceled: func(c context.Context, op Operation, d time.Duration, err error) error {
+				log = append(log, fmt.Sprintf("OnCanceled %s %v", op, err))
+				return nil
+			},
+			PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+				log = append(log, fmt.Sprintf("PostExec %v", err))
+				return nil
+			},
+			PostQuery: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+				log = append(log, fmt.Sprintf("PostQuery %v", err))
+				return nil
+			},
+		}),
+	}
+
+	// the operations with the live context don't trigger the OnCanceled hook.
+	if _, err := conn.ExecContext(context.Background(), "INSERT INTO t1 VALUES (?)", []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := conn.ExecContext(ctx, "INSERT INTO t1 VALUES (?)", []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}); err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+	if _, err := conn.QueryContext(ctx, "SELECT * FROM t1 WHERE id = ?", []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}); err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+
+	want := []string{
+		"PostExec <nil>",
+		"OnCanceled Exec context canceled", "PostExec context canceled",
+		"OnCanceled Query context canceled", "PostQuery context canceled",
+	}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("want %v, got %v", want, log)
+	}
+}
+
+func TestConnOncePerConn(t *testing.T) {
+	var log []string
+	fail := true
+	conn := &Conn{
+		Conn: &fakeConnExt{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}},
+		Proxy: NewProxyContext(fdriver, &HooksContext{
+			OncePerConn: func(c context.Context, conn *Conn) error {
+				log = append(log, "OncePerConn")
+				if fail {
+					fail = false
+					return errors.New("init failed")
+				}
+				return nil
+			},
+			PreExec: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+				log = append(log, "PreExec")
+				return nil, nil
+			},
+		}),
+	}
+
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+	if _, err := conn.ExecContext(context.Background(), "INSERT INTO t1 VALUES (?)", args); err == nil || err.Error() != "init failed" {
+		t.Errorf("want init failed, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := conn.ExecContext(context.Background(), "INSERT INTO t1 VALUES (?)", args); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"OncePerConn", "OncePerConn", "PreExec", "PreExec"}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("want %v, got %v", want, log)
+	}
+}
@@ -5,10 +5,18 @@ package proxy
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"io"
+	"time"
 )
 
+// borrower is a connector which lends connections owned by another pool.
+// The connections must be returned by calling release instead of closing them.
+type borrower interface {
+	borrow(ctx context.Context) (conn driver.Conn, release func() error, err error)
+}
+
 // Connector adds hook points into "database/sql/driver".Connector.
 type Connector struct {
 	Proxy     *Proxy
@@ -18,42 +26,100 @@ type Connector struct {
 
 // Connect returns a connection to the database which wrapped by Conn.
 // It will triggers PreOpen, Open, PostOpen hooks.
-func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
-	var err error
-	var myctx interface{}
+func (c *Connector) Connect(ctx context.Context) (ret driver.Conn, err error) {
+	var myctx, connectCtx interface{}
 	var conn driver.Conn
 	var myconn *Conn
-	hooks := c.Proxy.getHooks(ctx)
+	name := c.Name
+	start := time.Now()
+	defer c.Proxy.stats.observe(OpOpen, start, &err)
+	defer func() {
+		if err == nil {
+			c.Proxy.stats.countOpen(myconn)
+		}
+	}()
+	ch := &connHooks{routed: c.Proxy.routeDSN(name)}
+	hooks := c.Proxy.getHooks(ctx, OpOpen, ch)
 
 	if hooks != nil {
+		// Setup PostConnect. It is fired after PostOpen.
+		connctx := ctx
+		defer func() {
+			if c.Proxy.postError(&err, hooks.postConnect(withDuration(connctx, start), connectCtx, c, myconn, err)) {
+				myconn.closeDriverConn()
+				ret = nil
+			}
+		}()
+		if connectCtx, err = hooks.preConnect(ctx, c); err != nil {
+			return nil, err
+		}
+		if name != "" {
+			if name, err = hooks.rewriteDSN(ctx, name); err != nil {
+				return nil, err
+			}
+		}
+
 		// Setup PostOpen. This needs to be a closure like this
 		// or otherwise changes to the `ctx` and `conn` parameters
 		// within this Open() method does not get applied at the
 		// time defer is fired
-		defer func() { hooks.postOpen(ctx, myctx, myconn, err) }()
-		if myctx, err = hooks.preOpen(ctx, c.Name); err != nil {
+		defer func() {
+			if c.Proxy.postError(&err, hooks.postOpen(withDuration(ctx, start), myctx, myconn, err)) {
+				myconn.closeDriverConn()
+				ret = nil
+			}
+		}()
+		defer func() { notifyCanceled(ctx, hooks, OpOpen, start, err) }()
+		if myctx, err = hooks.preOpen(ctx, name); err != nil {
 			return nil, err
 		}
 	}
-	conn, err = c.Connector.Connect(ctx)
+	var release func() error
+	if b, ok := c.Connector.(borrower); ok {
+		conn, release, err = b.borrow(ctx)
+	} else if name != c.Name {
+		conn, err = c.connectName(ctx, name)
+	} else {
+		conn, err = c.Connector.Connect(ctx)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	myconn = &Conn{
-		Conn:  conn,
-		Proxy: c.Proxy,
-	}
+	myconn = newConn(conn, c.Proxy)
+	myconn.release = release
+	myconn.hooks = ch
 
 	if hooks != nil {
+		ctx = myconn.withMetadata(ctx)
 		if err = hooks.open(ctx, myctx, myconn); err != nil {
-			conn.Close()
+			myconn.closeDriverConn()
+			return nil, err
+		}
+		if err = hooks.connect(ctx, connectCtx, c, myconn); err != nil {
+			myconn.closeDriverConn()
 			return nil, err
 		}
 	}
 	return myconn, nil
 }
 
+// connectName connects to the database with the data source name rewritten by the RewriteDSN hooks.
+func (c *Connector) connectName(ctx context.Context, name string) (driver.Conn, error) {
+	if d, ok := c.Proxy.Driver.(driver.DriverContext); ok {
+		connector, err := d.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(ctx)
+	}
+	fc := &fallbackConnector{
+		driver: c.Proxy.Driver,
+		name:   name,
+	}
+	return fc.Connect(ctx)
+}
+
 // Driver returns the underlying Driver of the Connector.
 func (c *Connector) Driver() driver.Driver {
 	return c.Proxy
@@ -61,21 +127,54 @@ func (c *Connector) Driver() driver.Driver {
 
 // Close closes the c.Connector if it implements the io.Closer interface.
 // It is called by the DB.Close method from Go 1.17.
-func (c *Connector) Close() error {
-	if c, ok := c.Connector.(io.Closer); ok {
-		return c.Close()
+// It will triggers PreConnectorClose, PostConnectorClose hooks.
+func (c *Connector) Close() (err error) {
+	var myctx interface{}
+	ctx := context.Background()
+	start := time.Now()
+	defer c.Proxy.stats.observe(OpConnectorClose, start, &err)
+
+	if hooks := c.Proxy.hooksFor(OpConnectorClose, nil); hooks != nil {
+		defer func() { c.Proxy.postError(&err, hooks.postConnectorClose(withDuration(ctx, start), myctx, c, err)) }()
+		if myctx, err = hooks.preConnectorClose(ctx, c); err != nil {
+			return err
+		}
 	}
-	return nil
+	if closer, ok := c.Connector.(io.Closer); ok {
+		err = closer.Close()
+	}
+	return err
 }
 
 // NewConnector creates new proxied Connector.
+// It panics with ErrNilConnector if c or its driver is nil.
 func NewConnector(c driver.Connector, hs ...*HooksContext) driver.Connector {
+	conn, err := TryNewConnector(c, hs...)
+	if err != nil {
+		panic(err)
+	}
+	return conn
+}
+
+// TryNewConnector is the same as NewConnector,
+// but returns ErrNilConnector instead of panicking when c or its driver is nil.
+func TryNewConnector(c driver.Connector, hs ...*HooksContext) (driver.Connector, error) {
+	if isNil(c) || isNil(c.Driver()) {
+		return nil, ErrNilConnector
+	}
 	p := NewProxyContext(c.Driver(), hs...)
 	return &Connector{
 		Proxy:     p,
 		Connector: c,
 		Name:      "",
-	}
+	}, nil
+}
+
+// OpenDB opens a database that runs the operations on the connections of c with the hooks.
+// It is the same as sql.OpenDB(NewConnector(c, hs...)),
+// and doesn't require registering any driver names.
+func OpenDB(c driver.Connector, hs ...*HooksContext) *sql.DB {
+	return sql.OpenDB(NewConnector(c, hs...))
 }
 
 type fallbackConnector struct {
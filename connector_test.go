@@ -4,10 +4,13 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"database/sql/driver"
 	"errors"
+	"fmt"
 	"io"
+	"reflect"
 	"testing"
 )
 
@@ -61,6 +64,52 @@ func TestConnectorClose(t *testing.T) {
 		}
 	})
 
+	t.Run("Closing c.Connector triggers the hooks", func(t *testing.T) {
+		var log []string
+		c0 := &closerConnector{}
+		c1 := NewConnector(c0, &HooksContext{
+			PreConnectorClose: func(c context.Context, connector *Connector) (interface{}, error) {
+				log = append(log, "PreConnectorClose")
+				return nil, nil
+			},
+			PostConnectorClose: func(c context.Context, ctx interface{}, connector *Connector, err error) error {
+				log = append(log, fmt.Sprintf("PostConnectorClose %v", err))
+				return nil
+			},
+		})
+		if err := c1.(io.Closer).Close(); err != nil {
+			t.Fatal(err)
+		}
+		if !c0.closed {
+			t.Errorf("c.Connector should be closed, but not")
+		}
+		want := []string{"PreConnectorClose", "PostConnectorClose <nil>"}
+		if !reflect.DeepEqual(log, want) {
+			t.Errorf("want %v, got %v", want, log)
+		}
+	})
+
+	t.Run("the hooks are disabled by WithOperations", func(t *testing.T) {
+		called := false
+		c0 := &closerConnector{}
+		p := NewProxyContext(fdriverctx, &HooksContext{
+			PreConnectorClose: func(c context.Context, connector *Connector) (interface{}, error) {
+				called = true
+				return nil, nil
+			},
+		}).WithOperations(OpExec)
+		c1 := &Connector{Proxy: p, Connector: c0}
+		if err := c1.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if called {
+			t.Error("PreConnectorClose should not be called")
+		}
+		if got := p.Stats().Operations[OpConnectorClose].Count; got != 1 {
+			t.Errorf("want 1 call, got %d", got)
+		}
+	})
+
 	t.Run("Closing c.Connector fails", func(t *testing.T) {
 		errClose := errors.New("some error while closing")
 		c0 := &closerConnector{
@@ -75,3 +124,126 @@ func TestConnectorClose(t *testing.T) {
 		}
 	})
 }
+
+func TestConnectorConnectHooks(t *testing.T) {
+	var log []string
+	errConnect := errors.New("connect error")
+	fail := false
+	c := NewConnector(&fakeConnector{
+		driver: fdriverctx,
+		opt:    &fakeConnOption{},
+		db:     &fakeDB{log: &bytes.Buffer{}},
+	}, &HooksContext{
+		PreConnect: func(c context.Context, connector *Connector) (interface{}, error) {
+			log = append(log, "PreConnect")
+			return nil, nil
+		},
+		Connect: func(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error {
+			log = append(log, "Connect")
+			if fail {
+				return errConnect
+			}
+			return nil
+		},
+		PostConnect: func(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error {
+			log = append(log, fmt.Sprintf("PostConnect %v", err))
+			return nil
+		},
+		PreOpen: func(c context.Context, name string) (interface{}, error) {
+			log = append(log, "PreOpen")
+			return nil, nil
+		},
+		Open: func(c context.Context, ctx interface{}, conn *Conn) error {
+			log = append(log, "Open")
+			return nil
+		},
+		PostOpen: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			log = append(log, "PostOpen")
+			return nil
+		},
+	})
+
+	conn, err := c.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	fail = true
+	if _, err := c.Connect(context.Background()); err != errConnect {
+		t.Errorf("want %v, got %v", errConnect, err)
+	}
+
+	want := []string{
+		"PreConnect", "PreOpen", "Open", "Connect", "PostOpen", "PostConnect <nil>",
+		"PreConnect", "PreOpen", "Open", "Connect", "PostOpen", "PostConnect connect error",
+	}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("want %v, got %v", want, log)
+	}
+}
+
+func TestRewriteDSN(t *testing.T) {
+	const dsn = `{"Name":"rewrite-dsn","ConnType":"fakeConnCtx"}`
+	var got []string
+	p := NewProxyContext(fdriver, &HooksContext{
+		RewriteDSN: func(c context.Context, name string) (string, error) {
+			if name == "placeholder" {
+				return dsn, nil
+			}
+			return "", errors.New("unknown dsn")
+		},
+		PreOpen: func(c context.Context, name string) (interface{}, error) {
+			got = append(got, name)
+			return nil, nil
+		},
+	})
+
+	// Proxy.Open
+	conn, err := p.Open("placeholder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// Connector.Connect
+	connector, err := p.OpenConnector("placeholder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err = connector.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	if want := []string{dsn, dsn}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	if _, err := p.Open("other"); err == nil || err.Error() != "unknown dsn" {
+		t.Errorf("want unknown dsn, got %v", err)
+	}
+}
+
+func TestOpenDB(t *testing.T) {
+	var pings int
+	db := OpenDB(&fakeConnector{
+		driver: fdriverctx,
+		opt:    &fakeConnOption{},
+		db:     &fakeDB{log: &bytes.Buffer{}},
+	}, &HooksContext{
+		PostPing: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			pings++
+			return nil
+		},
+	})
+	defer db.Close()
+
+	if err := db.PingContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if pings != 1 {
+		t.Errorf("want 1 PostPing call, got %d", pings)
+	}
+}
@@ -0,0 +1,186 @@
+package proxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	lastConnID int64
+	lastTxID   int64
+	lastOpID   int64
+)
+
+func newConnID() int64 {
+	return atomic.AddInt64(&lastConnID, 1)
+}
+
+func newTxID() int64 {
+	return atomic.AddInt64(&lastTxID, 1)
+}
+
+func newOpID() int64 {
+	return atomic.AddInt64(&lastOpID, 1)
+}
+
+type metadataKey struct{}
+
+// metadata is the information about the operation that the proxy sets into the context.
+type metadata struct {
+	connID int64
+	txID   int64
+	opID   int64
+}
+
+func withMetadata(ctx context.Context, md metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// withNewOpID returns a copy of c in which a new operation ID associated.
+// The other metadata in c are kept.
+func withNewOpID(c context.Context) context.Context {
+	md, _ := c.Value(metadataKey{}).(metadata)
+	md.opID = newOpID()
+	return withMetadata(c, md)
+}
+
+// withTxID returns a copy of c in which the transaction ID associated.
+// The other metadata in c are kept.
+func withTxID(c context.Context, txID int64) context.Context {
+	md, _ := c.Value(metadataKey{}).(metadata)
+	md.txID = txID
+	return withMetadata(c, md)
+}
+
+// ConnIDFromContext returns the ID of the connection that executes the operation.
+// The proxy sets the ID into the context passed to the hooks.
+// The IDs are unique in the process.
+func ConnIDFromContext(ctx context.Context) (int64, bool) {
+	md, ok := ctx.Value(metadataKey{}).(metadata)
+	if !ok || md.connID == 0 {
+		return 0, false
+	}
+	return md.connID, true
+}
+
+// TxIDFromContext returns the ID of the transaction that executes the operation.
+// The proxy sets the ID into the context passed to the hooks.
+// It returns false if the operation is not executed in a transaction.
+func TxIDFromContext(ctx context.Context) (int64, bool) {
+	md, ok := ctx.Value(metadataKey{}).(metadata)
+	if !ok || md.txID == 0 {
+		return 0, false
+	}
+	return md.txID, true
+}
+
+// OperationIDFromContext returns the ID of the operation.
+// The proxy sets the ID into the context passed to the hooks, so the Pre and Post hooks of
+// the same call can be paired even if the logs of the goroutines are interleaved.
+// The IDs are unique in the process.
+// The hooks of the rows share the ID of the query, and the Pre hooks of Open and Connect have no ID,
+// because the connection is not opened yet.
+func OperationIDFromContext(ctx context.Context) (int64, bool) {
+	md, ok := ctx.Value(metadataKey{}).(metadata)
+	if !ok || md.opID == 0 {
+		return 0, false
+	}
+	return md.opID, true
+}
+
+type durationKey struct{}
+
+// withDuration returns a copy of c in which the elapsed time of the operation started at start associated.
+func withDuration(c context.Context, start time.Time) context.Context {
+	return context.WithValue(c, durationKey{}, time.Since(start))
+}
+
+// DurationFromContext returns the elapsed time of the operation.
+// The proxy measures it when the operation finishes, and sets it into the context passed to the Post hooks,
+// so the Pre hooks do not need to record the start time.
+// It returns false if the context is not passed to Post hooks.
+func DurationFromContext(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(durationKey{}).(time.Duration)
+	return d, ok
+}
+
+type txOptionsKey struct{}
+
+func withTxOptions(c context.Context, opts driver.TxOptions) context.Context {
+	return context.WithValue(c, txOptionsKey{}, opts)
+}
+
+// TxOptionsFromContext returns the options of the transaction that executes the operation.
+// The proxy sets the options passed to `Conn.BeginTx` into the context passed to
+// the Begin, Commit and Rollback hooks.
+func TxOptionsFromContext(ctx context.Context) (driver.TxOptions, bool) {
+	opts, ok := ctx.Value(txOptionsKey{}).(driver.TxOptions)
+	return opts, ok
+}
+
+type labelsKey struct{}
+
+// WithLabels returns a copy of parent context in which the labels associated.
+// The labels are merged into the labels already associated with the parent context.
+func WithLabels(ctx context.Context, labels map[string]string) context.Context {
+	current := LabelsFromContext(ctx)
+	merged := make(map[string]string, len(current)+len(labels))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, labelsKey{}, merged)
+}
+
+// WithLabel returns a copy of parent context in which the label associated.
+// It is a shorthand for WithLabels with a single label.
+func WithLabel(ctx context.Context, key, value string) context.Context {
+	return WithLabels(ctx, map[string]string{key: value})
+}
+
+// LabelsFromContext returns the labels associated with the context.
+// The returned map must not be modified.
+func LabelsFromContext(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(labelsKey{}).(map[string]string)
+	return labels
+}
+
+// HookKey is a key of the values passed from the application code to the hooks.
+// See WithHookValue.
+type HookKey string
+
+// The well-known keys of the hook values.
+// The hooks of this package and the integrations use them to annotate logs and spans.
+const (
+	// HookKeyRequestID is the key of the ID of the request that executes the operation.
+	HookKeyRequestID HookKey = "request_id"
+
+	// HookKeyUserID is the key of the ID of the user that executes the operation.
+	HookKeyUserID HookKey = "user_id"
+)
+
+type hookValuesKey struct{}
+
+// WithHookValue returns a copy of parent context in which the value associated with key.
+// The hooks can get the value by HookValue.
+// Unlike context.WithValue, the application and the hooks don't need to share their own key types.
+func WithHookValue(ctx context.Context, key HookKey, value interface{}) context.Context {
+	current, _ := ctx.Value(hookValuesKey{}).(map[HookKey]interface{})
+	values := make(map[HookKey]interface{}, len(current)+1)
+	for k, v := range current {
+		values[k] = v
+	}
+	values[key] = value
+	return context.WithValue(ctx, hookValuesKey{}, values)
+}
+
+// HookValue returns the value associated with key by WithHookValue.
+func HookValue(ctx context.Context, key HookKey) (interface{}, bool) {
+	values, _ := ctx.Value(hookValuesKey{}).(map[HookKey]interface{})
+	v, ok := values[key]
+	return v, ok
+}
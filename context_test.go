@@ -0,0 +1,312 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestContextMetadata(t *testing.T) {
+	type record struct {
+		op     string
+		connID int64
+		txID   int64
+		labels map[string]string
+	}
+	var mu sync.Mutex
+	var records []record
+	logRecord := func(op string, c context.Context) {
+		mu.Lock()
+		defer mu.Unlock()
+		connID, _ := ConnIDFromContext(c)
+		txID, _ := TxIDFromContext(c)
+		records = append(records, record{
+			op:     op,
+			connID: connID,
+			txID:   txID,
+			labels: LabelsFromContext(c),
+		})
+	}
+	sql.Register("fakedb-context-metadata", NewProxyContext(fdriver, &HooksContext{
+		PostOpen: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			logRecord("Open", c)
+			return nil
+		},
+		PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			logRecord("Exec", c)
+			return nil
+		},
+		PostBegin: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			logRecord("Begin", c)
+			return nil
+		},
+		PostCommit: func(c context.Context, ctx interface{}, tx *Tx, err error) error {
+			logRecord("Commit", c)
+			return nil
+		},
+	}))
+	db, err := sql.Open("fakedb-context-metadata", `{"Name":"context-metadata","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := WithLabels(context.Background(), map[string]string{"job": "test"})
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?)", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 5 {
+		t.Fatalf("want 5 records, got %d: %v", len(records), records)
+	}
+	connID := records[0].connID
+	if connID == 0 {
+		t.Error("want a connection ID, got zero")
+	}
+	txID := records[1].txID
+	if txID == 0 {
+		t.Error("want a transaction ID, got zero")
+	}
+	labels := map[string]string{"job": "test"}
+	want := []record{
+		{op: "Open", connID: connID, labels: labels},
+		{op: "Begin", connID: connID, txID: txID, labels: labels},
+		{op: "Exec", connID: connID, txID: txID, labels: labels},
+		{op: "Commit", connID: connID, txID: txID, labels: labels},
+		{op: "Exec", connID: connID, labels: labels},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("want %v, got %v", want, records)
+	}
+}
+
+func TestOperationIDFromContext(t *testing.T) {
+	var mu sync.Mutex
+	var ops []string
+	var ids []int64
+	logID := func(op string, c context.Context) {
+		mu.Lock()
+		defer mu.Unlock()
+		id, _ := OperationIDFromContext(c)
+		ops = append(ops, op)
+		ids = append(ids, id)
+	}
+	sql.Register("fakedb-operation-id", NewProxyContext(fdriver, &HooksContext{
+		PreExec: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+			logID("PreExec", c)
+			return nil, nil
+		},
+		PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			logID("PostExec", c)
+			return nil
+		},
+		PreBegin: func(c context.Context, conn *Conn) (interface{}, error) {
+			logID("PreBegin", c)
+			return nil, nil
+		},
+		PostBegin: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			logID("PostBegin", c)
+			return nil
+		},
+		PreCommit: func(c context.Context, tx *Tx) (interface{}, error) {
+			logID("PreCommit", c)
+			return nil, nil
+		},
+		PostCommit: func(c context.Context, ctx interface{}, tx *Tx, err error) error {
+			logID("PostCommit", c)
+			return nil
+		},
+	}))
+	db, err := sql.Open("fakedb-operation-id", `{"Name":"operation-id","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"PreBegin", "PostBegin", "PreExec", "PostExec", "PreCommit", "PostCommit"}
+	if !reflect.DeepEqual(ops, want) {
+		t.Fatalf("want %v, got %v", want, ops)
+	}
+	for i := 0; i < len(ids); i += 2 {
+		if ids[i] == 0 || ids[i] != ids[i+1] {
+			t.Errorf("want the same ID for %s and %s, got %d and %d", ops[i], ops[i+1], ids[i], ids[i+1])
+		}
+		if i > 0 && ids[i] == ids[i-1] {
+			t.Errorf("want different IDs for %s and %s, got %d", ops[i-1], ops[i], ids[i])
+		}
+	}
+}
+
+func TestDurationFromContext(t *testing.T) {
+	var preOK bool
+	var postOK bool
+	var d time.Duration
+	p := NewProxyContext(fdriver, &HooksContext{
+		PreExec: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+			_, preOK = DurationFromContext(c)
+			return nil, nil
+		},
+		Exec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		},
+		PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			d, postOK = DurationFromContext(c)
+			return nil
+		},
+	})
+	conn := newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+	if _, err := conn.ExecContext(context.Background(), "INSERT INTO t1 (id) VALUES(?)", []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if preOK {
+		t.Error("the duration should not be passed to the Pre hooks")
+	}
+	if !postOK {
+		t.Fatal("the duration should be passed to the Post hooks")
+	}
+	if d < 10*time.Millisecond {
+		t.Errorf("want the duration including the Exec hook, got %s", d)
+	}
+}
+
+func TestTxOptionsFromContext(t *testing.T) {
+	var got []driver.TxOptions
+	errReadOnly := errors.New("read-only transactions are not allowed")
+	p := NewProxyContext(fdriver, &HooksContext{
+		PreBegin: func(c context.Context, conn *Conn) (interface{}, error) {
+			opts, ok := TxOptionsFromContext(c)
+			if !ok {
+				t.Error("the options should be passed to the PreBegin hook")
+			}
+			if opts.ReadOnly {
+				return nil, errReadOnly
+			}
+			return nil, nil
+		},
+		PreCommit: func(c context.Context, tx *Tx) (interface{}, error) {
+			opts, _ := TxOptionsFromContext(c)
+			got = append(got, opts)
+			return nil, nil
+		},
+	})
+	conn := newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+
+	serializable := driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSerializable)}
+	tx, err := conn.BeginTx(context.Background(), serializable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.BeginTx(context.Background(), driver.TxOptions{ReadOnly: true}); err != errReadOnly {
+		t.Errorf("want %v, got %v", errReadOnly, err)
+	}
+
+	want := []driver.TxOptions{serializable}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestWithLabels(t *testing.T) {
+	ctx := context.Background()
+	if labels := LabelsFromContext(ctx); labels != nil {
+		t.Errorf("want nil, got %v", labels)
+	}
+
+	ctx1 := WithLabels(ctx, map[string]string{"a": "1", "b": "2"})
+	ctx2 := WithLabels(ctx1, map[string]string{"b": "3"})
+	if want, got := map[string]string{"a": "1", "b": "2"}, LabelsFromContext(ctx1); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if want, got := map[string]string{"a": "1", "b": "3"}, LabelsFromContext(ctx2); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestWithLabel(t *testing.T) {
+	ctx := WithLabel(context.Background(), "a", "1")
+	ctx = WithLabel(ctx, "b", "2")
+	if want, got := map[string]string{"a": "1", "b": "2"}, LabelsFromContext(ctx); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestWithLabel_Trace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-labels", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+	})))
+	db, err := sql.Open("fakedb-trace-labels", `{"Name":"trace-labels","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := WithLabel(context.Background(), "job", "batch")
+	ctx = WithLabel(ctx, "tier", "gold")
+	if _, err := db.ExecContext(ctx, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := regexp.MustCompile(`(?m)^Exec 0x[0-9a-f]+: SELECT 1; args = \[\]; labels = \{job=batch, tier=gold\}; conn_id = \d+ `)
+	if !want.MatchString(buf.String()) {
+		t.Errorf("unexpected log:\n%s", buf.String())
+	}
+}
+
+func TestWithHookValue(t *testing.T) {
+	ctx := context.Background()
+	if v, ok := HookValue(ctx, HookKeyRequestID); ok {
+		t.Errorf("want no value, got %v", v)
+	}
+
+	ctx1 := WithHookValue(ctx, HookKeyRequestID, "req-1")
+	ctx2 := WithHookValue(ctx1, HookKeyUserID, 42)
+	ctx3 := WithHookValue(ctx2, HookKeyRequestID, "req-2")
+	if v, ok := HookValue(ctx2, HookKeyRequestID); !ok || v != "req-1" {
+		t.Errorf("want req-1, got %v", v)
+	}
+	if v, ok := HookValue(ctx2, HookKeyUserID); !ok || v != 42 {
+		t.Errorf("want 42, got %v", v)
+	}
+	if v, ok := HookValue(ctx1, HookKeyUserID); ok {
+		t.Errorf("want no value, got %v", v)
+	}
+	if v, ok := HookValue(ctx3, HookKeyRequestID); !ok || v != "req-2" {
+		t.Errorf("want req-2, got %v", v)
+	}
+}
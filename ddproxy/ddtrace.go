@@ -0,0 +1,128 @@
+// Package ddproxy integrates go-sql-proxy with Datadog APM (dd-trace-go).
+package ddproxy
+
+import (
+	"context"
+	"database/sql/driver"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Options holds the options of NewHooks.
+type Options struct {
+	// DriverName is the name of the driver, e.g. "mysql" and "postgres".
+	// The spans are named DriverName + ".query", e.g. "mysql.query".
+	// If it is empty, "sql" is used.
+	DriverName string
+
+	// ServiceName is the service name of the spans.
+	// If it is empty, DriverName + ".db" is used.
+	ServiceName string
+
+	// Tags is the tags of all the spans, e.g. "db.name".
+	Tags map[string]interface{}
+}
+
+// NewHooks creates new HooksContext which produces the spans of dd-trace-go for the operations.
+// The spans are named in the same conventions as gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql,
+// so the APM dashboards of the contrib package keep working:
+// the resource names are the queries, or the names of the operations such as "Commit",
+// and the "sql.query_type" tag is the name of the operation.
+// The spans are the children of the spans associated with the contexts of the operations.
+func NewHooks(opt Options) *proxy.HooksContext {
+	driverName := opt.DriverName
+	if driverName == "" {
+		driverName = "sql"
+	}
+	serviceName := opt.ServiceName
+	if serviceName == "" {
+		serviceName = driverName + ".db"
+	}
+	spanName := driverName + ".query"
+
+	start := func(c context.Context, queryType, query string) ddtrace.Span {
+		resource := query
+		if resource == "" {
+			resource = queryType
+		}
+		opts := make([]ddtrace.StartSpanOption, 0, 5+len(opt.Tags))
+		opts = append(opts,
+			tracer.ServiceName(serviceName),
+			tracer.ResourceName(resource),
+			tracer.SpanType(ext.SpanTypeSQL),
+			tracer.Tag("sql.query_type", queryType),
+			tracer.Tag(ext.DBSystem, driverName),
+		)
+		for k, v := range opt.Tags {
+			opts = append(opts, tracer.Tag(k, v))
+		}
+		span, _ := tracer.StartSpanFromContext(c, spanName, opts...)
+		return span
+	}
+	finish := func(ctx interface{}, err error) error {
+		span, ok := ctx.(ddtrace.Span)
+		if !ok {
+			return nil
+		}
+		if err == driver.ErrSkip {
+			// the driver doesn't support the operation, and database/sql falls back to the other way.
+			err = nil
+		}
+		span.Finish(tracer.WithError(err))
+		return nil
+	}
+
+	return &proxy.HooksContext{
+		PreOpen: func(c context.Context, _ string) (interface{}, error) {
+			return start(c, "Connect", ""), nil
+		},
+		PostOpen: func(_ context.Context, ctx interface{}, _ *proxy.Conn, err error) error {
+			return finish(ctx, err)
+		},
+		PrePing: func(c context.Context, _ *proxy.Conn) (interface{}, error) {
+			return start(c, "Ping", ""), nil
+		},
+		PostPing: func(_ context.Context, ctx interface{}, _ *proxy.Conn, err error) error {
+			return finish(ctx, err)
+		},
+		PrePrepare: func(c context.Context, stmt *proxy.Stmt) (interface{}, error) {
+			return start(c, "Prepare", stmt.QueryString), nil
+		},
+		PostPrepare: func(_ context.Context, ctx interface{}, _ *proxy.Stmt, err error) error {
+			return finish(ctx, err)
+		},
+		PreExec: func(c context.Context, stmt *proxy.Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return start(c, "Exec", stmt.QueryString), nil
+		},
+		PostExec: func(_ context.Context, ctx interface{}, _ *proxy.Stmt, _ []driver.NamedValue, _ driver.Result, err error) error {
+			return finish(ctx, err)
+		},
+		PreQuery: func(c context.Context, stmt *proxy.Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return start(c, "Query", stmt.QueryString), nil
+		},
+		PostQuery: func(_ context.Context, ctx interface{}, _ *proxy.Stmt, _ []driver.NamedValue, _ driver.Rows, err error) error {
+			return finish(ctx, err)
+		},
+		PreBegin: func(c context.Context, _ *proxy.Conn) (interface{}, error) {
+			return start(c, "Begin", ""), nil
+		},
+		PostBegin: func(_ context.Context, ctx interface{}, _ *proxy.Conn, err error) error {
+			return finish(ctx, err)
+		},
+		PreCommit: func(c context.Context, _ *proxy.Tx) (interface{}, error) {
+			return start(c, "Commit", ""), nil
+		},
+		PostCommit: func(_ context.Context, ctx interface{}, _ *proxy.Tx, err error) error {
+			return finish(ctx, err)
+		},
+		PreRollback: func(c context.Context, _ *proxy.Tx) (interface{}, error) {
+			return start(c, "Rollback", ""), nil
+		},
+		PostRollback: func(_ context.Context, ctx interface{}, _ *proxy.Tx, err error) error {
+			return finish(ctx, err)
+		},
+	}
+}
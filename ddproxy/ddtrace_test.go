@@ -0,0 +1,70 @@
+package ddproxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestNewHooks(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	h := NewHooks(Options{
+		DriverName: "mysql",
+		Tags:       map[string]interface{}{"db.name": "main"},
+	})
+	parent, c := tracer.StartSpanFromContext(context.Background(), "http.request")
+
+	stmt := &proxy.Stmt{QueryString: "SELECT id FROM t1"}
+	ctx, err := h.PreQuery(c, stmt, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostQuery(c, ctx, stmt, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	errCommit := errors.New("commit failed")
+	ctx, err = h.PreCommit(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostCommit(c, ctx, nil, errCommit); err != nil {
+		t.Fatal(err)
+	}
+	parent.Finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 3 {
+		t.Fatalf("want 3 spans, got %d", len(spans))
+	}
+
+	query := spans[0]
+	if query.OperationName() != "mysql.query" || query.ParentID() != parent.Context().SpanID() {
+		t.Errorf("unexpected span: %v", query)
+	}
+	for k, want := range map[string]interface{}{
+		ext.ServiceName:  "mysql.db",
+		ext.ResourceName: "SELECT id FROM t1",
+		ext.SpanType:     ext.SpanTypeSQL,
+		"sql.query_type": "Query",
+		"db.name":        "main",
+	} {
+		if got := query.Tag(k); got != want {
+			t.Errorf("%s: want %v, got %v", k, want, got)
+		}
+	}
+	if err := query.Tag(ext.Error); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+
+	commit := spans[1]
+	if commit.Tag(ext.ResourceName) != "Commit" || commit.Tag(ext.ErrorMsg) != "commit failed" {
+		t.Errorf("unexpected span: %v", commit)
+	}
+}
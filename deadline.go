@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// DeadlineOptions holds the options of NewDeadlineHooks.
+type DeadlineOptions struct {
+	// Threshold is the fraction of the context deadline.
+	// A warning is reported when a query completes having consumed more than it.
+	// If it is zero, 0.8 is used.
+	Threshold float64
+
+	// MinRemaining is the budget that a query should have at the start.
+	// A warning is reported when a query starts with less time remaining.
+	// If it is zero, the budget at the start is not checked.
+	MinRemaining time.Duration
+
+	// OnWarning is called when a warning is reported. It must not be nil.
+	OnWarning func(c context.Context, w *DeadlineWarning)
+}
+
+// DeadlineWarning describes a query that comes close to its context deadline.
+type DeadlineWarning struct {
+	// Operation is the kind of the operation.
+	Operation Operation
+
+	// Query is the query string.
+	Query string
+
+	// Budget is the time remaining until the deadline at the start of the query.
+	Budget time.Duration
+
+	// Elapsed is the duration of the query.
+	// It is zero if the warning is reported at the start of the query.
+	Elapsed time.Duration
+}
+
+// Consumed returns the fraction of the budget consumed by the query.
+func (w *DeadlineWarning) Consumed() float64 {
+	if w.Budget <= 0 {
+		return 1
+	}
+	return float64(w.Elapsed) / float64(w.Budget)
+}
+
+type deadlineContext struct {
+	start  time.Time
+	budget time.Duration
+}
+
+// NewDeadlineHooks creates new HooksContext which reports queries
+// whose durations are close to their context deadlines.
+// It helps to find timeouts that are set too close to the real latency
+// before the queries start failing.
+// Queries without deadlines are ignored.
+func NewDeadlineHooks(opt DeadlineOptions) *HooksContext {
+	threshold := opt.Threshold
+	if threshold == 0 {
+		threshold = 0.8
+	}
+	pre := func(c context.Context, op Operation, query string) interface{} {
+		deadline, ok := c.Deadline()
+		if !ok {
+			return nil
+		}
+		now := time.Now()
+		budget := deadline.Sub(now)
+		if budget < opt.MinRemaining {
+			opt.OnWarning(c, &DeadlineWarning{
+				Operation: op,
+				Query:     query,
+				Budget:    budget,
+			})
+		}
+		return &deadlineContext{
+			start:  now,
+			budget: budget,
+		}
+	}
+	post := func(c context.Context, ctx interface{}, op Operation, query string) {
+		dctx, ok := ctx.(*deadlineContext)
+		if !ok {
+			return
+		}
+		w := &DeadlineWarning{
+			Operation: op,
+			Query:     query,
+			Budget:    dctx.budget,
+			Elapsed:   time.Since(dctx.start),
+		}
+		if w.Consumed() > threshold {
+			opt.OnWarning(c, w)
+		}
+	}
+	return &HooksContext{
+		PrePrepare: func(c context.Context, stmt *Stmt) (interface{}, error) {
+			return pre(c, OpPrepare, stmt.QueryString), nil
+		},
+		PostPrepare: func(c context.Context, ctx interface{}, stmt *Stmt, _ error) error {
+			post(c, ctx, OpPrepare, stmt.QueryString)
+			return nil
+		},
+		PreExec: func(c context.Context, stmt *Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return pre(c, OpExec, stmt.QueryString), nil
+		},
+		PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, _ []driver.NamedValue, _ driver.Result, _ error) error {
+			post(c, ctx, OpExec, stmt.QueryString)
+			return nil
+		},
+		PreQuery: func(c context.Context, stmt *Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return pre(c, OpQuery, stmt.QueryString), nil
+		},
+		PostQuery: func(c context.Context, ctx interface{}, stmt *Stmt, _ []driver.NamedValue, _ driver.Rows, _ error) error {
+			post(c, ctx, OpQuery, stmt.QueryString)
+			return nil
+		},
+	}
+}
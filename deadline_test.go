@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeadlineHooks(t *testing.T) {
+	var mu sync.Mutex
+	var warnings []DeadlineWarning
+	hooks := NewDeadlineHooks(DeadlineOptions{
+		MinRemaining: time.Hour,
+		OnWarning: func(c context.Context, w *DeadlineWarning) {
+			mu.Lock()
+			defer mu.Unlock()
+			warnings = append(warnings, *w)
+		},
+	})
+	sql.Register("fakedb-deadline", NewProxyContext(fdriver, Compose(hooks, &HooksContext{
+		PostExec: func(c context.Context, _ interface{}, _ *Stmt, _ []driver.NamedValue, _ driver.Result, _ error) error {
+			// consume the budget of the query.
+			if _, ok := c.Deadline(); ok {
+				<-c.Done()
+			}
+			return nil
+		},
+	})))
+	db, err := sql.Open("fakedb-deadline", `{"Name":"deadline","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// queries without deadlines are ignored.
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("want no warnings, got %v", warnings)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	db.ExecContext(ctx, "SELECT 2")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(warnings) != 2 {
+		t.Fatalf("want 2 warnings, got %v", warnings)
+	}
+	if w := warnings[0]; w.Operation != OpExec || w.Query != "SELECT 2" || w.Elapsed != 0 {
+		t.Errorf("unexpected warning at the start: %#v", w)
+	}
+	if w := warnings[1]; w.Operation != OpExec || w.Consumed() <= 0.8 {
+		t.Errorf("unexpected warning at the end: %#v", w)
+	}
+}
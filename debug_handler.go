@@ -0,0 +1,232 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultDebugWindow is the default of DebugHandlerOptions.Window.
+const defaultDebugWindow = time.Minute
+
+// DebugHandlerOptions holds the options of NewDebugHandler.
+type DebugHandlerOptions struct {
+	// Window is the duration of the recent operations to report.
+	// It is rounded up to seconds. If it is zero, one minute is used.
+	Window time.Duration
+
+	// SlowQuery is a threshold duration of slow queries.
+	// If it is zero, no queries are slow.
+	SlowQuery time.Duration
+
+	// MaxErrorRate is the maximum error rate of the recent operations for a database to be healthy.
+	// If it is zero, the error rate doesn't affect the health.
+	MaxErrorRate float64
+
+	// MaxPingAge is the maximum age of the last successful ping for a database to be healthy.
+	// If it is zero, the pings don't affect the health.
+	MaxPingAge time.Duration
+
+	// Now returns the current time.
+	// If it is nil, time.Now is used. It is intended for deterministic tests.
+	Now func() time.Time
+}
+
+// DebugHandler is an http.Handler which reports the health of the proxied databases
+// observed by the hooks created by its Hooks method.
+// It responds with 200 OK if all the databases are healthy, and 503 Service Unavailable otherwise,
+// so it is suitable for readiness probes and admin ports.
+// The body is a JSON object like:
+//
+//	{
+//	  "status": "ok",
+//	  "databases": {
+//	    "main": {
+//	      "status": "ok",
+//	      "calls": 120,
+//	      "errors": 1,
+//	      "error_rate": 0.008333333333333333,
+//	      "slow_queries": 2,
+//	      "last_ping": "2006-01-02T15:04:05Z"
+//	    }
+//	  }
+//	}
+type DebugHandler struct {
+	opt     DebugHandlerOptions
+	buckets int
+	now     func() time.Time
+
+	mu  sync.Mutex
+	dbs map[string]*debugDB
+}
+
+// debugDB is the recent operations of a database.
+type debugDB struct {
+	// buckets is a ring buffer of the statistics per second.
+	buckets  []debugBucket
+	lastPing time.Time
+}
+
+type debugBucket struct {
+	sec    int64 // the unix time of the bucket
+	calls  int64
+	errors int64
+	slow   int64
+}
+
+// DebugStatus is the health of a database reported by DebugHandler.
+type DebugStatus struct {
+	// Status is "ok" if the database is healthy, otherwise "unhealthy".
+	Status string `json:"status"`
+
+	// Calls is the number of the recent operations,
+	// excluding ResetSession and Close which database/sql calls to manage the connection pool.
+	Calls int64 `json:"calls"`
+
+	// Errors is the number of the recent operations that returned errors.
+	Errors int64 `json:"errors"`
+
+	// ErrorRate is the ratio of Errors to Calls.
+	ErrorRate float64 `json:"error_rate"`
+
+	// SlowQueries is the number of the recent Exec and Query that took SlowQuery or longer.
+	SlowQueries int64 `json:"slow_queries"`
+
+	// LastPing is the time of the last successful ping.
+	// It is nil if the database has never been pinged successfully.
+	LastPing *time.Time `json:"last_ping"`
+}
+
+// NewDebugHandler creates new DebugHandler.
+func NewDebugHandler(opt DebugHandlerOptions) *DebugHandler {
+	window := opt.Window
+	if window <= 0 {
+		window = defaultDebugWindow
+	}
+	now := opt.Now
+	if now == nil {
+		now = time.Now
+	}
+	return &DebugHandler{
+		opt:     opt,
+		buckets: int((window + time.Second - 1) / time.Second),
+		now:     now,
+		dbs:     make(map[string]*debugDB),
+	}
+}
+
+// Hooks returns new HooksContext which reports the operations of the database named name to h.
+// The database is reported since Hooks is called, even if it has never been used.
+func (h *DebugHandler) Hooks(name string) *HooksContext {
+	h.mu.Lock()
+	db, ok := h.dbs[name]
+	if !ok {
+		db = &debugDB{
+			buckets: make([]debugBucket, h.buckets),
+		}
+		h.dbs[name] = db
+	}
+	h.mu.Unlock()
+
+	return NewEventHooks(func(_ context.Context, e *Event) {
+		h.observe(db, e)
+	})
+}
+
+func (h *DebugHandler) observe(db *debugDB, e *Event) {
+	now := h.now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if e.Operation == OpPing && e.Error == "" {
+		db.lastPing = now
+	}
+	if e.Operation == OpResetSession || e.Operation == OpClose {
+		// they are the housekeeping of the connection pool, and dilute the error rate.
+		return
+	}
+	sec := now.Unix()
+	b := &db.buckets[int(sec%int64(len(db.buckets)))]
+	if b.sec != sec {
+		*b = debugBucket{sec: sec}
+	}
+	b.calls++
+	if e.Error != "" {
+		b.errors++
+	}
+	if (e.Operation == OpExec || e.Operation == OpQuery) && h.opt.SlowQuery > 0 && e.Duration >= h.opt.SlowQuery {
+		b.slow++
+	}
+}
+
+// Status returns the health of the databases keyed by the names passed to Hooks.
+func (h *DebugHandler) Status() map[string]DebugStatus {
+	now := h.now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ret := make(map[string]DebugStatus, len(h.dbs))
+	for name, db := range h.dbs {
+		ret[name] = h.status(db, now)
+	}
+	return ret
+}
+
+func (h *DebugHandler) status(db *debugDB, now time.Time) DebugStatus {
+	var s DebugStatus
+	oldest := now.Unix() - int64(len(db.buckets)) + 1
+	for _, b := range db.buckets {
+		if b.sec < oldest {
+			continue
+		}
+		s.Calls += b.calls
+		s.Errors += b.errors
+		s.SlowQueries += b.slow
+	}
+	if s.Calls > 0 {
+		s.ErrorRate = float64(s.Errors) / float64(s.Calls)
+	}
+	if !db.lastPing.IsZero() {
+		lastPing := db.lastPing
+		s.LastPing = &lastPing
+	}
+
+	s.Status = "ok"
+	if h.opt.MaxErrorRate > 0 && s.ErrorRate > h.opt.MaxErrorRate {
+		s.Status = "unhealthy"
+	}
+	if h.opt.MaxPingAge > 0 && (s.LastPing == nil || now.Sub(*s.LastPing) > h.opt.MaxPingAge) {
+		s.Status = "unhealthy"
+	}
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (h *DebugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	dbs := h.Status()
+	status := "ok"
+	code := http.StatusOK
+	for _, db := range dbs {
+		if db.Status != "ok" {
+			status = "unhealthy"
+			code = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if r.Method == http.MethodHead {
+		return
+	}
+	json.NewEncoder(w).Encode(struct {
+		Status    string                 `json:"status"`
+		Databases map[string]DebugStatus `json:"databases"`
+	}{
+		Status:    status,
+		Databases: dbs,
+	})
+}
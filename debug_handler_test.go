@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDebugHandler(t *testing.T) {
+	now := time.Date(2006, time.January, 2, 15, 4, 5, 0, time.UTC)
+	h := NewDebugHandler(DebugHandlerOptions{
+		Window:       10 * time.Second,
+		MaxErrorRate: 0.5,
+		MaxPingAge:   30 * time.Second,
+		Now: func() time.Time {
+			return now
+		},
+	})
+	sql.Register("fakedb-debug-handler", NewProxyContext(fdriver, h.Hooks("main")))
+	db, err := sql.Open("fakedb-debug-handler", `{"Name":"debug-handler","ConnType":"fakeConnCtx","FailQuery":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	serve := func() (int, map[string]DebugStatus) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/sql", nil))
+		var body struct {
+			Status    string                 `json:"status"`
+			Databases map[string]DebugStatus `json:"databases"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[int]string{200: "ok", 503: "unhealthy"}[rec.Code]; body.Status != want {
+			t.Errorf("status %d: want %q, got %q", rec.Code, want, body.Status)
+		}
+		return rec.Code, body.Databases
+	}
+
+	// never pinged
+	if code, _ := serve(); code != http.StatusServiceUnavailable {
+		t.Errorf("want %d, got %d", http.StatusServiceUnavailable, code)
+	}
+
+	ctx := context.Background()
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(1)"); err != nil {
+		t.Fatal(err)
+	}
+	code, dbs := serve()
+	if code != http.StatusOK {
+		t.Errorf("want %d, got %d", http.StatusOK, code)
+	}
+	main := dbs["main"]
+	if main.Status != "ok" || main.Errors != 0 || main.LastPing == nil || !main.LastPing.Equal(now) {
+		t.Errorf("unexpected status: %#v", main)
+	}
+
+	now = now.Add(5 * time.Second)
+	for i := 0; i < 10; i++ {
+		if _, err := db.QueryContext(ctx, "SELECT id FROM t1"); err == nil {
+			t.Fatal("want error, got nil")
+		}
+	}
+	code, dbs = serve()
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("want %d, got %d", http.StatusServiceUnavailable, code)
+	}
+	if main := dbs["main"]; main.Errors != 10 || main.ErrorRate <= 0.5 {
+		t.Errorf("unexpected status: %#v", main)
+	}
+
+	// the errors are out of the window.
+	now = now.Add(10 * time.Second)
+	if code, dbs := serve(); code != http.StatusOK || dbs["main"].Calls != 0 {
+		t.Errorf("want no recent calls, got %d %#v", code, dbs["main"])
+	}
+
+	// the last ping is too old.
+	now = now.Add(30 * time.Second)
+	if code, _ := serve(); code != http.StatusServiceUnavailable {
+		t.Errorf("want %d, got %d", http.StatusServiceUnavailable, code)
+	}
+}
+
+func TestDebugHandler_SlowQuery(t *testing.T) {
+	h := NewDebugHandler(DebugHandlerOptions{SlowQuery: time.Second})
+	hooks := h.Hooks("main")
+	stmt := &Stmt{QueryString: "SELECT 1"}
+	c := context.Background()
+	for _, d := range []time.Duration{2 * time.Second, 0} {
+		ctx, err := hooks.PreQuery(c, stmt, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := hooks.PostQuery(c, ctx.(time.Time).Add(-d), stmt, nil, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := h.Status()["main"]; s.Calls != 2 || s.SlowQueries != 1 || s.Status != "ok" {
+		t.Errorf("unexpected status: %#v", s)
+	}
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"sort"
+	"strings"
+)
+
+// dsnRoute is a hook set that is applied to the connections opened with the data source names with the prefix.
+type dsnRoute struct {
+	prefix string
+	hooks  hooks
+}
+
+// WithDSNHooks returns a new Proxy that applies the hook sets to the connections
+// depending on the data source names they are opened with.
+// The keys of routes are data source names or their prefixes.
+// A connection is given the hook set of the longest key that its data source name starts with,
+// in addition to the hook sets of p.
+// The data source names are matched before they are rewritten by the RewriteDSN hooks.
+// The connections created by NewConnector don't have data source names, so they are not routed.
+// p is not modified.
+func (p *Proxy) WithDSNHooks(routes map[string]*HooksContext) *Proxy {
+	dsnRoutes := make([]dsnRoute, 0, len(p.dsnRoutes)+len(routes))
+	for prefix, hk := range routes {
+		if hk == nil {
+			continue
+		}
+		dsnRoutes = append(dsnRoutes, dsnRoute{
+			prefix: prefix,
+			hooks:  hk,
+		})
+	}
+	for _, r := range p.dsnRoutes {
+		if _, ok := routes[r.prefix]; !ok {
+			dsnRoutes = append(dsnRoutes, r)
+		}
+	}
+	sort.Slice(dsnRoutes, func(i, j int) bool {
+		if len(dsnRoutes[i].prefix) != len(dsnRoutes[j].prefix) {
+			return len(dsnRoutes[i].prefix) > len(dsnRoutes[j].prefix)
+		}
+		return dsnRoutes[i].prefix < dsnRoutes[j].prefix
+	})
+
+	np := p.clone()
+	np.dsnRoutes = dsnRoutes
+	return np
+}
+
+// routeDSN returns the hook set for the connections opened with name.
+// It returns nil if no routes match name.
+func (p *Proxy) routeDSN(name string) hooks {
+	if name == "" {
+		return nil
+	}
+	for _, r := range p.dsnRoutes {
+		if strings.HasPrefix(name, r.prefix) {
+			return r.hooks
+		}
+	}
+	return nil
+}
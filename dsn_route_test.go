@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestProxyWithDSNHooks(t *testing.T) {
+	var log []string
+	newHooks := func(name string) *HooksContext {
+		return &HooksContext{
+			Name: name,
+			Ping: func(c context.Context, ctx interface{}, conn *Conn) error {
+				log = append(log, name)
+				return nil
+			},
+		}
+	}
+	p := NewProxyContext(fdriver, newHooks("base")).WithDSNHooks(map[string]*HooksContext{
+		`{"name":"dsn-route-analytics`:          newHooks("analytics"),
+		`{"name":"dsn-route-analytics-verbose"`: newHooks("verbose"),
+	})
+	sql.Register("fakedb-dsn-route", p)
+
+	tests := []struct {
+		dsn  string
+		want []string
+	}{
+		{`{"name":"dsn-route-primary"}`, []string{"base"}},
+		{`{"name":"dsn-route-analytics"}`, []string{"base", "analytics"}},
+		{`{"name":"dsn-route-analytics-verbose"}`, []string{"base", "verbose"}},
+	}
+	for _, tt := range tests {
+		log = nil
+		db, err := sql.Open("fakedb-dsn-route", tt.dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.PingContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+		if len(log) != len(tt.want) {
+			t.Errorf("%s: want %v, got %v", tt.dsn, tt.want, log)
+			continue
+		}
+		for i := range log {
+			if log[i] != tt.want[i] {
+				t.Errorf("%s: want %v, got %v", tt.dsn, tt.want, log)
+				break
+			}
+		}
+	}
+}
+
+func TestProxyWithDSNHooks_Merged(t *testing.T) {
+	p := NewProxyContext(fdriver, &HooksContext{Name: "base"}).WithDSNHooks(map[string]*HooksContext{
+		`{"Name":"dsn-route-merged"`: {Name: "routed"},
+	})
+	conn, err := p.Open(`{"Name":"dsn-route-merged","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ch := conn.(*Conn).hooks
+
+	// the hook sets are merged when the connection is opened, not every operation.
+	c := AppendHooks(context.Background(), &HooksContext{Name: "appended"})
+	p.getHooks(c, OpExec, ch)
+	allocs := testing.AllocsPerRun(100, func() {
+		p.getHooks(context.Background(), OpExec, ch)
+		p.getHooks(c, OpExec, ch)
+	})
+	if allocs != 0 {
+		t.Errorf("want no allocations, got %f", allocs)
+	}
+
+	want := []string{"base", "routed", "appended"}
+	infos := describeHooks(p.getHooks(c, OpExec, ch))
+	if len(infos) != len(want) {
+		t.Fatalf("want %v, got %#v", want, infos)
+	}
+	for i, info := range infos {
+		if info.Name != want[i] {
+			t.Errorf("want %v, got %#v", want, infos)
+		}
+	}
+
+	// SetHooks affects the connections already opened.
+	p.SetHooks(&HooksContext{Name: "swapped"})
+	infos = describeHooks(p.getHooks(context.Background(), OpExec, ch))
+	if len(infos) != 2 || infos[0].Name != "swapped" || infos[1].Name != "routed" {
+		t.Errorf("want [swapped routed], got %#v", infos)
+	}
+}
@@ -0,0 +1,97 @@
+package proxy
+
+import "fmt"
+
+// ErrorPolicy controls how the errors returned by the Post hooks affect the results of the operations.
+type ErrorPolicy int
+
+const (
+	// ErrorPolicyIgnore ignores the errors returned by the Post hooks.
+	// It is the default policy.
+	ErrorPolicyIgnore ErrorPolicy = iota
+
+	// ErrorPolicyReplace replaces the error of the operation with the error returned by the Post hooks.
+	// If the operation succeeded, it fails with the error,
+	// and the resources the operation returns, e.g. statements, transactions and rows, are released.
+	ErrorPolicyReplace
+
+	// ErrorPolicyWrap returns a PostHookError that wraps both the error of the operation and
+	// the error returned by the Post hooks, so the error of the driver is not masked.
+	// If the operation succeeded, it fails like ErrorPolicyReplace.
+	ErrorPolicyWrap
+)
+
+var errorPolicyNames = [...]string{
+	ErrorPolicyIgnore:  "Ignore",
+	ErrorPolicyReplace: "Replace",
+	ErrorPolicyWrap:    "Wrap",
+}
+
+// String returns the name of the policy.
+func (policy ErrorPolicy) String() string {
+	if policy < 0 || int(policy) >= len(errorPolicyNames) {
+		return fmt.Sprintf("ErrorPolicy(%d)", int(policy))
+	}
+	return errorPolicyNames[policy]
+}
+
+// apply returns the error of the operation that failed with err, and whose Post hooks returned hookErr.
+func (policy ErrorPolicy) apply(err, hookErr error) error {
+	if hookErr == nil {
+		return err
+	}
+	switch policy {
+	case ErrorPolicyReplace:
+		return hookErr
+	case ErrorPolicyWrap:
+		return &PostHookError{
+			Err:     err,
+			HookErr: hookErr,
+		}
+	}
+	return err
+}
+
+// PostHookError is the error returned by the operations when a Post hook returned an error,
+// and the error policy is ErrorPolicyWrap.
+type PostHookError struct {
+	// Err is the error of the operation. It is nil if the operation succeeded.
+	Err error
+
+	// HookErr is the error returned by the Post hooks.
+	HookErr error
+}
+
+func (e *PostHookError) Error() string {
+	if e.Err == nil {
+		return "proxy: post hook failed: " + e.HookErr.Error()
+	}
+	return e.Err.Error() + " (proxy: post hook failed: " + e.HookErr.Error() + ")"
+}
+
+// Unwrap returns the error of the operation, or the error returned by the Post hooks if the operation succeeded.
+func (e *PostHookError) Unwrap() error {
+	if e.Err == nil {
+		return e.HookErr
+	}
+	return e.Err
+}
+
+// WithErrorPolicy returns a new Proxy that handles the errors returned by the Post hooks with policy.
+// p is not modified.
+func (p *Proxy) WithErrorPolicy(policy ErrorPolicy) *Proxy {
+	np := p.clone()
+	np.errorPolicy = policy
+	return np
+}
+
+// postError applies the error policy of p to *err, the error of the operation, and hookErr, the error returned by the Post hooks.
+// It reports whether the operation succeeded but fails now, so the caller must release the resources of the operation.
+func (p *Proxy) postError(err *error, hookErr error) (failed bool) {
+	if hookErr == nil {
+		return false
+	}
+	orig := *err
+	*err = p.errorPolicy.apply(orig, hookErr)
+	return orig == nil && *err != nil
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestErrorPolicy(t *testing.T) {
+	errDriver := errors.New("driver error")
+	errHook := errors.New("hook error")
+	newConnWithPolicy := func(policy ErrorPolicy, fail bool) *Conn {
+		p := NewProxyContext(fdriver, &HooksContext{
+			PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+				return errHook
+			},
+		}).WithInterceptors(Interceptors{
+			Exec: func(next ExecFunc) ExecFunc {
+				if !fail {
+					return next
+				}
+				return func(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Result, error) {
+					return nil, errDriver
+				}
+			},
+		}).WithErrorPolicy(policy)
+		return newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+	}
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+	query := "INSERT INTO t1 (id) VALUES(?)"
+
+	t.Run("ignore", func(t *testing.T) {
+		_, err := newConnWithPolicy(ErrorPolicyIgnore, false).ExecContext(context.Background(), query, args)
+		if err != nil {
+			t.Errorf("want success, got %v", err)
+		}
+		_, err = newConnWithPolicy(ErrorPolicyIgnore, true).ExecContext(context.Background(), query, args)
+		if err != errDriver {
+			t.Errorf("want %v, got %v", errDriver, err)
+		}
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		result, err := newConnWithPolicy(ErrorPolicyReplace, false).ExecContext(context.Background(), query, args)
+		if err != errHook || result != nil {
+			t.Errorf("want (nil, %v), got (%v, %v)", errHook, result, err)
+		}
+		_, err = newConnWithPolicy(ErrorPolicyReplace, true).ExecContext(context.Background(), query, args)
+		if err != errHook {
+			t.Errorf("want %v, got %v", errHook, err)
+		}
+	})
+
+	t.Run("wrap", func(t *testing.T) {
+		result, err := newConnWithPolicy(ErrorPolicyWrap, false).ExecContext(context.Background(), query, args)
+		if perr, ok := err.(*PostHookError); !ok || perr.Err != nil || perr.HookErr != errHook || result != nil {
+			t.Errorf("want (nil, %v), got (%v, %v)", errHook, result, err)
+		}
+		_, err = newConnWithPolicy(ErrorPolicyWrap, true).ExecContext(context.Background(), query, args)
+		if perr, ok := err.(*PostHookError); !ok || perr.Err != errDriver || perr.HookErr != errHook {
+			t.Errorf("want PostHookError, got %v", err)
+		}
+		if want := "driver error (proxy: post hook failed: hook error)"; err.Error() != want {
+			t.Errorf("want %q, got %q", want, err.Error())
+		}
+	})
+}
+
+func TestErrorPolicy_ReleaseRows(t *testing.T) {
+	errHook := errors.New("hook error")
+	p := NewProxyContext(fdriver, &HooksContext{
+		PostQuery: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+			return errHook
+		},
+	}).WithErrorPolicy(ErrorPolicyReplace)
+	db := &fakeDB{log: &bytes.Buffer{}}
+	conn := newConn(&fakeConnCtx{db: db, opt: &fakeConnOption{}}, p)
+
+	rows, err := conn.QueryContext(context.Background(), "SELECT id FROM t1 WHERE id = ?", []driver.NamedValue{{Ordinal: 1, Value: int64(1)}})
+	if err != errHook || rows != nil {
+		t.Errorf("want (nil, %v), got (%v, %v)", errHook, rows, err)
+	}
+}
+
+func TestErrorPolicy_String(t *testing.T) {
+	if got := ErrorPolicyWrap.String(); got != "Wrap" {
+		t.Errorf("want Wrap, got %s", got)
+	}
+	if got := ErrorPolicy(-1).String(); got != "ErrorPolicy(-1)" {
+		t.Errorf("want ErrorPolicy(-1), got %s", got)
+	}
+}
@@ -0,0 +1,35 @@
+package proxy
+
+import "errors"
+
+var (
+	// ErrNonDefaultIsolationLevel is returned by Conn.BeginTx
+	// when a non-default isolation level is requested,
+	// but the original driver does not support it.
+	ErrNonDefaultIsolationLevel = errors.New("proxy: driver does not support non-default isolation level")
+
+	// ErrReadOnlyTransaction is returned by Conn.BeginTx
+	// when a read-only transaction is requested,
+	// but the original driver does not support it.
+	ErrReadOnlyTransaction = errors.New("proxy: driver does not support read-only transactions")
+
+	// ErrNamedParametersNotSupported is returned when named parameters are passed,
+	// but the original driver does not support them.
+	ErrNamedParametersNotSupported = errors.New("proxy: driver does not support the use of Named Parameters")
+
+	// ErrInvalidConnection is passed to the PostIsValid hooks of stacked hooks
+	// when the connection is marked as invalid.
+	ErrInvalidConnection = errors.New("proxy: invalid connection")
+
+	// ErrBorrowedConnection is returned when a connection borrowed by InstrumentDB
+	// is requested without the way to return it.
+	ErrBorrowedConnection = errors.New("proxy: the connection is borrowed from another pool")
+
+	// ErrNilDriver is returned by TryNewProxyContext when the driver is nil.
+	// The other constructors of Proxy panic with it.
+	ErrNilDriver = errors.New("proxy: the driver is nil")
+
+	// ErrNilConnector is returned by TryNewConnector when the connector or its driver is nil.
+	// NewConnector and OpenDB panic with it.
+	ErrNilConnector = errors.New("proxy: the connector is nil")
+)
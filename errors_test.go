@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	conn := &Conn{
+		Conn:  &fakeConn{db: &fakeDB{}, opt: &fakeConnOption{}},
+		Proxy: NewProxyContext(fdriver),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := conn.BeginTx(ctx, driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelLinearizable)})
+	if err != ErrNonDefaultIsolationLevel {
+		t.Errorf("want %v, got %v", ErrNonDefaultIsolationLevel, err)
+	}
+
+	_, err = conn.BeginTx(ctx, driver.TxOptions{ReadOnly: true})
+	if err != ErrReadOnlyTransaction {
+		t.Errorf("want %v, got %v", ErrReadOnlyTransaction, err)
+	}
+
+	_, err = namedValuesToValues([]driver.NamedValue{{Name: "foo", Ordinal: 1, Value: 1}})
+	if err != ErrNamedParametersNotSupported {
+		t.Errorf("want %v, got %v", ErrNamedParametersNotSupported, err)
+	}
+}
+
+func TestErrNil(t *testing.T) {
+	if _, err := TryNewProxyContext(nil); err != ErrNilDriver {
+		t.Errorf("want %v, got %v", ErrNilDriver, err)
+	}
+	if _, err := TryNewProxyContext((*Proxy)(nil)); err != ErrNilDriver {
+		t.Errorf("want %v, got %v", ErrNilDriver, err)
+	}
+	if _, err := TryNewConnector(nil); err != ErrNilConnector {
+		t.Errorf("want %v, got %v", ErrNilConnector, err)
+	}
+	if _, err := TryNewConnector(&fakeConnector{}); err != ErrNilConnector {
+		t.Errorf("want %v, got %v", ErrNilConnector, err)
+	}
+
+	defer func() {
+		if err := recover(); err != ErrNilDriver {
+			t.Errorf("want panic with %v, got %v", ErrNilDriver, err)
+		}
+	}()
+	NewProxyContext(nil)
+}
@@ -0,0 +1,191 @@
+package proxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// EventVersion is the version of the Event schema.
+// It is incremented only when the meaning of existing fields changes.
+// New fields may be added without incrementing the version,
+// so the consumers of events should ignore unknown fields.
+const EventVersion = 1
+
+// Event describes an operation observed by the hooks.
+// It is the stable schema shared by the outputs of structured events.
+type Event struct {
+	// Version is the version of the schema. It is always EventVersion.
+	Version int `json:"version"`
+
+	// Operation is the kind of the operation.
+	Operation Operation `json:"operation"`
+
+	// ConnID is the ID of the connection. It is zero if unknown.
+	ConnID int64 `json:"conn_id,omitempty"`
+
+	// TxID is the ID of the transaction.
+	// It is zero if the operation is not executed in a transaction.
+	TxID int64 `json:"tx_id,omitempty"`
+
+	// Query is the query string of Prepare, Exec and Query operations.
+	Query string `json:"query,omitempty"`
+
+	// Args is the arguments of Exec and Query operations.
+	Args []EventArg `json:"args,omitempty"`
+
+	// Uses is the number of uses of the prepared statement, including the operation.
+	// It is set only for Exec and Query operations of prepared statements.
+	Uses int64 `json:"uses,omitempty"`
+
+	// Start is the time when the operation started.
+	Start time.Time `json:"start"`
+
+	// Duration is the duration of the operation.
+	Duration time.Duration `json:"duration"`
+
+	// Error is the error message of the operation.
+	// It is empty if the operation succeeded.
+	Error string `json:"error,omitempty"`
+
+	// Labels is the labels associated with the context by WithLabels.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// EventArg is an argument of Exec and Query operations.
+type EventArg struct {
+	// Name is the name of the argument. It is empty if the argument is not named.
+	Name string `json:"name,omitempty"`
+
+	// Ordinal is the position of the argument starting from one.
+	Ordinal int `json:"ordinal"`
+
+	// Value is the value of the argument.
+	Value driver.Value `json:"value"`
+}
+
+func newEventArgs(args []driver.NamedValue) []EventArg {
+	if len(args) == 0 {
+		return nil
+	}
+	ret := make([]EventArg, len(args))
+	for i, arg := range args {
+		ret[i] = EventArg{
+			Name:    arg.Name,
+			Ordinal: arg.Ordinal,
+			Value:   arg.Value,
+		}
+	}
+	return ret
+}
+
+// NewEventHooks creates new HooksContext which calls f with an Event
+// at the end of every operation.
+// The operations that fail with driver.ErrSkip are not reported,
+// because database/sql retries them in another way, which is reported instead.
+// f must not retain the event after it returns.
+func NewEventHooks(f func(c context.Context, e *Event)) *HooksContext {
+	emit := func(c context.Context, op Operation, ctx interface{}, conn *Conn, query string, args []driver.NamedValue, uses int64, err error) {
+		if err == driver.ErrSkip {
+			return
+		}
+		start, _ := ctx.(time.Time)
+		d, ok := DurationFromContext(c)
+		if !ok {
+			d = time.Since(start)
+		}
+		e := &Event{
+			Version:   EventVersion,
+			Operation: op,
+			Query:     query,
+			Args:      newEventArgs(args),
+			Uses:      uses,
+			Start:     start,
+			Duration:  d,
+			Labels:    LabelsFromContext(c),
+		}
+		if conn != nil {
+			e.ConnID = conn.id
+		}
+		e.TxID, _ = TxIDFromContext(c)
+		if err != nil {
+			e.Error = err.Error()
+		}
+		f(c, e)
+	}
+	now := func() interface{} {
+		return time.Now()
+	}
+	return &HooksContext{
+		PrePing: func(_ context.Context, _ *Conn) (interface{}, error) {
+			return now(), nil
+		},
+		PostPing: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			emit(c, OpPing, ctx, conn, "", nil, 0, err)
+			return nil
+		},
+		PreOpen: func(_ context.Context, _ string) (interface{}, error) {
+			return now(), nil
+		},
+		PostOpen: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			emit(c, OpOpen, ctx, conn, "", nil, 0, err)
+			return nil
+		},
+		PrePrepare: func(_ context.Context, _ *Stmt) (interface{}, error) {
+			return now(), nil
+		},
+		PostPrepare: func(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+			emit(c, OpPrepare, ctx, stmt.Conn, stmt.QueryString, nil, 0, err)
+			return nil
+		},
+		PreExec: func(_ context.Context, _ *Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return now(), nil
+		},
+		PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, _ driver.Result, err error) error {
+			emit(c, OpExec, ctx, stmt.Conn, stmt.QueryString, args, stmt.preparedUses(), err)
+			return nil
+		},
+		PreQuery: func(_ context.Context, _ *Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return now(), nil
+		},
+		PostQuery: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, _ driver.Rows, err error) error {
+			emit(c, OpQuery, ctx, stmt.Conn, stmt.QueryString, args, stmt.preparedUses(), err)
+			return nil
+		},
+		PreBegin: func(_ context.Context, _ *Conn) (interface{}, error) {
+			return now(), nil
+		},
+		PostBegin: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			emit(c, OpBegin, ctx, conn, "", nil, 0, err)
+			return nil
+		},
+		PreCommit: func(_ context.Context, _ *Tx) (interface{}, error) {
+			return now(), nil
+		},
+		PostCommit: func(c context.Context, ctx interface{}, tx *Tx, err error) error {
+			emit(c, OpCommit, ctx, tx.Conn, "", nil, 0, err)
+			return nil
+		},
+		PreRollback: func(_ context.Context, _ *Tx) (interface{}, error) {
+			return now(), nil
+		},
+		PostRollback: func(c context.Context, ctx interface{}, tx *Tx, err error) error {
+			emit(c, OpRollback, ctx, tx.Conn, "", nil, 0, err)
+			return nil
+		},
+		PreClose: func(_ context.Context, _ *Conn) (interface{}, error) {
+			return now(), nil
+		},
+		PostClose: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			emit(c, OpClose, ctx, conn, "", nil, 0, err)
+			return nil
+		},
+		PreResetSession: func(_ context.Context, _ *Conn) (interface{}, error) {
+			return now(), nil
+		},
+		PostResetSession: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			emit(c, OpResetSession, ctx, conn, "", nil, 0, err)
+			return nil
+		},
+	}
+}
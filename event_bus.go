@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// QueryEvent is an operation published to the subscribers of EventBus.
+// Unlike the events passed to the callbacks of NewEventHooks,
+// it is a copy owned by each subscriber, so it may be retained.
+type QueryEvent struct {
+	Event
+}
+
+// EventBus publishes the operations of all the proxies to the subscribers,
+// so multiple consumers, e.g. metrics, logging and anomaly detection,
+// can observe the same stream without composing the hooks.
+// The proxies feed the bus only while it has subscribers, so it costs nothing otherwise.
+type EventBus struct {
+	mu   sync.RWMutex
+	subs map[chan<- QueryEvent]struct{}
+
+	// the number of the subscribers, which is read without locking mu.
+	n int32
+
+	// the number of the events dropped because the channels are full.
+	dropped int64
+
+	hooks *HooksContext
+}
+
+var defaultEventBus = newEventBus()
+
+// Events returns the EventBus which the proxies publish their operations to.
+func Events() *EventBus {
+	return defaultEventBus
+}
+
+func newEventBus() *EventBus {
+	b := &EventBus{
+		subs: make(map[chan<- QueryEvent]struct{}),
+	}
+	b.hooks = NewEventHooks(func(_ context.Context, e *Event) {
+		b.publish(e)
+	})
+	return b
+}
+
+// Subscribe starts sending the events to ch, and returns the function to stop it.
+// The events are sent without blocking the operations:
+// if ch is not ready, the event is dropped, and it is counted by Dropped.
+// Use a buffered channel and receive the events promptly.
+func (b *EventBus) Subscribe(ch chan<- QueryEvent) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; !ok {
+		b.subs[ch] = struct{}{}
+		atomic.AddInt32(&b.n, 1)
+	}
+	return func() {
+		b.Unsubscribe(ch)
+	}
+}
+
+// Unsubscribe stops sending the events to ch.
+// No events are sent to ch after it returns, so ch can be closed safely.
+func (b *EventBus) Unsubscribe(ch chan<- QueryEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		atomic.AddInt32(&b.n, -1)
+	}
+}
+
+// Dropped returns the number of the events dropped because the channels of the subscribers were not ready.
+func (b *EventBus) Dropped() int64 {
+	return atomic.LoadInt64(&b.dropped)
+}
+
+// active reports whether b has any subscribers.
+func (b *EventBus) active() bool {
+	return atomic.LoadInt32(&b.n) > 0
+}
+
+func (b *EventBus) publish(e *Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subs {
+		ev := QueryEvent{Event: *e}
+		if len(e.Args) > 0 {
+			ev.Args = append([]EventArg(nil), e.Args...)
+		}
+		select {
+		case ch <- ev:
+		default:
+			atomic.AddInt64(&b.dropped, 1)
+		}
+	}
+}
+
+// withEventBus returns the hooks that call h and then feed the event bus if it has any subscribers.
+func withEventBus(h hooks) hooks {
+	if !defaultEventBus.active() {
+		return h
+	}
+	return appendHooks(h, defaultEventBus.hooks)
+}
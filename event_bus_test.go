@@ -0,0 +1,82 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	sql.Register("fakedb-event-bus", NewProxyContext(fdriver))
+	db, err := sql.Open("fakedb-event-bus", `{"Name":"event-bus","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	if err := db.PingContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the proxies feed the bus only while it has subscribers.
+	if Events().active() {
+		t.Fatal("want no subscribers")
+	}
+
+	ch1 := make(chan QueryEvent, 100)
+	ch2 := make(chan QueryEvent, 100)
+	unsubscribe1 := Events().Subscribe(ch1)
+	unsubscribe2 := Events().Subscribe(ch2)
+	if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?) -- event bus", 1); err != nil {
+		t.Fatal(err)
+	}
+	unsubscribe1()
+	if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?) -- event bus", 2); err != nil {
+		t.Fatal(err)
+	}
+	unsubscribe2()
+	if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?) -- event bus", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	collect := func(ch chan QueryEvent) []int64 {
+		var ret []int64
+		for {
+			select {
+			case e := <-ch:
+				if e.Operation != OpExec || e.Query != "INSERT INTO t1 (id) VALUES(?) -- event bus" {
+					continue
+				}
+				if e.ConnID == 0 || e.Duration < 0 || len(e.Args) != 1 {
+					t.Errorf("unexpected event: %#v", e)
+				}
+				ret = append(ret, e.Args[0].Value.(int64))
+			default:
+				return ret
+			}
+		}
+	}
+	if got := collect(ch1); len(got) != 1 || got[0] != 1 {
+		t.Errorf("want [1], got %v", got)
+	}
+	if got := collect(ch2); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("want [1 2], got %v", got)
+	}
+}
+
+func TestEventBus_Dropped(t *testing.T) {
+	b := newEventBus()
+	ch := make(chan QueryEvent, 1)
+	defer b.Subscribe(ch)()
+
+	for i := 0; i < 3; i++ {
+		b.publish(&Event{Operation: OpPing})
+	}
+	if n := b.Dropped(); n != 2 {
+		t.Errorf("want 2 dropped events, got %d", n)
+	}
+	if e := <-ch; e.Operation != OpPing {
+		t.Errorf("unexpected event: %#v", e)
+	}
+}
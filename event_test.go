@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewEventHooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []Event
+	sql.Register("fakedb-event-hooks", NewProxyContext(fdriver, NewEventHooks(func(c context.Context, e *Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, *e)
+	})))
+	db, err := sql.Open("fakedb-event-hooks", `{"Name":"event-hooks","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := WithLabels(context.Background(), map[string]string{"job": "test"})
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var ops []Operation
+	for _, e := range events {
+		ops = append(ops, e.Operation)
+		if e.Version != EventVersion {
+			t.Errorf("want version %d, got %d", EventVersion, e.Version)
+		}
+		if e.ConnID == 0 {
+			t.Errorf("%v: want a connection ID, got zero", e.Operation)
+		}
+		if e.Start.IsZero() {
+			t.Errorf("%v: want the start time, got zero", e.Operation)
+		}
+	}
+	wantOps := []Operation{OpOpen, OpBegin, OpExec, OpCommit}
+	if !reflect.DeepEqual(ops, wantOps) {
+		t.Fatalf("want %v, got %v", wantOps, ops)
+	}
+
+	exec := events[2]
+	if exec.TxID == 0 {
+		t.Error("want a transaction ID, got zero")
+	}
+	if exec.Query != "INSERT INTO t1 (id) VALUES(?)" {
+		t.Errorf("unexpected query: %q", exec.Query)
+	}
+	wantArgs := []EventArg{{Ordinal: 1, Value: int64(1)}}
+	if !reflect.DeepEqual(exec.Args, wantArgs) {
+		t.Errorf("want %v, got %v", wantArgs, exec.Args)
+	}
+	if exec.Labels["job"] != "test" {
+		t.Errorf("want the label job=test, got %v", exec.Labels)
+	}
+}
+
+func TestNewEventHooks_ErrSkip(t *testing.T) {
+	called := false
+	h := NewEventHooks(func(c context.Context, e *Event) {
+		called = true
+	})
+	stmt := &Stmt{QueryString: "INSERT INTO t1 (id) VALUES(?)"}
+	if err := h.PostExec(context.Background(), time.Now(), stmt, nil, nil, driver.ErrSkip); err != nil {
+		t.Fatal(err)
+	}
+	if called {
+		t.Error("want no events for driver.ErrSkip")
+	}
+}
+
+func TestNewEventHooks_Duration(t *testing.T) {
+	var got time.Duration
+	h := NewEventHooks(func(c context.Context, e *Event) {
+		got = e.Duration
+	})
+	stmt := &Stmt{QueryString: "INSERT INTO t1 (id) VALUES(?)"}
+	start := time.Now().Add(-time.Hour)
+	c := context.WithValue(context.Background(), durationKey{}, 3*time.Second)
+	if err := h.PostExec(c, start, stmt, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got != 3*time.Second {
+		t.Errorf("want the duration measured by the proxy, got %v", got)
+	}
+}
+
+func TestEvent_JSON(t *testing.T) {
+	e := &Event{
+		Version:   EventVersion,
+		Operation: OpExec,
+		ConnID:    1,
+		Query:     "SELECT 1",
+		Args:      []EventArg{{Ordinal: 1, Value: "foo"}},
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["version"] != float64(EventVersion) {
+		t.Errorf("unexpected version: %v", got["version"])
+	}
+	if got["operation"] != "Exec" {
+		t.Errorf("unexpected operation: %v", got["operation"])
+	}
+	if _, ok := got["tx_id"]; ok {
+		t.Error("tx_id should be omitted")
+	}
+}
@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+)
+
+// defaultExpvarPrefix is the default of ExpvarOptions.Prefix.
+const defaultExpvarPrefix = "sqlproxy"
+
+// ExpvarOptions holds the options of Proxy.PublishExpvar.
+type ExpvarOptions struct {
+	// Prefix is the prefix of the names of the variables.
+	// If it is empty, "sqlproxy" is used.
+	Prefix string
+
+	// SlowQuery is a threshold duration of slow queries.
+	// If it is zero, no queries are slow.
+	SlowQuery time.Duration
+}
+
+// PublishExpvar publishes the statistics of p via expvar, for the users who don't run any monitoring systems.
+// The variables are named after Prefix:
+//
+//   - Prefix.queries: the number of Exec and Query
+//   - Prefix.errors: the number of the operations that returned errors
+//   - Prefix.slow_queries: the number of Exec and Query that took SlowQuery or longer
+//   - Prefix.open_connections: the number of the open connections
+//
+// The statistics of the proxies created from p by With and the other methods are not included.
+// It panics if the names are already published, in the same manner as expvar.Publish.
+func (p *Proxy) PublishExpvar(opt ExpvarOptions) {
+	prefix := opt.Prefix
+	if prefix == "" {
+		prefix = defaultExpvarPrefix
+	}
+	atomic.StoreInt64(&p.stats.slowNanos, int64(opt.SlowQuery))
+
+	s := &p.stats
+	expvar.Publish(prefix+".queries", expvar.Func(func() interface{} {
+		return atomic.LoadInt64(&s.ops[OpExec].count) + atomic.LoadInt64(&s.ops[OpQuery].count)
+	}))
+	expvar.Publish(prefix+".errors", expvar.Func(func() interface{} {
+		var n int64
+		for i := range s.ops {
+			n += atomic.LoadInt64(&s.ops[i].errors)
+		}
+		return n
+	}))
+	expvar.Publish(prefix+".slow_queries", expvar.Func(func() interface{} {
+		return atomic.LoadInt64(&s.slow)
+	}))
+	expvar.Publish(prefix+".open_connections", expvar.Func(func() interface{} {
+		return atomic.LoadInt64(&s.openConns)
+	}))
+}
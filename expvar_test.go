@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"database/sql"
+	"expvar"
+	"testing"
+	"time"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	p := NewProxyWithOptions(fdriver, WithExpvar(ExpvarOptions{
+		Prefix:    "test-publish-expvar",
+		SlowQuery: time.Nanosecond,
+	}))
+	sql.Register("fakedb-publish-expvar", p)
+
+	db, err := sql.Open("fakedb-publish-expvar", `{"Name":"publish-expvar","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(1)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(2)"); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(name string) string {
+		v := expvar.Get("test-publish-expvar." + name)
+		if v == nil {
+			t.Fatalf("%s is not published", name)
+		}
+		return v.String()
+	}
+	if got := get("queries"); got != "2" {
+		t.Errorf("want 2 queries, got %s", got)
+	}
+	if got := get("errors"); got != "0" {
+		t.Errorf("want 0 errors, got %s", got)
+	}
+	if got := get("slow_queries"); got != "2" {
+		t.Errorf("want 2 slow queries, got %s", got)
+	}
+	if got := get("open_connections"); got != "1" {
+		t.Errorf("want 1 open connection, got %s", got)
+	}
+
+	db.Close()
+	if got := get("open_connections"); got != "0" {
+		t.Errorf("want 0 open connections, got %s", got)
+	}
+}
@@ -24,6 +24,20 @@ type fakeConnOption struct {
 
 	// call of Query will fail if failQuery is true
 	FailQuery bool
+
+	// NumRows is the number of rows returned by Query
+	NumRows int
+
+	// Next of the rows will fail after NumRows rows if FailNext is true
+	FailNext bool
+
+	// RowsAffected is the number of rows affected by Exec.
+	// Exec returns nil result if it is zero.
+	RowsAffected int64
+
+	// NumInput is the number of placeholders reported by the statements.
+	// The statements don't know the number if it is zero.
+	NumInput int
 }
 
 type fakeDriver struct {
@@ -65,7 +79,10 @@ type fakeStmtExt fakeStmt
 // fakeStmtCtx is fakeStmt with context
 type fakeStmtCtx fakeStmt
 
-type fakeRows struct{}
+type fakeRows struct {
+	remaining int
+	fail      bool
+}
 
 var fdriver = &fakeDriver{}
 var _ driver.Driver = &fakeDriver{}
@@ -206,7 +223,7 @@ func (c *fakeConnExt) Exec(query string, args []driver.Value) (driver.Result, er
 		c.db.Log("[Conn.Exec]", "ERROR!")
 		return nil, errors.New("Exec failed")
 	}
-	return nil, nil
+	return c.opt.result(), nil
 }
 
 func (c *fakeConnExt) Query(query string, args []driver.Value) (driver.Rows, error) {
@@ -215,7 +232,7 @@ func (c *fakeConnExt) Query(query string, args []driver.Value) (driver.Rows, err
 		c.db.Log("[Conn.Query]", "ERROR!")
 		return nil, errors.New("Query failed")
 	}
-	return &fakeRows{}, nil
+	return &fakeRows{remaining: c.opt.NumRows, fail: c.opt.FailNext}, nil
 }
 
 func (c *fakeConnCtx) Ping(ctx context.Context) error {
@@ -261,7 +278,7 @@ func (c *fakeConnCtx) ExecContext(ctx context.Context, query string, args []driv
 		c.db.Log("[Conn.ExecContext]", "ERROR!")
 		return nil, errors.New("Exec failed")
 	}
-	return nil, nil
+	return c.opt.result(), nil
 }
 
 func (c *fakeConnCtx) Query(query string, args []driver.Value) (driver.Rows, error) {
@@ -274,7 +291,7 @@ func (c *fakeConnCtx) QueryContext(ctx context.Context, query string, args []dri
 		c.db.Log("[Conn.QueryContext]", "ERROR!")
 		return nil, errors.New("Query failed")
 	}
-	return &fakeRows{}, nil
+	return &fakeRows{remaining: c.opt.NumRows, fail: c.opt.FailNext}, nil
 }
 
 func (tx *fakeTx) Commit() error {
@@ -293,7 +310,7 @@ func (stmt *fakeStmt) Close() error {
 }
 
 func (stmt *fakeStmt) NumInput() int {
-	return -1 // fakeDriver doesn't know its number of placeholders
+	return stmt.opt.numInput()
 }
 
 func (stmt *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
@@ -302,7 +319,7 @@ func (stmt *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
 		stmt.db.Log("[Stmt.Exec]", "ERROR!")
 		return nil, errors.New("Exec failed")
 	}
-	return nil, nil
+	return stmt.opt.result(), nil
 }
 
 func (stmt *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
@@ -311,7 +328,7 @@ func (stmt *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
 		stmt.db.Log("[Stmt.Query]", "ERROR!")
 		return nil, errors.New("Query failed")
 	}
-	return &fakeRows{}, nil
+	return &fakeRows{remaining: stmt.opt.NumRows, fail: stmt.opt.FailNext}, nil
 }
 
 func (stmt *fakeStmtExt) Close() error {
@@ -320,7 +337,7 @@ func (stmt *fakeStmtExt) Close() error {
 }
 
 func (stmt *fakeStmtExt) NumInput() int {
-	return -1 // fakeDriver doesn't know its number of placeholders
+	return stmt.opt.numInput()
 }
 
 func (stmt *fakeStmtExt) Exec(args []driver.Value) (driver.Result, error) {
@@ -342,7 +359,7 @@ func (stmt *fakeStmtCtx) Close() error {
 }
 
 func (stmt *fakeStmtCtx) NumInput() int {
-	return -1 // fakeDriver doesn't know its number of placeholders
+	return stmt.opt.numInput()
 }
 
 func (stmt *fakeStmtCtx) Exec(args []driver.Value) (driver.Result, error) {
@@ -355,7 +372,7 @@ func (stmt *fakeStmtCtx) ExecContext(ctx context.Context, args []driver.NamedVal
 		stmt.db.Log("[Conn.ExecContext]", "ERROR!")
 		return nil, errors.New("Exec failed")
 	}
-	return nil, nil
+	return stmt.opt.result(), nil
 }
 
 func (stmt *fakeStmtCtx) Query(args []driver.Value) (driver.Rows, error) {
@@ -368,7 +385,7 @@ func (stmt *fakeStmtCtx) QueryContext(ctx context.Context, args []driver.NamedVa
 		stmt.db.Log("[Conn.QueryContext]", "ERROR!")
 		return nil, errors.New("Query failed")
 	}
-	return &fakeRows{}, nil
+	return &fakeRows{remaining: stmt.opt.NumRows, fail: stmt.opt.FailNext}, nil
 }
 
 func (stmt *fakeStmtCtx) ColumnConverter(idx int) driver.ValueConverter {
@@ -385,7 +402,29 @@ func (rows *fakeRows) Close() error {
 }
 
 func (rows *fakeRows) Next(dest []driver.Value) error {
-	return io.EOF
+	if rows.remaining <= 0 {
+		if rows.fail {
+			return errors.New("next failed")
+		}
+		return io.EOF
+	}
+	dest[0] = int64(rows.remaining)
+	rows.remaining--
+	return nil
+}
+
+func (opt *fakeConnOption) numInput() int {
+	if opt.NumInput == 0 {
+		return -1 // fakeDriver doesn't know its number of placeholders
+	}
+	return opt.NumInput
+}
+
+func (opt *fakeConnOption) result() driver.Result {
+	if opt.RowsAffected == 0 {
+		return nil
+	}
+	return driver.RowsAffected(opt.RowsAffected)
 }
 
 func convertValuesToString(args []driver.Value) string {
@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// FilePathFilter is a FileFilter that skips the frames in the files matching the patterns,
+// e.g. the code generated by ORMs. It doesn't skip any packages by itself,
+// so combine it with DefaultPackageFilter by MultiFilter.
+type FilePathFilter struct {
+	// Globs are the patterns of path.Match.
+	// A pattern matches a file if it matches the path or any of its trailing parts,
+	// e.g. both of "generated/*.go" and "*/generated/*" match "/src/app/generated/models.go".
+	Globs []string
+
+	// Regexps are the regular expressions of the file paths.
+	Regexps []*regexp.Regexp
+}
+
+// DoOutput always returns true.
+func (f *FilePathFilter) DoOutput(packageName string) bool {
+	return true
+}
+
+// DoOutputFile returns false if the file matches any of the patterns.
+func (f *FilePathFilter) DoOutputFile(file string) bool {
+	for _, re := range f.Regexps {
+		if re.MatchString(file) {
+			return false
+		}
+	}
+	for _, glob := range f.Globs {
+		if matchPathSuffix(glob, file) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPathSuffix reports whether glob matches name or any of its trailing parts split by "/".
+func matchPathSuffix(glob, name string) bool {
+	for {
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+		i := strings.IndexByte(name, '/')
+		if i < 0 {
+			return false
+		}
+		name = name[i+1:]
+	}
+}
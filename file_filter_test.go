@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFilePathFilter(t *testing.T) {
+	f := &FilePathFilter{
+		Globs:   []string{"*/generated/*", "*_gen.go"},
+		Regexps: []*regexp.Regexp{regexp.MustCompile(`/vendor/`)},
+	}
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{"/src/app/generated/models.go", false},
+		{"/src/app/models_gen.go", false},
+		{"/src/app/vendor/github.com/foo/orm/orm.go", false},
+		{"/src/app/main.go", true},
+		{"/src/app/generated.go", true},
+	}
+	for _, tt := range tests {
+		if got := f.DoOutputFile(tt.file); got != tt.want {
+			t.Errorf("DoOutputFile(%q): want %v, got %v", tt.file, tt.want, got)
+		}
+	}
+	if !f.DoOutput("github.com/foo/orm") {
+		t.Error("want FilePathFilter not to skip packages, but skipped")
+	}
+}
+
+func TestMultiFilter(t *testing.T) {
+	f := MultiFilter(
+		PackageFilter{"github.com/foo/orm": struct{}{}},
+		nil,
+		&FilePathFilter{Globs: []string{"*/generated/*"}},
+	)
+	if f.DoOutput("github.com/foo/orm") {
+		t.Error("want the package to be skipped, but not")
+	}
+	if !f.DoOutput("github.com/foo/app") {
+		t.Error("want the package not to be skipped, but skipped")
+	}
+	if !doOutputFrame(f, "github.com/foo/app.Run", "/src/app/main.go") {
+		t.Error("want the frame not to be skipped, but skipped")
+	}
+	if doOutputFrame(f, "github.com/foo/app/generated.Find", "/src/app/generated/models.go") {
+		t.Error("want the frame of the generated code to be skipped, but not")
+	}
+}
+
+func traceFromFileFilterTest(f Filter) string {
+	return stackTrace(2, f)
+}
+
+func TestFilePathFilter_StackTrace(t *testing.T) {
+	all := traceFromFileFilterTest(PackageFilter{})
+	if !strings.Contains(all, "/file_filter_test.go:") {
+		t.Errorf("want the frames of file_filter_test.go, got:\n%s", all)
+	}
+	filtered := traceFromFileFilterTest(&FilePathFilter{Globs: []string{"*/file_filter_test.go"}})
+	if strings.Contains(filtered, "/file_filter_test.go:") || !strings.Contains(filtered, "testing.tRunner") {
+		t.Errorf("want the frames of file_filter_test.go to be skipped, got:\n%s", filtered)
+	}
+}
@@ -25,17 +25,7 @@ func findCaller(f Filter) int {
 			if name == "" || strings.HasPrefix(name, "runtime.") {
 				continue
 			}
-			// http://stackoverflow.com/questions/25262754/how-to-get-name-of-current-package-in-go
-			dotIdx := 0
-			for j := len(name) - 1; j >= 0; j-- {
-				if name[j] == '.' {
-					dotIdx = j
-				} else if name[j] == '/' {
-					break
-				}
-			}
-			packageName := name[:dotIdx]
-			if f.DoOutput(packageName) {
+			if doOutputFrame(f, name, frame.File) {
 				return skip + i
 			}
 		}
@@ -16,21 +16,12 @@ func findCaller(f Filter) int {
 		n := runtime.Callers(skip, rpc[:])
 
 		for i, pc := range rpc[:n] {
-			// http://stackoverflow.com/questions/25262754/how-to-get-name-of-current-package-in-go
-			name := runtime.FuncForPC(pc).Name()
+			fn := runtime.FuncForPC(pc)
+			name := fn.Name()
 			if name == "" || strings.HasPrefix(name, "runtime.") {
 				continue
 			}
-			dotIdx := 0
-			for j := len(name) - 1; j >= 0; j-- {
-				if name[j] == '.' {
-					dotIdx = j
-				} else if name[j] == '/' {
-					break
-				}
-			}
-			packageName := name[:dotIdx]
-			if f.DoOutput(packageName) {
+			if file, _ := fn.FileLine(pc); doOutputFrame(f, name, file) {
 				return skip + i + 1
 			}
 		}
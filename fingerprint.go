@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"regexp"
+	"strings"
+)
+
+var fingerprintInList = regexp.MustCompile(`\bin\((?:\?, )*\?\)`)
+
+// Fingerprint returns the normalized form of query, so that the logically identical queries have the same fingerprint.
+// It removes comments, replaces literals and placeholders with "?", collapses whitespace and IN-lists,
+// and lowercases the query except for the quoted identifiers. e.g.
+//
+//	SELECT * FROM t1 WHERE id IN (1, 2, 3) AND name = 'foo' -- comment
+//
+// is normalized into
+//
+//	select * from t1 where id in(?+) and name = ?
+func Fingerprint(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	space := false // whitespace is pending
+	emit := func(s string) {
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			space = true
+			i++
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			space = true
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			if j := strings.Index(query[i+2:], "*/"); j >= 0 {
+				i += j + 4
+			} else {
+				i = len(query)
+			}
+			space = true
+		case c == '\'':
+			i = skipQuoted(query, i, '\'', true)
+			emit("?")
+		case c == '"' || c == '`':
+			j := skipQuoted(query, i, c, false)
+			emit(query[i:j])
+			i = j
+		case isDigit(c) || (c == '.' && i+1 < len(query) && isDigit(query[i+1])):
+			for i < len(query) && (isIdentChar(query[i]) || query[i] == '.') {
+				i++
+			}
+			emit("?")
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			i++
+			for i < len(query) && isDigit(query[i]) {
+				i++
+			}
+			emit("?")
+		case isIdentChar(c):
+			j := i
+			for j < len(query) && (isIdentChar(query[j]) || query[j] == '$') {
+				j++
+			}
+			emit(strings.ToLower(query[i:j]))
+			i = j
+		case c == '(':
+			space = false
+			emit("(")
+			i++
+		case c == ')' || c == ',':
+			space = false
+			emit(string(c))
+			space = c == ','
+			i++
+		default:
+			emit(string(c))
+			i++
+		}
+	}
+	return fingerprintInList.ReplaceAllString(b.String(), "in(?+)")
+}
+
+// skipQuoted returns the index next to the quoted string that starts at query[i].
+// The quote is escaped by doubling it, or by a backslash if backslash is true.
+func skipQuoted(query string, i int, quote byte, backslash bool) int {
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || isDigit(c) || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || c >= 0x80
+}
@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"bytes"
+	"database/sql"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{
+			"SELECT * FROM t1 WHERE id IN (1, 2, 3) AND name = 'foo' -- comment",
+			"select * from t1 where id in(?+) and name = ?",
+		},
+		{
+			"select *\n\tfrom t1 /* comment */ where id in(?,?)   and name = ?",
+			"select * from t1 where id in(?+) and name = ?",
+		},
+		{
+			`SELECT "Name", ` + "`Id`" + ` FROM t1 WHERE x = 'it''s' AND y = 'a\'b' AND z = $1`,
+			`select "Name", ` + "`Id`" + ` from t1 where x = ? and y = ? and z = ?`,
+		},
+		{
+			"INSERT INTO t1 (id, score) VALUES(1, -2.5e3)",
+			"insert into t1(id, score) values(?, -?)",
+		},
+		{
+			"SELECT 0x1F, .5",
+			"select ?, ?",
+		},
+	}
+	for _, tt := range tests {
+		if got := Fingerprint(tt.in); got != tt.want {
+			t.Errorf("Fingerprint(%q):\n want %q\n  got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestTracerOptions_Fingerprint(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-fingerprint", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter:   log.New(buf, "", 0),
+		Fingerprint: true,
+	})))
+	db, err := sql.Open("fakedb-trace-fingerprint", `{"name":"trace-fingerprint"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	want := ": insert into t1(id) values(?); args = [1]"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want %q in the log, got:\n%s", want, buf.String())
+	}
+}
@@ -5,7 +5,7 @@ package proxy
 import (
 	"context"
 	"database/sql/driver"
-	"errors"
+	"time"
 )
 
 // hooks is callback functions for the proxy.
@@ -44,10 +44,44 @@ type hooks interface {
 	preIsValid(conn *Conn) (interface{}, error)
 	isValid(ctx interface{}, conn *Conn) error
 	postIsValid(ctx interface{}, conn *Conn, valid bool) error
+	preStmtClose(c context.Context, stmt *Stmt) (interface{}, error)
+	stmtClose(c context.Context, ctx interface{}, stmt *Stmt) error
+	postStmtClose(c context.Context, ctx interface{}, stmt *Stmt, err error) error
+	preRowsClose(c context.Context, rows *Rows) (interface{}, error)
+	rowsClose(c context.Context, ctx interface{}, rows *Rows) error
+	postRowsClose(c context.Context, ctx interface{}, rows *Rows, err error) error
+	postRows(c context.Context, rows *Rows, count int64, d time.Duration) error
+	postLastInsertId(c context.Context, result *Result, id int64, err error) error
+	postRowsAffected(c context.Context, result *Result, n int64, err error) error
+	preConnect(c context.Context, connector *Connector) (interface{}, error)
+	connect(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error
+	postConnect(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error
+	preConnectorClose(c context.Context, connector *Connector) (interface{}, error)
+	postConnectorClose(c context.Context, ctx interface{}, connector *Connector, err error) error
+	onCanceled(c context.Context, op Operation, d time.Duration, err error) error
+	columns(c context.Context, rows *Rows) error
+	preSavepoint(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error)
+	savepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error
+	postSavepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error
+	columnConverter(stmt *Stmt, idx int, conv driver.ValueConverter) driver.ValueConverter
+	oncePerConn(c context.Context, conn *Conn) error
+	rewriteDSN(c context.Context, name string) (string, error)
 }
 
 // HooksContext is callback functions with context.Context for the proxy.
+// The Post hooks can get the elapsed time of the operation by DurationFromContext.
 type HooksContext struct {
+	// Name is an optional name of the hook set.
+	// It is reported by `Proxy.Hooks` and used by `Proxy.LookupHooks`.
+	Name string
+
+	// Priority is the priority of the hook set in a Proxy.
+	// The hook sets with higher priorities are outer: their Pre hooks are called earlier,
+	// and their Post hooks are called later than the others.
+	// The hook sets with the same priority are called in the order of registration.
+	// The default is zero.
+	Priority int
+
 	// PrePing is a callback that gets called prior to calling
 	// `Conn.Ping`, and is ALWAYS called. If this callback returns an
 	// error, the underlying driver's `Conn.Ping` and `Hooks.Ping` methods
@@ -114,7 +148,7 @@ type HooksContext struct {
 
 	// PrePrepare is a callback that gets called prior to calling
 	// `db.Prepare`, and is ALWAYS called. If this callback returns an
-	// error, the underlying driver's `db.Exec` and `Hooks.Prepare` methods
+	// error, the underlying driver's `db.Prepare` and `Hooks.Prepare` methods
 	// are not called.
 	//
 	// The first return value is passed to both `Hooks.Prepare` and
@@ -394,6 +428,245 @@ type HooksContext struct {
 	// The `ctx` parameter is the return value supplied from the
 	// `Hooks.PrePostIsValid` method, and may be nil.
 	PostIsValid func(ctx interface{}, conn *Conn, valid bool) error
+
+	// PreStmtClose is a callback that gets called prior to calling
+	// `Stmt.Close`, and is ALWAYS called. If this callback returns an
+	// error, the underlying driver's `Stmt.Close` and `Hooks.StmtClose` methods
+	// are not called.
+	//
+	// The first return value is passed to both `Hooks.StmtClose` and
+	// `Hooks.PostStmtClose` callbacks. You may specify anything you want.
+	// Return nil if you do not need to use it.
+	//
+	// The second return value is indicates the error found while
+	// executing this hook.
+	PreStmtClose func(c context.Context, stmt *Stmt) (interface{}, error)
+
+	// StmtClose is called after the underlying driver's `Stmt.Close` method
+	// returns without any errors.
+	//
+	// The `ctx` parameter is the return value supplied from the
+	// `Hooks.PreStmtClose` method, and may be nil.
+	//
+	// If this callback returns an error, then the error from this
+	// callback is returned by the `Stmt.Close` method.
+	StmtClose func(c context.Context, ctx interface{}, stmt *Stmt) error
+
+	// PostStmtClose is a callback that gets called at the end of
+	// the call to `Stmt.Close`. It is ALWAYS called.
+	//
+	// The `ctx` parameter is the return value supplied from the
+	// `Hooks.PreStmtClose` method, and may be nil.
+	PostStmtClose func(c context.Context, ctx interface{}, stmt *Stmt, err error) error
+
+	// PreRowsClose is a callback that gets called prior to calling
+	// `Rows.Close`, and is ALWAYS called. If this callback returns an
+	// error, the underlying driver's `Rows.Close` and `Hooks.RowsClose` methods
+	// are not called.
+	//
+	// The first return value is passed to both `Hooks.RowsClose` and
+	// `Hooks.PostRowsClose` callbacks. You may specify anything you want.
+	// Return nil if you do not need to use it.
+	//
+	// The second return value is indicates the error found while
+	// executing this hook.
+	PreRowsClose func(c context.Context, rows *Rows) (interface{}, error)
+
+	// RowsClose is called after the underlying driver's `Rows.Close` method
+	// returns without any errors.
+	// `Rows.Elapsed` returns the total time from the query to the close.
+	//
+	// The `ctx` parameter is the return value supplied from the
+	// `Hooks.PreRowsClose` method, and may be nil.
+	//
+	// If this callback returns an error, then the error from this
+	// callback is returned by the `Rows.Close` method.
+	RowsClose func(c context.Context, ctx interface{}, rows *Rows) error
+
+	// PostRowsClose is a callback that gets called at the end of
+	// the call to `Rows.Close`. It is ALWAYS called.
+	//
+	// The `ctx` parameter is the return value supplied from the
+	// `Hooks.PreRowsClose` method, and may be nil.
+	PostRowsClose func(c context.Context, ctx interface{}, rows *Rows, err error) error
+
+	// PostRows is a callback that gets called once when the rows returned by
+	// a query are exhausted or closed.
+	//
+	// The `count` parameter is the number of rows read by `Rows.Next`,
+	// and the `d` parameter is the duration of the iteration.
+	// If `Rows.Next` fails with an error other than io.EOF, it is called then,
+	// and `Rows.Err` returns the error.
+	PostRows func(c context.Context, rows *Rows, count int64, d time.Duration) error
+
+	// PostLastInsertId is a callback that gets called after `Result.LastInsertId`
+	// of the result returned by `Conn.Exec` or `Stmt.Exec`.
+	//
+	// The `id` and `err` parameters are the return values of the underlying driver's
+	// `Result.LastInsertId` method.
+	PostLastInsertId func(c context.Context, result *Result, id int64, err error) error
+
+	// PostRowsAffected is a callback that gets called after `Result.RowsAffected`
+	// of the result returned by `Conn.Exec` or `Stmt.Exec`.
+	//
+	// The `n` and `err` parameters are the return values of the underlying driver's
+	// `Result.RowsAffected` method.
+	PostRowsAffected func(c context.Context, result *Result, n int64, err error) error
+
+	// PreConnect is a callback that gets called prior to calling
+	// `Connector.Connect`, and is ALWAYS called. If this callback returns an
+	// error, the underlying connector's `Connect` and `Hooks.Connect` methods
+	// are not called.
+	//
+	// The Open hooks are also called inside of the Connect hooks.
+	// `connector.Name` is the data source name of the connector,
+	// and it is empty if the connector is created by NewConnector.
+	//
+	// The first return value is passed to both `Hooks.Connect` and
+	// `Hooks.PostConnect` callbacks. You may specify anything you want.
+	// Return nil if you do not need to use it.
+	//
+	// The second return value is indicates the error found while
+	// executing this hook.
+	PreConnect func(c context.Context, connector *Connector) (interface{}, error)
+
+	// Connect is called after the underlying connector's `Connect` method
+	// returns without any errors.
+	//
+	// The `ctx` parameter is the return value supplied from the
+	// `Hooks.PreConnect` method, and may be nil.
+	//
+	// If this callback returns an error, then the `conn` is
+	// closed by calling the `Close` method, and the error from this
+	// callback is returned by the `Connector.Connect` method.
+	Connect func(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error
+
+	// PostConnect is a callback that gets called at the end of
+	// the call to `Connector.Connect`. It is ALWAYS called.
+	//
+	// The `ctx` parameter is the return value supplied from the
+	// `Hooks.PreConnect` method, and may be nil.
+	PostConnect func(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error
+
+	// PreConnectorClose is a callback that gets called prior to calling
+	// `Connector.Close`, and is ALWAYS called. If this callback returns an
+	// error, the underlying connector is not closed.
+	//
+	// The first return value is passed to the `Hooks.PostConnectorClose`
+	// callback. You may specify anything you want.
+	// Return nil if you do not need to use it.
+	//
+	// The second return value is indicates the error found while
+	// executing this hook.
+	PreConnectorClose func(c context.Context, connector *Connector) (interface{}, error)
+
+	// PostConnectorClose is a callback that gets called at the end of
+	// the call to `Connector.Close`. It is ALWAYS called.
+	// It is a good place to flush metrics or close exporters.
+	//
+	// The `ctx` parameter is the return value supplied from the
+	// `Hooks.PreConnectorClose` method, and may be nil.
+	PostConnectorClose func(c context.Context, ctx interface{}, connector *Connector, err error) error
+
+	// OnCanceled is a callback that gets called when an operation fails
+	// because its context is canceled or its deadline is exceeded.
+	// It is called before the Post hook of the operation.
+	//
+	// The `op` parameter is the kind of the operation, and
+	// the `d` parameter is the elapsed time of the operation.
+	// The `err` parameter is the return value of `c.Err()`,
+	// i.e. `context.Canceled` or `context.DeadlineExceeded`.
+	//
+	// The returned error is ignored.
+	OnCanceled func(c context.Context, op Operation, d time.Duration, err error) error
+
+	// Columns is called after `Hooks.Query` with the columns of the result set.
+	// `columns[i].DatabaseTypeName` and `columns[i].ScanType` are available
+	// only if the driver exposes them.
+	//
+	// If this callback returns an error, then the rows are closed,
+	// and the error from this callback is returned by the `Conn.QueryContext` method.
+	Columns func(c context.Context, rows *Rows, columns []Column) error
+
+	// PreSavepoint is a callback that gets called prior to calling
+	// `Conn.ExecContext` or `Stmt.ExecContext` with a savepoint statement,
+	// e.g. "SAVEPOINT name", and is ALWAYS called for them.
+	// The statements are recognized by ParseSavepoint.
+	// If this callback returns an error, the savepoint statement is not executed.
+	//
+	// The Exec hooks are also called inside of the Savepoint hooks.
+	//
+	// The first return value is passed to both `Hooks.Savepoint` and
+	// `Hooks.PostSavepoint` callbacks. You may specify anything you want.
+	// Return nil if you do not need to use it.
+	//
+	// The second return value is indicates the error found while
+	// executing this hook.
+	PreSavepoint func(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error)
+
+	// Savepoint is called after the savepoint statement is executed
+	// without any errors.
+	//
+	// The `ctx` parameter is the return value supplied from the
+	// `Hooks.PreSavepoint` method, and may be nil.
+	//
+	// If this callback returns an error, then the error from this
+	// callback is returned by the `ExecContext` method.
+	Savepoint func(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error
+
+	// PostSavepoint is a callback that gets called at the end of
+	// the execution of the savepoint statement. It is ALWAYS called.
+	//
+	// The `ctx` parameter is the return value supplied from the
+	// `Hooks.PreSavepoint` method, and may be nil.
+	PostSavepoint func(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error
+
+	// ColumnConverter is called when the converter of the arguments is chosen
+	// for the drivers that use "database/sql/driver".ColumnConverter.
+	// `conv` is the converter returned by the underlying statement, or
+	// driver.DefaultParameterConverter if the statement does not implement ColumnConverter.
+	// The returned converter is used in place of conv, so return conv to observe it only.
+	// It is called only when database/sql itself would consult the converter of the statement,
+	// e.g. database/sql before Go 1.27 doesn't if the statement doesn't know the number of its placeholders.
+	ColumnConverter func(stmt *Stmt, idx int, conv driver.ValueConverter) driver.ValueConverter
+
+	// OncePerConn is called once per underlying connection, before the first
+	// `Conn.Ping`, `Conn.PrepareContext`, `Conn.BeginTx`, `Conn.ExecContext` or `Conn.QueryContext`
+	// on the connection. It is suitable to initialize the session, e.g. setting the session variables.
+	//
+	// If this callback returns an error, the operation fails with the error,
+	// and the callback is called again on the next use of the connection.
+	OncePerConn func(c context.Context, conn *Conn) error
+
+	// RewriteDSN is called before `Hooks.PreOpen` with the data source name of the connection to open.
+	// The returned name is passed to `Hooks.PreOpen` and the underlying driver in place of name,
+	// so it can rotate the credentials, append the connection attributes, and so on.
+	// If the hook set has multiple RewriteDSN hooks, each of them receives the name returned by the previous one.
+	//
+	// If this callback returns an error, the connection is not opened, and the error is returned.
+	// It is not called for the connectors created by NewConnector, whose data source names are unknown.
+	RewriteDSN func(c context.Context, name string) (string, error)
+
+	// tracerFilter is the filter of the hooks created by NewTraceHooks.
+	tracerFilter Filter
+}
+
+func (h *HooksContext) priority() int {
+	if h == nil {
+		return 0
+	}
+	return h.Priority
+}
+
+func (h *HooksContext) describe() []HookSetInfo {
+	if h == nil {
+		return nil
+	}
+	return []HookSetInfo{{
+		Name:  h.Name,
+		Kind:  "HooksContext",
+		Value: h,
+	}}
 }
 
 func (h *HooksContext) prePing(c context.Context, conn *Conn) (interface{}, error) {
@@ -627,6 +900,160 @@ func (h *HooksContext) postIsValid(ctx interface{}, conn *Conn, valid bool) erro
 	return h.PostIsValid(ctx, conn, valid)
 }
 
+func (h *HooksContext) preStmtClose(c context.Context, stmt *Stmt) (interface{}, error) {
+	if h == nil || h.PreStmtClose == nil {
+		return nil, nil
+	}
+	return h.PreStmtClose(c, stmt)
+}
+
+func (h *HooksContext) stmtClose(c context.Context, ctx interface{}, stmt *Stmt) error {
+	if h == nil || h.StmtClose == nil {
+		return nil
+	}
+	return h.StmtClose(c, ctx, stmt)
+}
+
+func (h *HooksContext) postStmtClose(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+	if h == nil || h.PostStmtClose == nil {
+		return nil
+	}
+	return h.PostStmtClose(c, ctx, stmt, err)
+}
+
+func (h *HooksContext) preRowsClose(c context.Context, rows *Rows) (interface{}, error) {
+	if h == nil || h.PreRowsClose == nil {
+		return nil, nil
+	}
+	return h.PreRowsClose(c, rows)
+}
+
+func (h *HooksContext) rowsClose(c context.Context, ctx interface{}, rows *Rows) error {
+	if h == nil || h.RowsClose == nil {
+		return nil
+	}
+	return h.RowsClose(c, ctx, rows)
+}
+
+func (h *HooksContext) postRowsClose(c context.Context, ctx interface{}, rows *Rows, err error) error {
+	if h == nil || h.PostRowsClose == nil {
+		return nil
+	}
+	return h.PostRowsClose(c, ctx, rows, err)
+}
+
+func (h *HooksContext) postRows(c context.Context, rows *Rows, count int64, d time.Duration) error {
+	if h == nil || h.PostRows == nil {
+		return nil
+	}
+	return h.PostRows(c, rows, count, d)
+}
+
+func (h *HooksContext) postLastInsertId(c context.Context, result *Result, id int64, err error) error {
+	if h == nil || h.PostLastInsertId == nil {
+		return nil
+	}
+	return h.PostLastInsertId(c, result, id, err)
+}
+
+func (h *HooksContext) postRowsAffected(c context.Context, result *Result, n int64, err error) error {
+	if h == nil || h.PostRowsAffected == nil {
+		return nil
+	}
+	return h.PostRowsAffected(c, result, n, err)
+}
+
+func (h *HooksContext) preConnect(c context.Context, connector *Connector) (interface{}, error) {
+	if h == nil || h.PreConnect == nil {
+		return nil, nil
+	}
+	return h.PreConnect(c, connector)
+}
+
+func (h *HooksContext) connect(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error {
+	if h == nil || h.Connect == nil {
+		return nil
+	}
+	return h.Connect(c, ctx, connector, conn)
+}
+
+func (h *HooksContext) postConnect(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error {
+	if h == nil || h.PostConnect == nil {
+		return nil
+	}
+	return h.PostConnect(c, ctx, connector, conn, err)
+}
+
+func (h *HooksContext) preConnectorClose(c context.Context, connector *Connector) (interface{}, error) {
+	if h == nil || h.PreConnectorClose == nil {
+		return nil, nil
+	}
+	return h.PreConnectorClose(c, connector)
+}
+
+func (h *HooksContext) postConnectorClose(c context.Context, ctx interface{}, connector *Connector, err error) error {
+	if h == nil || h.PostConnectorClose == nil {
+		return nil
+	}
+	return h.PostConnectorClose(c, ctx, connector, err)
+}
+
+func (h *HooksContext) onCanceled(c context.Context, op Operation, d time.Duration, err error) error {
+	if h == nil || h.OnCanceled == nil {
+		return nil
+	}
+	return h.OnCanceled(c, op, d, err)
+}
+
+func (h *HooksContext) columns(c context.Context, rows *Rows) error {
+	if h == nil || h.Columns == nil {
+		return nil
+	}
+	return h.Columns(c, rows, rows.columnInfo())
+}
+
+func (h *HooksContext) preSavepoint(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error) {
+	if h == nil || h.PreSavepoint == nil {
+		return nil, nil
+	}
+	return h.PreSavepoint(c, stmt, sp)
+}
+
+func (h *HooksContext) savepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error {
+	if h == nil || h.Savepoint == nil {
+		return nil
+	}
+	return h.Savepoint(c, ctx, stmt, sp)
+}
+
+func (h *HooksContext) postSavepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error {
+	if h == nil || h.PostSavepoint == nil {
+		return nil
+	}
+	return h.PostSavepoint(c, ctx, stmt, sp, err)
+}
+
+func (h *HooksContext) columnConverter(stmt *Stmt, idx int, conv driver.ValueConverter) driver.ValueConverter {
+	if h == nil || h.ColumnConverter == nil {
+		return conv
+	}
+	return h.ColumnConverter(stmt, idx, conv)
+}
+
+func (h *HooksContext) oncePerConn(c context.Context, conn *Conn) error {
+	if h == nil || h.OncePerConn == nil {
+		return nil
+	}
+	return h.OncePerConn(c, conn)
+}
+
+func (h *HooksContext) rewriteDSN(c context.Context, name string) (string, error) {
+	if h == nil || h.RewriteDSN == nil {
+		return name, nil
+	}
+	return h.RewriteDSN(c, name)
+}
+
 // Hooks is callback functions for the proxy.
 // Deprecated: You should use HooksContext instead.
 type Hooks struct {
@@ -918,18 +1345,39 @@ type Hooks struct {
 	PostResetSession func(ctx interface{}, conn *Conn, err error) error
 }
 
+func valuesToNamedValues(args []driver.Value) []driver.NamedValue {
+	ret := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		ret[i] = driver.NamedValue{
+			Ordinal: i + 1,
+			Value:   arg,
+		}
+	}
+	return ret
+}
+
 func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
 	var err error
 	ret := make([]driver.Value, len(args))
 	for _, arg := range args {
 		if len(arg.Name) > 0 {
-			err = errors.New("proxy: driver does not support the use of Named Parameters")
+			err = ErrNamedParametersNotSupported
 		}
 		ret[arg.Ordinal-1] = arg.Value
 	}
 	return ret, err
 }
 
+func (h *Hooks) describe() []HookSetInfo {
+	if h == nil {
+		return nil
+	}
+	return []HookSetInfo{{
+		Kind:  "Hooks",
+		Value: h,
+	}}
+}
+
 func (h *Hooks) prePing(c context.Context, conn *Conn) (interface{}, error) {
 	if h == nil || h.PrePing == nil {
 		return nil, nil
@@ -1150,17 +1598,130 @@ func (h *Hooks) postIsValid(ctx interface{}, conn *Conn, valid bool) error {
 	return nil
 }
 
+func (h *Hooks) preStmtClose(c context.Context, stmt *Stmt) (interface{}, error) {
+	return nil, nil
+}
+
+func (h *Hooks) stmtClose(c context.Context, ctx interface{}, stmt *Stmt) error {
+	return nil
+}
+
+func (h *Hooks) postStmtClose(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+	return nil
+}
+
+func (h *Hooks) preRowsClose(c context.Context, rows *Rows) (interface{}, error) {
+	return nil, nil
+}
+
+func (h *Hooks) rowsClose(c context.Context, ctx interface{}, rows *Rows) error {
+	return nil
+}
+
+func (h *Hooks) postRowsClose(c context.Context, ctx interface{}, rows *Rows, err error) error {
+	return nil
+}
+
+func (h *Hooks) postRows(c context.Context, rows *Rows, count int64, d time.Duration) error {
+	return nil
+}
+
+func (h *Hooks) postLastInsertId(c context.Context, result *Result, id int64, err error) error {
+	return nil
+}
+
+func (h *Hooks) postRowsAffected(c context.Context, result *Result, n int64, err error) error {
+	return nil
+}
+
+func (h *Hooks) preConnect(c context.Context, connector *Connector) (interface{}, error) {
+	return nil, nil
+}
+
+func (h *Hooks) connect(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error {
+	return nil
+}
+
+func (h *Hooks) postConnect(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error {
+	return nil
+}
+
+func (h *Hooks) preConnectorClose(c context.Context, connector *Connector) (interface{}, error) {
+	return nil, nil
+}
+
+func (h *Hooks) postConnectorClose(c context.Context, ctx interface{}, connector *Connector, err error) error {
+	return nil
+}
+
+func (h *Hooks) onCanceled(c context.Context, op Operation, d time.Duration, err error) error {
+	return nil
+}
+
+func (h *Hooks) columns(c context.Context, rows *Rows) error {
+	return nil
+}
+
+func (h *Hooks) preSavepoint(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error) {
+	return nil, nil
+}
+
+func (h *Hooks) savepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error {
+	return nil
+}
+
+func (h *Hooks) postSavepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error {
+	return nil
+}
+
+func (h *Hooks) columnConverter(stmt *Stmt, idx int, conv driver.ValueConverter) driver.ValueConverter {
+	return conv
+}
+
+func (h *Hooks) oncePerConn(c context.Context, conn *Conn) error {
+	return nil
+}
+
+func (h *Hooks) rewriteDSN(c context.Context, name string) (string, error) {
+	return name, nil
+}
+
 type multipleHooks []hooks
 
+func (h multipleHooks) describe() []HookSetInfo {
+	var infos []HookSetInfo
+	for _, hk := range h {
+		infos = append(infos, describeHooks(hk)...)
+	}
+	return infos
+}
+
+// multipleHooksContext holds the values returned by the Pre hooks of multipleHooks.
+// The values are indexed by the position of the hook set in multipleHooks.
+type multipleHooksContext struct {
+	values []interface{}
+}
+
+// get returns the value for the i-th hook set.
+// It returns nil if there is no value, so that the hooks never see the value of other hook sets.
+func (c *multipleHooksContext) get(i int) interface{} {
+	if c == nil || i >= len(c.values) {
+		return nil
+	}
+	return c.values[i]
+}
+
 func (h multipleHooks) preDo(f func(h hooks) (interface{}, error)) (interface{}, error) {
 	if len(h) == 0 {
 		return nil, nil
 	}
-	ctx := make([]interface{}, len(h))
+	ctx := &multipleHooksContext{
+		values: make([]interface{}, len(h)),
+	}
 	var err error
 	for i, hk := range h {
 		ctx0, err0 := f(hk)
-		ctx[i] = ctx0
+		ctx.values[i] = ctx0
 		if err0 != nil && err == nil {
 			err = err0
 		}
@@ -1169,15 +1730,9 @@ func (h multipleHooks) preDo(f func(h hooks) (interface{}, error)) (interface{},
 }
 
 func (h multipleHooks) do(ctx interface{}, f func(h hooks, ctx interface{}) error) error {
-	if len(h) == 0 {
-		return nil
-	}
-	sctx, ok := ctx.([]interface{})
-	if !ok {
-		return errors.New("invalid context type")
-	}
+	mctx, _ := ctx.(*multipleHooksContext)
 	for i, hk := range h {
-		if err := f(hk, sctx[i]); err != nil {
+		if err := f(hk, mctx.get(i)); err != nil {
 			return err
 		}
 	}
@@ -1185,16 +1740,10 @@ func (h multipleHooks) do(ctx interface{}, f func(h hooks, ctx interface{}) erro
 }
 
 func (h multipleHooks) postDo(ctx interface{}, err error, f func(h hooks, ctx interface{}, err error) error) error {
-	if len(h) == 0 {
-		return nil
-	}
-	sctx, ok := ctx.([]interface{})
-	if !ok {
-		return errors.New("invalid context type")
-	}
+	mctx, _ := ctx.(*multipleHooksContext)
 	var reterr error
 	for i := len(h) - 1; i >= 0; i-- {
-		if err0 := f(h[i], sctx[i], err); err0 != nil {
+		if err0 := f(h[i], mctx.get(i), err); err0 != nil {
 			if err == nil {
 				err = err0
 			}
@@ -1398,18 +1947,159 @@ func (h multipleHooks) isValid(ctx interface{}, conn *Conn) error {
 	})
 }
 
-var errInvlidConnection = errors.New("invalid connection")
-
 func (h multipleHooks) postIsValid(ctx interface{}, conn *Conn, valid bool) error {
 	var err error
 	if !valid {
-		err = errInvlidConnection
+		err = ErrInvalidConnection
 	}
 	return h.postDo(ctx, err, func(h hooks, ctx interface{}, err error) error {
 		return h.postIsValid(ctx, conn, err == nil)
 	})
 }
 
+func (h multipleHooks) preStmtClose(c context.Context, stmt *Stmt) (interface{}, error) {
+	return h.preDo(func(h hooks) (interface{}, error) {
+		return h.preStmtClose(c, stmt)
+	})
+}
+
+func (h multipleHooks) stmtClose(c context.Context, ctx interface{}, stmt *Stmt) error {
+	return h.do(ctx, func(h hooks, ctx interface{}) error {
+		return h.stmtClose(c, ctx, stmt)
+	})
+}
+
+func (h multipleHooks) postStmtClose(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+	return h.postDo(ctx, err, func(h hooks, ctx interface{}, err error) error {
+		return h.postStmtClose(c, ctx, stmt, err)
+	})
+}
+
+func (h multipleHooks) preRowsClose(c context.Context, rows *Rows) (interface{}, error) {
+	return h.preDo(func(h hooks) (interface{}, error) {
+		return h.preRowsClose(c, rows)
+	})
+}
+
+func (h multipleHooks) rowsClose(c context.Context, ctx interface{}, rows *Rows) error {
+	return h.do(ctx, func(h hooks, ctx interface{}) error {
+		return h.rowsClose(c, ctx, rows)
+	})
+}
+
+func (h multipleHooks) postRowsClose(c context.Context, ctx interface{}, rows *Rows, err error) error {
+	return h.postDo(ctx, err, func(h hooks, ctx interface{}, err error) error {
+		return h.postRowsClose(c, ctx, rows, err)
+	})
+}
+
+func (h multipleHooks) postRows(c context.Context, rows *Rows, count int64, d time.Duration) error {
+	return h.postDo(nil, nil, func(h hooks, _ interface{}, _ error) error {
+		return h.postRows(c, rows, count, d)
+	})
+}
+
+func (h multipleHooks) postLastInsertId(c context.Context, result *Result, id int64, err error) error {
+	return h.postDo(nil, nil, func(h hooks, _ interface{}, _ error) error {
+		return h.postLastInsertId(c, result, id, err)
+	})
+}
+
+func (h multipleHooks) postRowsAffected(c context.Context, result *Result, n int64, err error) error {
+	return h.postDo(nil, nil, func(h hooks, _ interface{}, _ error) error {
+		return h.postRowsAffected(c, result, n, err)
+	})
+}
+
+func (h multipleHooks) preConnect(c context.Context, connector *Connector) (interface{}, error) {
+	return h.preDo(func(h hooks) (interface{}, error) {
+		return h.preConnect(c, connector)
+	})
+}
+
+func (h multipleHooks) connect(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error {
+	return h.do(ctx, func(h hooks, ctx interface{}) error {
+		return h.connect(c, ctx, connector, conn)
+	})
+}
+
+func (h multipleHooks) postConnect(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error {
+	return h.postDo(ctx, err, func(h hooks, ctx interface{}, err error) error {
+		return h.postConnect(c, ctx, connector, conn, err)
+	})
+}
+
+func (h multipleHooks) preConnectorClose(c context.Context, connector *Connector) (interface{}, error) {
+	return h.preDo(func(h hooks) (interface{}, error) {
+		return h.preConnectorClose(c, connector)
+	})
+}
+
+func (h multipleHooks) postConnectorClose(c context.Context, ctx interface{}, connector *Connector, err error) error {
+	return h.postDo(ctx, err, func(h hooks, ctx interface{}, err error) error {
+		return h.postConnectorClose(c, ctx, connector, err)
+	})
+}
+
+func (h multipleHooks) onCanceled(c context.Context, op Operation, d time.Duration, err error) error {
+	return h.postDo(nil, nil, func(h hooks, _ interface{}, _ error) error {
+		return h.onCanceled(c, op, d, err)
+	})
+}
+
+func (h multipleHooks) columns(c context.Context, rows *Rows) error {
+	for _, hk := range h {
+		if err := hk.columns(c, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h multipleHooks) preSavepoint(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error) {
+	return h.preDo(func(h hooks) (interface{}, error) {
+		return h.preSavepoint(c, stmt, sp)
+	})
+}
+
+func (h multipleHooks) savepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error {
+	return h.do(ctx, func(h hooks, ctx interface{}) error {
+		return h.savepoint(c, ctx, stmt, sp)
+	})
+}
+
+func (h multipleHooks) postSavepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error {
+	return h.postDo(ctx, err, func(h hooks, ctx interface{}, err error) error {
+		return h.postSavepoint(c, ctx, stmt, sp, err)
+	})
+}
+
+func (h multipleHooks) columnConverter(stmt *Stmt, idx int, conv driver.ValueConverter) driver.ValueConverter {
+	for _, hk := range h {
+		conv = hk.columnConverter(stmt, idx, conv)
+	}
+	return conv
+}
+
+func (h multipleHooks) oncePerConn(c context.Context, conn *Conn) error {
+	for _, hk := range h {
+		if err := hk.oncePerConn(c, conn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h multipleHooks) rewriteDSN(c context.Context, name string) (string, error) {
+	for _, hk := range h {
+		var err error
+		if name, err = hk.rewriteDSN(c, name); err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
 type contextHooksKey struct{}
 
 func contextHooks(ctx context.Context) hooks {
@@ -1448,5 +2138,39 @@ func WithHooks(ctx context.Context, hs ...*HooksContext) context.Context {
 	for _, hk := range hs {
 		hooksSlice = append(hooksSlice, hk)
 	}
+	sortHooks(hooksSlice)
 	return context.WithValue(ctx, contextHooksKey{}, multipleHooks(hooksSlice))
 }
+
+type contextAppendedHooksKey struct{}
+
+// AppendHooks returns a copy of parent context in which the hooks associated.
+// Unlike WithHooks, the hooks don't replace the hooks of the proxy:
+// the operations executed with the context call the hooks of the proxy (or the hooks associated by WithHooks) first,
+// and then call hs, unless the priorities of the hook sets say otherwise.
+func AppendHooks(ctx context.Context, hs ...*HooksContext) context.Context {
+	var hooksSlice []hooks
+	current, _ := ctx.Value(contextAppendedHooksKey{}).(multipleHooks)
+	hooksSlice = make([]hooks, 0, len(hs)+len(current))
+	hooksSlice = append(hooksSlice, current...)
+	for _, hk := range hs {
+		if hk != nil {
+			hooksSlice = append(hooksSlice, hk)
+		}
+	}
+	if len(hooksSlice) == len(current) {
+		return ctx
+	}
+	return context.WithValue(ctx, contextAppendedHooksKey{}, multipleHooks(hooksSlice))
+}
+
+type contextSkipHooksKey struct{}
+
+// SkipHooks returns a copy of parent context in which all the hooks are disabled.
+// The operations executed with the context call neither the hooks of the proxy
+// nor the hooks associated by WithHooks and AppendHooks.
+// It is useful for health checks and migrations that should not appear in traces and metrics of the hooks.
+// Note that Proxy.Stats still counts the operations.
+func SkipHooks(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextSkipHooksKey{}, true)
+}
@@ -0,0 +1,465 @@
+//go:build go1.18
+// +build go1.18
+
+package proxy
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// HooksContextOf is a variant of HooksContext, in which the value passed between the hooks is typed.
+// The value returned by a Pre hook is passed to the following hooks of the same operation as is,
+// so the hooks don't need type assertions.
+// If the Pre hook is nil, the following hooks receive the zero value of T.
+//
+// Convert it into HooksContext by the HooksContext method to install it.
+// The other hooks, e.g. Columns and OncePerConn, are available on the converted HooksContext.
+type HooksContextOf[T any] struct {
+	// Name is the name of the hook set.
+	Name string
+
+	// Priority is the priority of the hook set.
+	Priority int
+
+	// PrePing is the typed version of HooksContext.PrePing.
+	PrePing func(c context.Context, conn *Conn) (T, error)
+
+	// Ping is the typed version of HooksContext.Ping.
+	Ping func(c context.Context, ctx T, conn *Conn) error
+
+	// PostPing is the typed version of HooksContext.PostPing.
+	PostPing func(c context.Context, ctx T, conn *Conn, err error) error
+
+	// PreOpen is the typed version of HooksContext.PreOpen.
+	PreOpen func(c context.Context, name string) (T, error)
+
+	// Open is the typed version of HooksContext.Open.
+	Open func(c context.Context, ctx T, conn *Conn) error
+
+	// PostOpen is the typed version of HooksContext.PostOpen.
+	PostOpen func(c context.Context, ctx T, conn *Conn, err error) error
+
+	// PrePrepare is the typed version of HooksContext.PrePrepare.
+	PrePrepare func(c context.Context, stmt *Stmt) (T, error)
+
+	// Prepare is the typed version of HooksContext.Prepare.
+	Prepare func(c context.Context, ctx T, stmt *Stmt) error
+
+	// PostPrepare is the typed version of HooksContext.PostPrepare.
+	PostPrepare func(c context.Context, ctx T, stmt *Stmt, err error) error
+
+	// PreExec is the typed version of HooksContext.PreExec.
+	PreExec func(c context.Context, stmt *Stmt, args []driver.NamedValue) (T, error)
+
+	// Exec is the typed version of HooksContext.Exec.
+	Exec func(c context.Context, ctx T, stmt *Stmt, args []driver.NamedValue, result driver.Result) error
+
+	// PostExec is the typed version of HooksContext.PostExec.
+	PostExec func(c context.Context, ctx T, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error
+
+	// PreQuery is the typed version of HooksContext.PreQuery.
+	PreQuery func(c context.Context, stmt *Stmt, args []driver.NamedValue) (T, error)
+
+	// Query is the typed version of HooksContext.Query.
+	Query func(c context.Context, ctx T, stmt *Stmt, args []driver.NamedValue, rows driver.Rows) error
+
+	// PostQuery is the typed version of HooksContext.PostQuery.
+	PostQuery func(c context.Context, ctx T, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error
+
+	// PreBegin is the typed version of HooksContext.PreBegin.
+	PreBegin func(c context.Context, conn *Conn) (T, error)
+
+	// Begin is the typed version of HooksContext.Begin.
+	Begin func(c context.Context, ctx T, conn *Conn) error
+
+	// PostBegin is the typed version of HooksContext.PostBegin.
+	PostBegin func(c context.Context, ctx T, conn *Conn, err error) error
+
+	// PreCommit is the typed version of HooksContext.PreCommit.
+	PreCommit func(c context.Context, tx *Tx) (T, error)
+
+	// Commit is the typed version of HooksContext.Commit.
+	Commit func(c context.Context, ctx T, tx *Tx) error
+
+	// PostCommit is the typed version of HooksContext.PostCommit.
+	PostCommit func(c context.Context, ctx T, tx *Tx, err error) error
+
+	// PreRollback is the typed version of HooksContext.PreRollback.
+	PreRollback func(c context.Context, tx *Tx) (T, error)
+
+	// Rollback is the typed version of HooksContext.Rollback.
+	Rollback func(c context.Context, ctx T, tx *Tx) error
+
+	// PostRollback is the typed version of HooksContext.PostRollback.
+	PostRollback func(c context.Context, ctx T, tx *Tx, err error) error
+
+	// PreClose is the typed version of HooksContext.PreClose.
+	PreClose func(c context.Context, conn *Conn) (T, error)
+
+	// Close is the typed version of HooksContext.Close.
+	Close func(c context.Context, ctx T, conn *Conn) error
+
+	// PostClose is the typed version of HooksContext.PostClose.
+	PostClose func(c context.Context, ctx T, conn *Conn, err error) error
+
+	// PreResetSession is the typed version of HooksContext.PreResetSession.
+	PreResetSession func(c context.Context, conn *Conn) (T, error)
+
+	// ResetSession is the typed version of HooksContext.ResetSession.
+	ResetSession func(c context.Context, ctx T, conn *Conn) error
+
+	// PostResetSession is the typed version of HooksContext.PostResetSession.
+	PostResetSession func(c context.Context, ctx T, conn *Conn, err error) error
+
+	// PreIsValid is the typed version of HooksContext.PreIsValid.
+	PreIsValid func(conn *Conn) (T, error)
+
+	// IsValid is the typed version of HooksContext.IsValid.
+	IsValid func(ctx T, conn *Conn) error
+
+	// PostIsValid is the typed version of HooksContext.PostIsValid.
+	PostIsValid func(ctx T, conn *Conn, valid bool) error
+
+	// PreStmtClose is the typed version of HooksContext.PreStmtClose.
+	PreStmtClose func(c context.Context, stmt *Stmt) (T, error)
+
+	// StmtClose is the typed version of HooksContext.StmtClose.
+	StmtClose func(c context.Context, ctx T, stmt *Stmt) error
+
+	// PostStmtClose is the typed version of HooksContext.PostStmtClose.
+	PostStmtClose func(c context.Context, ctx T, stmt *Stmt, err error) error
+
+	// PreRowsClose is the typed version of HooksContext.PreRowsClose.
+	PreRowsClose func(c context.Context, rows *Rows) (T, error)
+
+	// RowsClose is the typed version of HooksContext.RowsClose.
+	RowsClose func(c context.Context, ctx T, rows *Rows) error
+
+	// PostRowsClose is the typed version of HooksContext.PostRowsClose.
+	PostRowsClose func(c context.Context, ctx T, rows *Rows, err error) error
+
+	// PreConnect is the typed version of HooksContext.PreConnect.
+	PreConnect func(c context.Context, connector *Connector) (T, error)
+
+	// Connect is the typed version of HooksContext.Connect.
+	Connect func(c context.Context, ctx T, connector *Connector, conn *Conn) error
+
+	// PostConnect is the typed version of HooksContext.PostConnect.
+	PostConnect func(c context.Context, ctx T, connector *Connector, conn *Conn, err error) error
+
+	// PreConnectorClose is the typed version of HooksContext.PreConnectorClose.
+	PreConnectorClose func(c context.Context, connector *Connector) (T, error)
+
+	// PostConnectorClose is the typed version of HooksContext.PostConnectorClose.
+	PostConnectorClose func(c context.Context, ctx T, connector *Connector, err error) error
+
+	// PreSavepoint is the typed version of HooksContext.PreSavepoint.
+	PreSavepoint func(c context.Context, stmt *Stmt, sp *Savepoint) (T, error)
+
+	// Savepoint is the typed version of HooksContext.Savepoint.
+	Savepoint func(c context.Context, ctx T, stmt *Stmt, sp *Savepoint) error
+
+	// PostSavepoint is the typed version of HooksContext.PostSavepoint.
+	PostSavepoint func(c context.Context, ctx T, stmt *Stmt, sp *Savepoint, err error) error
+}
+
+// HooksContext converts h into HooksContext.
+func (h *HooksContextOf[T]) HooksContext() *HooksContext {
+	if h == nil {
+		return nil
+	}
+	hk := &HooksContext{
+		Name:     h.Name,
+		Priority: h.Priority,
+	}
+	if h.PrePing != nil {
+		f := h.PrePing
+		hk.PrePing = func(c context.Context, conn *Conn) (interface{}, error) {
+			return f(c, conn)
+		}
+	}
+	if h.Ping != nil {
+		f := h.Ping
+		hk.Ping = func(c context.Context, ctx interface{}, conn *Conn) error {
+			return f(c, typedValue[T](ctx), conn)
+		}
+	}
+	if h.PostPing != nil {
+		f := h.PostPing
+		hk.PostPing = func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			return f(c, typedValue[T](ctx), conn, err)
+		}
+	}
+	if h.PreOpen != nil {
+		f := h.PreOpen
+		hk.PreOpen = func(c context.Context, name string) (interface{}, error) {
+			return f(c, name)
+		}
+	}
+	if h.Open != nil {
+		f := h.Open
+		hk.Open = func(c context.Context, ctx interface{}, conn *Conn) error {
+			return f(c, typedValue[T](ctx), conn)
+		}
+	}
+	if h.PostOpen != nil {
+		f := h.PostOpen
+		hk.PostOpen = func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			return f(c, typedValue[T](ctx), conn, err)
+		}
+	}
+	if h.PrePrepare != nil {
+		f := h.PrePrepare
+		hk.PrePrepare = func(c context.Context, stmt *Stmt) (interface{}, error) {
+			return f(c, stmt)
+		}
+	}
+	if h.Prepare != nil {
+		f := h.Prepare
+		hk.Prepare = func(c context.Context, ctx interface{}, stmt *Stmt) error {
+			return f(c, typedValue[T](ctx), stmt)
+		}
+	}
+	if h.PostPrepare != nil {
+		f := h.PostPrepare
+		hk.PostPrepare = func(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+			return f(c, typedValue[T](ctx), stmt, err)
+		}
+	}
+	if h.PreExec != nil {
+		f := h.PreExec
+		hk.PreExec = func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+			return f(c, stmt, args)
+		}
+	}
+	if h.Exec != nil {
+		f := h.Exec
+		hk.Exec = func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result) error {
+			return f(c, typedValue[T](ctx), stmt, args, result)
+		}
+	}
+	if h.PostExec != nil {
+		f := h.PostExec
+		hk.PostExec = func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			return f(c, typedValue[T](ctx), stmt, args, result, err)
+		}
+	}
+	if h.PreQuery != nil {
+		f := h.PreQuery
+		hk.PreQuery = func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+			return f(c, stmt, args)
+		}
+	}
+	if h.Query != nil {
+		f := h.Query
+		hk.Query = func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows) error {
+			return f(c, typedValue[T](ctx), stmt, args, rows)
+		}
+	}
+	if h.PostQuery != nil {
+		f := h.PostQuery
+		hk.PostQuery = func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+			return f(c, typedValue[T](ctx), stmt, args, rows, err)
+		}
+	}
+	if h.PreBegin != nil {
+		f := h.PreBegin
+		hk.PreBegin = func(c context.Context, conn *Conn) (interface{}, error) {
+			return f(c, conn)
+		}
+	}
+	if h.Begin != nil {
+		f := h.Begin
+		hk.Begin = func(c context.Context, ctx interface{}, conn *Conn) error {
+			return f(c, typedValue[T](ctx), conn)
+		}
+	}
+	if h.PostBegin != nil {
+		f := h.PostBegin
+		hk.PostBegin = func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			return f(c, typedValue[T](ctx), conn, err)
+		}
+	}
+	if h.PreCommit != nil {
+		f := h.PreCommit
+		hk.PreCommit = func(c context.Context, tx *Tx) (interface{}, error) {
+			return f(c, tx)
+		}
+	}
+	if h.Commit != nil {
+		f := h.Commit
+		hk.Commit = func(c context.Context, ctx interface{}, tx *Tx) error {
+			return f(c, typedValue[T](ctx), tx)
+		}
+	}
+	if h.PostCommit != nil {
+		f := h.PostCommit
+		hk.PostCommit = func(c context.Context, ctx interface{}, tx *Tx, err error) error {
+			return f(c, typedValue[T](ctx), tx, err)
+		}
+	}
+	if h.PreRollback != nil {
+		f := h.PreRollback
+		hk.PreRollback = func(c context.Context, tx *Tx) (interface{}, error) {
+			return f(c, tx)
+		}
+	}
+	if h.Rollback != nil {
+		f := h.Rollback
+		hk.Rollback = func(c context.Context, ctx interface{}, tx *Tx) error {
+			return f(c, typedValue[T](ctx), tx)
+		}
+	}
+	if h.PostRollback != nil {
+		f := h.PostRollback
+		hk.PostRollback = func(c context.Context, ctx interface{}, tx *Tx, err error) error {
+			return f(c, typedValue[T](ctx), tx, err)
+		}
+	}
+	if h.PreClose != nil {
+		f := h.PreClose
+		hk.PreClose = func(c context.Context, conn *Conn) (interface{}, error) {
+			return f(c, conn)
+		}
+	}
+	if h.Close != nil {
+		f := h.Close
+		hk.Close = func(c context.Context, ctx interface{}, conn *Conn) error {
+			return f(c, typedValue[T](ctx), conn)
+		}
+	}
+	if h.PostClose != nil {
+		f := h.PostClose
+		hk.PostClose = func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			return f(c, typedValue[T](ctx), conn, err)
+		}
+	}
+	if h.PreResetSession != nil {
+		f := h.PreResetSession
+		hk.PreResetSession = func(c context.Context, conn *Conn) (interface{}, error) {
+			return f(c, conn)
+		}
+	}
+	if h.ResetSession != nil {
+		f := h.ResetSession
+		hk.ResetSession = func(c context.Context, ctx interface{}, conn *Conn) error {
+			return f(c, typedValue[T](ctx), conn)
+		}
+	}
+	if h.PostResetSession != nil {
+		f := h.PostResetSession
+		hk.PostResetSession = func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			return f(c, typedValue[T](ctx), conn, err)
+		}
+	}
+	if h.PreIsValid != nil {
+		f := h.PreIsValid
+		hk.PreIsValid = func(conn *Conn) (interface{}, error) {
+			return f(conn)
+		}
+	}
+	if h.IsValid != nil {
+		f := h.IsValid
+		hk.IsValid = func(ctx interface{}, conn *Conn) error {
+			return f(typedValue[T](ctx), conn)
+		}
+	}
+	if h.PostIsValid != nil {
+		f := h.PostIsValid
+		hk.PostIsValid = func(ctx interface{}, conn *Conn, valid bool) error {
+			return f(typedValue[T](ctx), conn, valid)
+		}
+	}
+	if h.PreStmtClose != nil {
+		f := h.PreStmtClose
+		hk.PreStmtClose = func(c context.Context, stmt *Stmt) (interface{}, error) {
+			return f(c, stmt)
+		}
+	}
+	if h.StmtClose != nil {
+		f := h.StmtClose
+		hk.StmtClose = func(c context.Context, ctx interface{}, stmt *Stmt) error {
+			return f(c, typedValue[T](ctx), stmt)
+		}
+	}
+	if h.PostStmtClose != nil {
+		f := h.PostStmtClose
+		hk.PostStmtClose = func(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+			return f(c, typedValue[T](ctx), stmt, err)
+		}
+	}
+	if h.PreRowsClose != nil {
+		f := h.PreRowsClose
+		hk.PreRowsClose = func(c context.Context, rows *Rows) (interface{}, error) {
+			return f(c, rows)
+		}
+	}
+	if h.RowsClose != nil {
+		f := h.RowsClose
+		hk.RowsClose = func(c context.Context, ctx interface{}, rows *Rows) error {
+			return f(c, typedValue[T](ctx), rows)
+		}
+	}
+	if h.PostRowsClose != nil {
+		f := h.PostRowsClose
+		hk.PostRowsClose = func(c context.Context, ctx interface{}, rows *Rows, err error) error {
+			return f(c, typedValue[T](ctx), rows, err)
+		}
+	}
+	if h.PreConnect != nil {
+		f := h.PreConnect
+		hk.PreConnect = func(c context.Context, connector *Connector) (interface{}, error) {
+			return f(c, connector)
+		}
+	}
+	if h.Connect != nil {
+		f := h.Connect
+		hk.Connect = func(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error {
+			return f(c, typedValue[T](ctx), connector, conn)
+		}
+	}
+	if h.PostConnect != nil {
+		f := h.PostConnect
+		hk.PostConnect = func(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error {
+			return f(c, typedValue[T](ctx), connector, conn, err)
+		}
+	}
+	if h.PreConnectorClose != nil {
+		f := h.PreConnectorClose
+		hk.PreConnectorClose = func(c context.Context, connector *Connector) (interface{}, error) {
+			return f(c, connector)
+		}
+	}
+	if h.PostConnectorClose != nil {
+		f := h.PostConnectorClose
+		hk.PostConnectorClose = func(c context.Context, ctx interface{}, connector *Connector, err error) error {
+			return f(c, typedValue[T](ctx), connector, err)
+		}
+	}
+	if h.PreSavepoint != nil {
+		f := h.PreSavepoint
+		hk.PreSavepoint = func(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error) {
+			return f(c, stmt, sp)
+		}
+	}
+	if h.Savepoint != nil {
+		f := h.Savepoint
+		hk.Savepoint = func(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error {
+			return f(c, typedValue[T](ctx), stmt, sp)
+		}
+	}
+	if h.PostSavepoint != nil {
+		f := h.PostSavepoint
+		hk.PostSavepoint = func(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error {
+			return f(c, typedValue[T](ctx), stmt, sp, err)
+		}
+	}
+	return hk
+}
+
+// typedValue returns ctx as T, or the zero value of T if ctx is not a T.
+func typedValue[T any](ctx interface{}) T {
+	v, _ := ctx.(T)
+	return v
+}
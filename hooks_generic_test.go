@@ -0,0 +1,73 @@
+//go:build go1.18
+// +build go1.18
+
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestHooksContextOf(t *testing.T) {
+	type span struct {
+		query string
+	}
+	var got []string
+	hooks := &HooksContextOf[*span]{
+		Name: "typed",
+		PreExec: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (*span, error) {
+			return &span{query: stmt.QueryString}, nil
+		},
+		Exec: func(c context.Context, ctx *span, stmt *Stmt, args []driver.NamedValue, result driver.Result) error {
+			got = append(got, "Exec "+ctx.query)
+			return nil
+		},
+		PostExec: func(c context.Context, ctx *span, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			got = append(got, "PostExec "+ctx.query)
+			return nil
+		},
+		PostQuery: func(c context.Context, ctx *span, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+			// PreQuery is nil, so ctx is the zero value.
+			if ctx != nil {
+				t.Errorf("want nil, got %v", ctx)
+			}
+			got = append(got, "PostQuery")
+			return nil
+		},
+	}
+	hk := hooks.HooksContext()
+	if hk.Name != "typed" {
+		t.Errorf("want typed, got %s", hk.Name)
+	}
+	if hk.PrePing != nil {
+		t.Error("want nil PrePing")
+	}
+
+	sql.Register("fakedb-hooks-context-of", NewProxyContext(fdriver, hk))
+	db, err := sql.Open("fakedb-hooks-context-of", `{"Name":"hooks-context-of","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("SELECT id FROM t1 WHERE id = ?", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	want := []string{
+		"Exec INSERT INTO t1 (id) VALUES(?)",
+		"PostExec INSERT INTO t1 (id) VALUES(?)",
+		"PostQuery",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"database/sql/driver"
 	"errors"
@@ -360,10 +361,29 @@ func TestMultipleHooks(t *testing.T) {
 	hooks1, ctx1 := newTestHooksContext(t)
 	hooks2, ctx2 := newTestHooks(t)
 	hooks := multipleHooks{hooks1, hooks2}
-	ctx0 := []interface{}{ctx1, ctx2}
+	ctx0 := &multipleHooksContext{values: []interface{}{ctx1, ctx2}}
 	testHooksInterface(t, hooks, ctx0)
 }
 
+func TestMultipleHooks_unknownContext(t *testing.T) {
+	var got []interface{}
+	hk := &HooksContext{
+		Ping: func(c context.Context, ctx interface{}, conn *Conn) error {
+			got = append(got, ctx)
+			return nil
+		},
+	}
+	hooks := multipleHooks{hk, hk}
+
+	// the values that are not returned by preDo are never passed to the hooks.
+	if err := hooks.ping(context.Background(), "unknown", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []interface{}{nil, nil}) {
+		t.Errorf("want [nil nil], got %v", got)
+	}
+}
+
 func TestWithHooks(t *testing.T) {
 	ctx := WithHooks(context.Background(), &HooksContext{}, &HooksContext{})
 	hooks := contextHooks(ctx)
@@ -380,6 +400,69 @@ func TestWithHooks(t *testing.T) {
 	}
 }
 
+func TestAppendHooks(t *testing.T) {
+	var got []string
+	newHooks := func(name string) *HooksContext {
+		return &HooksContext{
+			PrePing: func(c context.Context, conn *Conn) (interface{}, error) {
+				got = append(got, name)
+				return nil, nil
+			},
+		}
+	}
+	p := NewProxyContext(fdriver, newHooks("proxy"))
+	conn := newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+
+	ctx := AppendHooks(context.Background(), newHooks("appended1"), nil)
+	ctx = AppendHooks(ctx, newHooks("appended2"))
+	if err := conn.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"proxy", "appended1", "appended2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// the hooks associated by WithHooks replace the proxy's, and the appended hooks are still called.
+	got = nil
+	if err := conn.Ping(WithHooks(ctx, newHooks("replaced"))); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"replaced", "appended1", "appended2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// AppendHooks without hooks returns the context as is.
+	if c := context.Background(); AppendHooks(c) != c {
+		t.Error("want the same context")
+	}
+}
+
+func TestSkipHooks(t *testing.T) {
+	var count int
+	hooks := &HooksContext{
+		PrePing: func(c context.Context, conn *Conn) (interface{}, error) {
+			count++
+			return nil, nil
+		},
+	}
+	p := NewProxyContext(fdriver, hooks)
+	conn := newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+
+	ctx := SkipHooks(AppendHooks(WithHooks(context.Background(), hooks), hooks))
+	if err := conn.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("want no hooks called, got %d", count)
+	}
+	if err := conn.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("want 1, got %d", count)
+	}
+}
+
 func TestWithHooks_error(t *testing.T) {
 	var count int
 	var errPrePing = errors.New("pre-ping error")
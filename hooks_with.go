@@ -0,0 +1,418 @@
+package proxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// Clone returns a shallow copy of h.
+// The hooks of the copy can be overridden without modifying h,
+// so a base hook set can be shared and customized by the With methods.
+// If h is nil, it returns an empty HooksContext.
+func (h *HooksContext) Clone() *HooksContext {
+	if h == nil {
+		return &HooksContext{}
+	}
+	nh := *h
+	return &nh
+}
+
+// WithName returns a copy of h whose name is name.
+func (h *HooksContext) WithName(name string) *HooksContext {
+	nh := h.Clone()
+	nh.Name = name
+	return nh
+}
+
+// WithPriority returns a copy of h whose priority is priority.
+func (h *HooksContext) WithPriority(priority int) *HooksContext {
+	nh := h.Clone()
+	nh.Priority = priority
+	return nh
+}
+
+// WithPrePing returns a copy of h whose PrePing hook is fn.
+func (h *HooksContext) WithPrePing(fn func(c context.Context, conn *Conn) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PrePing = fn
+	return nh
+}
+
+// WithPing returns a copy of h whose Ping hook is fn.
+func (h *HooksContext) WithPing(fn func(c context.Context, ctx interface{}, conn *Conn) error) *HooksContext {
+	nh := h.Clone()
+	nh.Ping = fn
+	return nh
+}
+
+// WithPostPing returns a copy of h whose PostPing hook is fn.
+func (h *HooksContext) WithPostPing(fn func(c context.Context, ctx interface{}, conn *Conn, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostPing = fn
+	return nh
+}
+
+// WithPreOpen returns a copy of h whose PreOpen hook is fn.
+func (h *HooksContext) WithPreOpen(fn func(c context.Context, name string) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreOpen = fn
+	return nh
+}
+
+// WithOpen returns a copy of h whose Open hook is fn.
+func (h *HooksContext) WithOpen(fn func(c context.Context, ctx interface{}, conn *Conn) error) *HooksContext {
+	nh := h.Clone()
+	nh.Open = fn
+	return nh
+}
+
+// WithPostOpen returns a copy of h whose PostOpen hook is fn.
+func (h *HooksContext) WithPostOpen(fn func(c context.Context, ctx interface{}, conn *Conn, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostOpen = fn
+	return nh
+}
+
+// WithPrePrepare returns a copy of h whose PrePrepare hook is fn.
+func (h *HooksContext) WithPrePrepare(fn func(c context.Context, stmt *Stmt) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PrePrepare = fn
+	return nh
+}
+
+// WithPrepare returns a copy of h whose Prepare hook is fn.
+func (h *HooksContext) WithPrepare(fn func(c context.Context, ctx interface{}, stmt *Stmt) error) *HooksContext {
+	nh := h.Clone()
+	nh.Prepare = fn
+	return nh
+}
+
+// WithPostPrepare returns a copy of h whose PostPrepare hook is fn.
+func (h *HooksContext) WithPostPrepare(fn func(c context.Context, ctx interface{}, stmt *Stmt, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostPrepare = fn
+	return nh
+}
+
+// WithPreExec returns a copy of h whose PreExec hook is fn.
+func (h *HooksContext) WithPreExec(fn func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreExec = fn
+	return nh
+}
+
+// WithExec returns a copy of h whose Exec hook is fn.
+func (h *HooksContext) WithExec(fn func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result) error) *HooksContext {
+	nh := h.Clone()
+	nh.Exec = fn
+	return nh
+}
+
+// WithPostExec returns a copy of h whose PostExec hook is fn.
+func (h *HooksContext) WithPostExec(fn func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostExec = fn
+	return nh
+}
+
+// WithPreQuery returns a copy of h whose PreQuery hook is fn.
+func (h *HooksContext) WithPreQuery(fn func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreQuery = fn
+	return nh
+}
+
+// WithQuery returns a copy of h whose Query hook is fn.
+func (h *HooksContext) WithQuery(fn func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows) error) *HooksContext {
+	nh := h.Clone()
+	nh.Query = fn
+	return nh
+}
+
+// WithPostQuery returns a copy of h whose PostQuery hook is fn.
+func (h *HooksContext) WithPostQuery(fn func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostQuery = fn
+	return nh
+}
+
+// WithPreBegin returns a copy of h whose PreBegin hook is fn.
+func (h *HooksContext) WithPreBegin(fn func(c context.Context, conn *Conn) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreBegin = fn
+	return nh
+}
+
+// WithBegin returns a copy of h whose Begin hook is fn.
+func (h *HooksContext) WithBegin(fn func(c context.Context, ctx interface{}, conn *Conn) error) *HooksContext {
+	nh := h.Clone()
+	nh.Begin = fn
+	return nh
+}
+
+// WithPostBegin returns a copy of h whose PostBegin hook is fn.
+func (h *HooksContext) WithPostBegin(fn func(c context.Context, ctx interface{}, conn *Conn, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostBegin = fn
+	return nh
+}
+
+// WithPreCommit returns a copy of h whose PreCommit hook is fn.
+func (h *HooksContext) WithPreCommit(fn func(c context.Context, tx *Tx) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreCommit = fn
+	return nh
+}
+
+// WithCommit returns a copy of h whose Commit hook is fn.
+func (h *HooksContext) WithCommit(fn func(c context.Context, ctx interface{}, tx *Tx) error) *HooksContext {
+	nh := h.Clone()
+	nh.Commit = fn
+	return nh
+}
+
+// WithPostCommit returns a copy of h whose PostCommit hook is fn.
+func (h *HooksContext) WithPostCommit(fn func(c context.Context, ctx interface{}, tx *Tx, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostCommit = fn
+	return nh
+}
+
+// WithPreRollback returns a copy of h whose PreRollback hook is fn.
+func (h *HooksContext) WithPreRollback(fn func(c context.Context, tx *Tx) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreRollback = fn
+	return nh
+}
+
+// WithRollback returns a copy of h whose Rollback hook is fn.
+func (h *HooksContext) WithRollback(fn func(c context.Context, ctx interface{}, tx *Tx) error) *HooksContext {
+	nh := h.Clone()
+	nh.Rollback = fn
+	return nh
+}
+
+// WithPostRollback returns a copy of h whose PostRollback hook is fn.
+func (h *HooksContext) WithPostRollback(fn func(c context.Context, ctx interface{}, tx *Tx, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostRollback = fn
+	return nh
+}
+
+// WithPreClose returns a copy of h whose PreClose hook is fn.
+func (h *HooksContext) WithPreClose(fn func(c context.Context, conn *Conn) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreClose = fn
+	return nh
+}
+
+// WithClose returns a copy of h whose Close hook is fn.
+func (h *HooksContext) WithClose(fn func(c context.Context, ctx interface{}, conn *Conn) error) *HooksContext {
+	nh := h.Clone()
+	nh.Close = fn
+	return nh
+}
+
+// WithPostClose returns a copy of h whose PostClose hook is fn.
+func (h *HooksContext) WithPostClose(fn func(c context.Context, ctx interface{}, conn *Conn, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostClose = fn
+	return nh
+}
+
+// WithPreResetSession returns a copy of h whose PreResetSession hook is fn.
+func (h *HooksContext) WithPreResetSession(fn func(c context.Context, conn *Conn) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreResetSession = fn
+	return nh
+}
+
+// WithResetSession returns a copy of h whose ResetSession hook is fn.
+func (h *HooksContext) WithResetSession(fn func(c context.Context, ctx interface{}, conn *Conn) error) *HooksContext {
+	nh := h.Clone()
+	nh.ResetSession = fn
+	return nh
+}
+
+// WithPostResetSession returns a copy of h whose PostResetSession hook is fn.
+func (h *HooksContext) WithPostResetSession(fn func(c context.Context, ctx interface{}, conn *Conn, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostResetSession = fn
+	return nh
+}
+
+// WithPreIsValid returns a copy of h whose PreIsValid hook is fn.
+func (h *HooksContext) WithPreIsValid(fn func(conn *Conn) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreIsValid = fn
+	return nh
+}
+
+// WithIsValid returns a copy of h whose IsValid hook is fn.
+func (h *HooksContext) WithIsValid(fn func(ctx interface{}, conn *Conn) error) *HooksContext {
+	nh := h.Clone()
+	nh.IsValid = fn
+	return nh
+}
+
+// WithPostIsValid returns a copy of h whose PostIsValid hook is fn.
+func (h *HooksContext) WithPostIsValid(fn func(ctx interface{}, conn *Conn, valid bool) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostIsValid = fn
+	return nh
+}
+
+// WithPreStmtClose returns a copy of h whose PreStmtClose hook is fn.
+func (h *HooksContext) WithPreStmtClose(fn func(c context.Context, stmt *Stmt) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreStmtClose = fn
+	return nh
+}
+
+// WithStmtClose returns a copy of h whose StmtClose hook is fn.
+func (h *HooksContext) WithStmtClose(fn func(c context.Context, ctx interface{}, stmt *Stmt) error) *HooksContext {
+	nh := h.Clone()
+	nh.StmtClose = fn
+	return nh
+}
+
+// WithPostStmtClose returns a copy of h whose PostStmtClose hook is fn.
+func (h *HooksContext) WithPostStmtClose(fn func(c context.Context, ctx interface{}, stmt *Stmt, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostStmtClose = fn
+	return nh
+}
+
+// WithPreRowsClose returns a copy of h whose PreRowsClose hook is fn.
+func (h *HooksContext) WithPreRowsClose(fn func(c context.Context, rows *Rows) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreRowsClose = fn
+	return nh
+}
+
+// WithRowsClose returns a copy of h whose RowsClose hook is fn.
+func (h *HooksContext) WithRowsClose(fn func(c context.Context, ctx interface{}, rows *Rows) error) *HooksContext {
+	nh := h.Clone()
+	nh.RowsClose = fn
+	return nh
+}
+
+// WithPostRowsClose returns a copy of h whose PostRowsClose hook is fn.
+func (h *HooksContext) WithPostRowsClose(fn func(c context.Context, ctx interface{}, rows *Rows, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostRowsClose = fn
+	return nh
+}
+
+// WithPostRows returns a copy of h whose PostRows hook is fn.
+func (h *HooksContext) WithPostRows(fn func(c context.Context, rows *Rows, count int64, d time.Duration) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostRows = fn
+	return nh
+}
+
+// WithPostLastInsertId returns a copy of h whose PostLastInsertId hook is fn.
+func (h *HooksContext) WithPostLastInsertId(fn func(c context.Context, result *Result, id int64, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostLastInsertId = fn
+	return nh
+}
+
+// WithPostRowsAffected returns a copy of h whose PostRowsAffected hook is fn.
+func (h *HooksContext) WithPostRowsAffected(fn func(c context.Context, result *Result, n int64, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostRowsAffected = fn
+	return nh
+}
+
+// WithPreConnect returns a copy of h whose PreConnect hook is fn.
+func (h *HooksContext) WithPreConnect(fn func(c context.Context, connector *Connector) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreConnect = fn
+	return nh
+}
+
+// WithConnect returns a copy of h whose Connect hook is fn.
+func (h *HooksContext) WithConnect(fn func(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error) *HooksContext {
+	nh := h.Clone()
+	nh.Connect = fn
+	return nh
+}
+
+// WithPostConnect returns a copy of h whose PostConnect hook is fn.
+func (h *HooksContext) WithPostConnect(fn func(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostConnect = fn
+	return nh
+}
+
+// WithPreConnectorClose returns a copy of h whose PreConnectorClose hook is fn.
+func (h *HooksContext) WithPreConnectorClose(fn func(c context.Context, connector *Connector) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreConnectorClose = fn
+	return nh
+}
+
+// WithPostConnectorClose returns a copy of h whose PostConnectorClose hook is fn.
+func (h *HooksContext) WithPostConnectorClose(fn func(c context.Context, ctx interface{}, connector *Connector, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostConnectorClose = fn
+	return nh
+}
+
+// WithOnCanceled returns a copy of h whose OnCanceled hook is fn.
+func (h *HooksContext) WithOnCanceled(fn func(c context.Context, op Operation, d time.Duration, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.OnCanceled = fn
+	return nh
+}
+
+// WithColumns returns a copy of h whose Columns hook is fn.
+func (h *HooksContext) WithColumns(fn func(c context.Context, rows *Rows, columns []Column) error) *HooksContext {
+	nh := h.Clone()
+	nh.Columns = fn
+	return nh
+}
+
+// WithPreSavepoint returns a copy of h whose PreSavepoint hook is fn.
+func (h *HooksContext) WithPreSavepoint(fn func(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error)) *HooksContext {
+	nh := h.Clone()
+	nh.PreSavepoint = fn
+	return nh
+}
+
+// WithSavepoint returns a copy of h whose Savepoint hook is fn.
+func (h *HooksContext) WithSavepoint(fn func(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error) *HooksContext {
+	nh := h.Clone()
+	nh.Savepoint = fn
+	return nh
+}
+
+// WithPostSavepoint returns a copy of h whose PostSavepoint hook is fn.
+func (h *HooksContext) WithPostSavepoint(fn func(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error) *HooksContext {
+	nh := h.Clone()
+	nh.PostSavepoint = fn
+	return nh
+}
+
+// WithColumnConverter returns a copy of h whose ColumnConverter hook is fn.
+func (h *HooksContext) WithColumnConverter(fn func(stmt *Stmt, idx int, conv driver.ValueConverter) driver.ValueConverter) *HooksContext {
+	nh := h.Clone()
+	nh.ColumnConverter = fn
+	return nh
+}
+
+// WithOncePerConn returns a copy of h whose OncePerConn hook is fn.
+func (h *HooksContext) WithOncePerConn(fn func(c context.Context, conn *Conn) error) *HooksContext {
+	nh := h.Clone()
+	nh.OncePerConn = fn
+	return nh
+}
+
+// WithRewriteDSN returns a copy of h whose RewriteDSN hook is fn.
+func (h *HooksContext) WithRewriteDSN(fn func(c context.Context, name string) (string, error)) *HooksContext {
+	nh := h.Clone()
+	nh.RewriteDSN = fn
+	return nh
+}
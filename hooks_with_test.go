@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestHooksContextWith(t *testing.T) {
+	var log []string
+	base := &HooksContext{
+		Name: "base",
+		PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			log = append(log, "base PostExec")
+			return nil
+		},
+		PostQuery: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+			log = append(log, "base PostQuery")
+			return nil
+		},
+	}
+	custom := base.WithName("custom").WithPostQuery(func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+		log = append(log, "custom PostQuery")
+		return nil
+	})
+	if custom == base {
+		t.Fatal("want a copy, got the base hook set")
+	}
+	if base.Name != "base" {
+		t.Errorf("the base hook set is modified: Name = %q", base.Name)
+	}
+	if custom.Name != "custom" {
+		t.Errorf("want %q, got %q", "custom", custom.Name)
+	}
+
+	c := context.Background()
+	base.postQuery(c, nil, nil, nil, nil, nil)
+	custom.postQuery(c, nil, nil, nil, nil, nil)
+	custom.postExec(c, nil, nil, nil, nil, nil)
+	want := []string{"base PostQuery", "custom PostQuery", "base PostExec"}
+	if len(log) != len(want) {
+		t.Fatalf("want %v, got %v", want, log)
+	}
+	for i := range want {
+		if log[i] != want[i] {
+			t.Errorf("want %v, got %v", want, log)
+			break
+		}
+	}
+
+	if (*HooksContext)(nil).Clone() == nil {
+		t.Error("want an empty hook set, got nil")
+	}
+}
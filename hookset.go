@@ -0,0 +1,417 @@
+package proxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// HookSetV1 is the version 1 of the interface for implementing hooks as a type.
+// The methods are the same as the fields of HooksContext.
+//
+// HookSetV1 is frozen. New hook points are added to a new version of the interface,
+// so implementations outside of this package keep compiling.
+// Embed NoopHookSetV1 into your type to implement only the methods you need.
+//
+// Use FromHookSetV1 to pass a HookSetV1 to NewProxyContext, NewConnector and WithHooks.
+type HookSetV1 interface {
+	PrePing(c context.Context, conn *Conn) (interface{}, error)
+	Ping(c context.Context, ctx interface{}, conn *Conn) error
+	PostPing(c context.Context, ctx interface{}, conn *Conn, err error) error
+	PreOpen(c context.Context, name string) (interface{}, error)
+	Open(c context.Context, ctx interface{}, conn *Conn) error
+	PostOpen(c context.Context, ctx interface{}, conn *Conn, err error) error
+	PrePrepare(c context.Context, stmt *Stmt) (interface{}, error)
+	Prepare(c context.Context, ctx interface{}, stmt *Stmt) error
+	PostPrepare(c context.Context, ctx interface{}, stmt *Stmt, err error) error
+	PreExec(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error)
+	Exec(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result) error
+	PostExec(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error
+	PreQuery(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error)
+	Query(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows) error
+	PostQuery(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error
+	PreBegin(c context.Context, conn *Conn) (interface{}, error)
+	Begin(c context.Context, ctx interface{}, conn *Conn) error
+	PostBegin(c context.Context, ctx interface{}, conn *Conn, err error) error
+	PreCommit(c context.Context, tx *Tx) (interface{}, error)
+	Commit(c context.Context, ctx interface{}, tx *Tx) error
+	PostCommit(c context.Context, ctx interface{}, tx *Tx, err error) error
+	PreRollback(c context.Context, tx *Tx) (interface{}, error)
+	Rollback(c context.Context, ctx interface{}, tx *Tx) error
+	PostRollback(c context.Context, ctx interface{}, tx *Tx, err error) error
+	PreClose(c context.Context, conn *Conn) (interface{}, error)
+	Close(c context.Context, ctx interface{}, conn *Conn) error
+	PostClose(c context.Context, ctx interface{}, conn *Conn, err error) error
+	PreResetSession(c context.Context, conn *Conn) (interface{}, error)
+	ResetSession(c context.Context, ctx interface{}, conn *Conn) error
+	PostResetSession(c context.Context, ctx interface{}, conn *Conn, err error) error
+	PreIsValid(conn *Conn) (interface{}, error)
+	IsValid(ctx interface{}, conn *Conn) error
+	PostIsValid(ctx interface{}, conn *Conn, valid bool) error
+}
+
+// HookSet is the latest version of the interface for implementing hooks as a type.
+// It is currently HookSetV1.
+//
+// HookSet may refer a newer version with more methods in the future.
+// Embed NoopHookSet into your type to keep it implementing HookSet,
+// or refer the versioned interface, e.g. HookSetV1, if you implement all the methods by yourself.
+type HookSet = HookSetV1
+
+// NoopHookSet is an implementation of HookSet that does nothing.
+// Embed it into your type for forward compatibility.
+type NoopHookSet = NoopHookSetV1
+
+// NewProxyHookSet creates new Proxy driver from the hook sets implemented as types.
+// It is the same as NewProxyContext with the hook sets converted by FromHookSetV1.
+func NewProxyHookSet(driver driver.Driver, hs ...HookSet) *Proxy {
+	hooksSlice := make([]*HooksContext, 0, len(hs))
+	for _, h := range hs {
+		hooksSlice = append(hooksSlice, FromHookSetV1(h))
+	}
+	return NewProxyContext(driver, hooksSlice...)
+}
+
+// NoopHookSetV1 is an implementation of HookSetV1 that does nothing.
+// Embed it into your type for forward compatibility.
+type NoopHookSetV1 struct{}
+
+var _ HookSetV1 = NoopHookSetV1{}
+var _ HookSet = NoopHookSet{}
+
+// PrePing implements HookSetV1.
+func (NoopHookSetV1) PrePing(c context.Context, conn *Conn) (interface{}, error) {
+	return nil, nil
+}
+
+// Ping implements HookSetV1.
+func (NoopHookSetV1) Ping(c context.Context, ctx interface{}, conn *Conn) error {
+	return nil
+}
+
+// PostPing implements HookSetV1.
+func (NoopHookSetV1) PostPing(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return nil
+}
+
+// PreOpen implements HookSetV1.
+func (NoopHookSetV1) PreOpen(c context.Context, name string) (interface{}, error) {
+	return nil, nil
+}
+
+// Open implements HookSetV1.
+func (NoopHookSetV1) Open(c context.Context, ctx interface{}, conn *Conn) error {
+	return nil
+}
+
+// PostOpen implements HookSetV1.
+func (NoopHookSetV1) PostOpen(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return nil
+}
+
+// PrePrepare implements HookSetV1.
+func (NoopHookSetV1) PrePrepare(c context.Context, stmt *Stmt) (interface{}, error) {
+	return nil, nil
+}
+
+// Prepare implements HookSetV1.
+func (NoopHookSetV1) Prepare(c context.Context, ctx interface{}, stmt *Stmt) error {
+	return nil
+}
+
+// PostPrepare implements HookSetV1.
+func (NoopHookSetV1) PostPrepare(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+	return nil
+}
+
+// PreExec implements HookSetV1.
+func (NoopHookSetV1) PreExec(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+	return nil, nil
+}
+
+// Exec implements HookSetV1.
+func (NoopHookSetV1) Exec(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result) error {
+	return nil
+}
+
+// PostExec implements HookSetV1.
+func (NoopHookSetV1) PostExec(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+	return nil
+}
+
+// PreQuery implements HookSetV1.
+func (NoopHookSetV1) PreQuery(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+	return nil, nil
+}
+
+// Query implements HookSetV1.
+func (NoopHookSetV1) Query(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows) error {
+	return nil
+}
+
+// PostQuery implements HookSetV1.
+func (NoopHookSetV1) PostQuery(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+	return nil
+}
+
+// PreBegin implements HookSetV1.
+func (NoopHookSetV1) PreBegin(c context.Context, conn *Conn) (interface{}, error) {
+	return nil, nil
+}
+
+// Begin implements HookSetV1.
+func (NoopHookSetV1) Begin(c context.Context, ctx interface{}, conn *Conn) error {
+	return nil
+}
+
+// PostBegin implements HookSetV1.
+func (NoopHookSetV1) PostBegin(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return nil
+}
+
+// PreCommit implements HookSetV1.
+func (NoopHookSetV1) PreCommit(c context.Context, tx *Tx) (interface{}, error) {
+	return nil, nil
+}
+
+// Commit implements HookSetV1.
+func (NoopHookSetV1) Commit(c context.Context, ctx interface{}, tx *Tx) error {
+	return nil
+}
+
+// PostCommit implements HookSetV1.
+func (NoopHookSetV1) PostCommit(c context.Context, ctx interface{}, tx *Tx, err error) error {
+	return nil
+}
+
+// PreRollback implements HookSetV1.
+func (NoopHookSetV1) PreRollback(c context.Context, tx *Tx) (interface{}, error) {
+	return nil, nil
+}
+
+// Rollback implements HookSetV1.
+func (NoopHookSetV1) Rollback(c context.Context, ctx interface{}, tx *Tx) error {
+	return nil
+}
+
+// PostRollback implements HookSetV1.
+func (NoopHookSetV1) PostRollback(c context.Context, ctx interface{}, tx *Tx, err error) error {
+	return nil
+}
+
+// PreClose implements HookSetV1.
+func (NoopHookSetV1) PreClose(c context.Context, conn *Conn) (interface{}, error) {
+	return nil, nil
+}
+
+// Close implements HookSetV1.
+func (NoopHookSetV1) Close(c context.Context, ctx interface{}, conn *Conn) error {
+	return nil
+}
+
+// PostClose implements HookSetV1.
+func (NoopHookSetV1) PostClose(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return nil
+}
+
+// PreResetSession implements HookSetV1.
+func (NoopHookSetV1) PreResetSession(c context.Context, conn *Conn) (interface{}, error) {
+	return nil, nil
+}
+
+// ResetSession implements HookSetV1.
+func (NoopHookSetV1) ResetSession(c context.Context, ctx interface{}, conn *Conn) error {
+	return nil
+}
+
+// PostResetSession implements HookSetV1.
+func (NoopHookSetV1) PostResetSession(c context.Context, ctx interface{}, conn *Conn, err error) error {
+	return nil
+}
+
+// PreIsValid implements HookSetV1.
+func (NoopHookSetV1) PreIsValid(conn *Conn) (interface{}, error) {
+	return nil, nil
+}
+
+// IsValid implements HookSetV1.
+func (NoopHookSetV1) IsValid(ctx interface{}, conn *Conn) error {
+	return nil
+}
+
+// PostIsValid implements HookSetV1.
+func (NoopHookSetV1) PostIsValid(ctx interface{}, conn *Conn, valid bool) error {
+	return nil
+}
+
+// StmtCloseHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the methods as the Stmt.Close hooks if h implements it.
+type StmtCloseHookSet interface {
+	PreStmtClose(c context.Context, stmt *Stmt) (interface{}, error)
+	StmtClose(c context.Context, ctx interface{}, stmt *Stmt) error
+	PostStmtClose(c context.Context, ctx interface{}, stmt *Stmt, err error) error
+}
+
+// RowsCloseHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the methods as the Rows.Close hooks if h implements it.
+type RowsCloseHookSet interface {
+	PreRowsClose(c context.Context, rows *Rows) (interface{}, error)
+	RowsClose(c context.Context, ctx interface{}, rows *Rows) error
+	PostRowsClose(c context.Context, ctx interface{}, rows *Rows, err error) error
+}
+
+// PostRowsHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the method as the PostRows hook if h implements it.
+type PostRowsHookSet interface {
+	PostRows(c context.Context, rows *Rows, count int64, d time.Duration) error
+}
+
+// ResultHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the methods as the Result hooks if h implements it.
+type ResultHookSet interface {
+	PostLastInsertId(c context.Context, result *Result, id int64, err error) error
+	PostRowsAffected(c context.Context, result *Result, n int64, err error) error
+}
+
+// ConnectHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the methods as the Connect hooks if h implements it.
+type ConnectHookSet interface {
+	PreConnect(c context.Context, connector *Connector) (interface{}, error)
+	Connect(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error
+	PostConnect(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error
+}
+
+// ConnectorCloseHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the methods as the Connector.Close hooks if h implements it.
+type ConnectorCloseHookSet interface {
+	PreConnectorClose(c context.Context, connector *Connector) (interface{}, error)
+	PostConnectorClose(c context.Context, ctx interface{}, connector *Connector, err error) error
+}
+
+// CanceledHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the method as the OnCanceled hook if h implements it.
+type CanceledHookSet interface {
+	OnCanceled(c context.Context, op Operation, d time.Duration, err error) error
+}
+
+// ColumnsHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the method as the Columns hook if h implements it.
+type ColumnsHookSet interface {
+	Columns(c context.Context, rows *Rows, columns []Column) error
+}
+
+// SavepointHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the methods as the Savepoint hooks if h implements it.
+type SavepointHookSet interface {
+	PreSavepoint(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error)
+	Savepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error
+	PostSavepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error
+}
+
+// ColumnConverterHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the method as the ColumnConverter hook if h implements it.
+type ColumnConverterHookSet interface {
+	ColumnConverter(stmt *Stmt, idx int, conv driver.ValueConverter) driver.ValueConverter
+}
+
+// OncePerConnHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the method as the OncePerConn hook if h implements it.
+type OncePerConnHookSet interface {
+	OncePerConn(c context.Context, conn *Conn) error
+}
+
+// RewriteDSNHookSet is an optional interface for HookSetV1 implementations.
+// FromHookSetV1 installs the method as the RewriteDSN hook if h implements it.
+type RewriteDSNHookSet interface {
+	RewriteDSN(c context.Context, name string) (string, error)
+}
+
+// FromHookSetV1 converts h into HooksContext.
+// The methods of the optional interfaces, e.g. StmtCloseHookSet, are also converted.
+func FromHookSetV1(h HookSetV1) *HooksContext {
+	if h == nil {
+		return nil
+	}
+	hk := &HooksContext{
+		PrePing:          h.PrePing,
+		Ping:             h.Ping,
+		PostPing:         h.PostPing,
+		PreOpen:          h.PreOpen,
+		Open:             h.Open,
+		PostOpen:         h.PostOpen,
+		PrePrepare:       h.PrePrepare,
+		Prepare:          h.Prepare,
+		PostPrepare:      h.PostPrepare,
+		PreExec:          h.PreExec,
+		Exec:             h.Exec,
+		PostExec:         h.PostExec,
+		PreQuery:         h.PreQuery,
+		Query:            h.Query,
+		PostQuery:        h.PostQuery,
+		PreBegin:         h.PreBegin,
+		Begin:            h.Begin,
+		PostBegin:        h.PostBegin,
+		PreCommit:        h.PreCommit,
+		Commit:           h.Commit,
+		PostCommit:       h.PostCommit,
+		PreRollback:      h.PreRollback,
+		Rollback:         h.Rollback,
+		PostRollback:     h.PostRollback,
+		PreClose:         h.PreClose,
+		Close:            h.Close,
+		PostClose:        h.PostClose,
+		PreResetSession:  h.PreResetSession,
+		ResetSession:     h.ResetSession,
+		PostResetSession: h.PostResetSession,
+		PreIsValid:       h.PreIsValid,
+		IsValid:          h.IsValid,
+		PostIsValid:      h.PostIsValid,
+	}
+	if h, ok := h.(StmtCloseHookSet); ok {
+		hk.PreStmtClose = h.PreStmtClose
+		hk.StmtClose = h.StmtClose
+		hk.PostStmtClose = h.PostStmtClose
+	}
+	if h, ok := h.(RowsCloseHookSet); ok {
+		hk.PreRowsClose = h.PreRowsClose
+		hk.RowsClose = h.RowsClose
+		hk.PostRowsClose = h.PostRowsClose
+	}
+	if h, ok := h.(PostRowsHookSet); ok {
+		hk.PostRows = h.PostRows
+	}
+	if h, ok := h.(ResultHookSet); ok {
+		hk.PostLastInsertId = h.PostLastInsertId
+		hk.PostRowsAffected = h.PostRowsAffected
+	}
+	if h, ok := h.(ConnectHookSet); ok {
+		hk.PreConnect = h.PreConnect
+		hk.Connect = h.Connect
+		hk.PostConnect = h.PostConnect
+	}
+	if h, ok := h.(ConnectorCloseHookSet); ok {
+		hk.PreConnectorClose = h.PreConnectorClose
+		hk.PostConnectorClose = h.PostConnectorClose
+	}
+	if h, ok := h.(CanceledHookSet); ok {
+		hk.OnCanceled = h.OnCanceled
+	}
+	if h, ok := h.(ColumnsHookSet); ok {
+		hk.Columns = h.Columns
+	}
+	if h, ok := h.(SavepointHookSet); ok {
+		hk.PreSavepoint = h.PreSavepoint
+		hk.Savepoint = h.Savepoint
+		hk.PostSavepoint = h.PostSavepoint
+	}
+	if h, ok := h.(ColumnConverterHookSet); ok {
+		hk.ColumnConverter = h.ColumnConverter
+	}
+	if h, ok := h.(OncePerConnHookSet); ok {
+		hk.OncePerConn = h.OncePerConn
+	}
+	if h, ok := h.(RewriteDSNHookSet); ok {
+		hk.RewriteDSN = h.RewriteDSN
+	}
+	return hk
+}
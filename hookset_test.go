@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+type pingCounter struct {
+	NoopHookSetV1
+	count int
+}
+
+var _ HookSetV1 = (*pingCounter)(nil)
+
+func (h *pingCounter) Ping(c context.Context, ctx interface{}, conn *Conn) error {
+	h.count++
+	return nil
+}
+
+func TestNoopHookSetV1(t *testing.T) {
+	// NoopHookSetV1 has no effect
+	testHooksInterface(t, FromHookSetV1(NoopHookSetV1{}), nil)
+}
+
+func TestFromHookSetV1(t *testing.T) {
+	h := &pingCounter{}
+	sql.Register("fakedb-hookset-v1", NewProxyContext(fdriver, FromHookSetV1(h)))
+	db, err := sql.Open("fakedb-hookset-v1", `{"Name":"hookset-v1","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if h.count != 1 {
+		t.Errorf("want 1, got %d", h.count)
+	}
+
+	if FromHookSetV1(nil) != nil {
+		t.Error("want nil, got non-nil")
+	}
+}
+
+type stmtCloseCounter struct {
+	NoopHookSetV1
+	count int
+}
+
+var _ StmtCloseHookSet = (*stmtCloseCounter)(nil)
+
+func (h *stmtCloseCounter) PreStmtClose(c context.Context, stmt *Stmt) (interface{}, error) {
+	return nil, nil
+}
+
+func (h *stmtCloseCounter) StmtClose(c context.Context, ctx interface{}, stmt *Stmt) error {
+	h.count++
+	return nil
+}
+
+func (h *stmtCloseCounter) PostStmtClose(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+	return nil
+}
+
+func TestNewProxyHookSet(t *testing.T) {
+	h := &pingCounter{}
+	sql.Register("fakedb-proxy-hookset", NewProxyHookSet(fdriver, h, nil))
+	db, err := sql.Open("fakedb-proxy-hookset", `{"Name":"proxy-hookset","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if h.count != 1 {
+		t.Errorf("want 1, got %d", h.count)
+	}
+}
+
+func TestFromHookSetV1_StmtCloseHookSet(t *testing.T) {
+	h := &stmtCloseCounter{}
+	hk := FromHookSetV1(h)
+	if err := hk.stmtClose(context.Background(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if h.count != 1 {
+		t.Errorf("want 1, got %d", h.count)
+	}
+}
@@ -0,0 +1,240 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// ExecFunc executes stmt with args.
+type ExecFunc func(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Result, error)
+
+// ExecInterceptor wraps the ExecFunc that calls the original driver.
+type ExecInterceptor func(next ExecFunc) ExecFunc
+
+// QueryFunc executes the query of stmt with args.
+type QueryFunc func(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Rows, error)
+
+// QueryInterceptor wraps the QueryFunc that calls the original driver.
+type QueryInterceptor func(next QueryFunc) QueryFunc
+
+// PrepareFunc prepares the query of stmt.
+type PrepareFunc func(c context.Context, stmt *Stmt) (driver.Stmt, error)
+
+// PrepareInterceptor wraps the PrepareFunc that calls the original driver.
+type PrepareInterceptor func(next PrepareFunc) PrepareFunc
+
+// BeginFunc starts a transaction on conn.
+type BeginFunc func(c context.Context, conn *Conn, opts driver.TxOptions) (driver.Tx, error)
+
+// BeginInterceptor wraps the BeginFunc that calls the original driver.
+type BeginInterceptor func(next BeginFunc) BeginFunc
+
+// Interceptors is a set of the middleware-style interceptors.
+// An interceptor wraps the call of the original driver, like the middlewares of net/http.
+// It may call next zero or more times, so retrying, measuring and short-circuiting are natural.
+//
+// The interceptors run inside the hooks: the Pre hooks are called before the interceptors,
+// and the other hooks are called after the interceptors return.
+type Interceptors struct {
+	// Exec intercepts Conn.ExecContext and Stmt.ExecContext.
+	Exec ExecInterceptor
+
+	// Query intercepts Conn.QueryContext and Stmt.QueryContext.
+	Query QueryInterceptor
+
+	// Prepare intercepts Conn.PrepareContext.
+	Prepare PrepareInterceptor
+
+	// Begin intercepts Conn.BeginTx.
+	Begin BeginInterceptor
+}
+
+// WithInterceptors returns a new Proxy that calls the original driver through is.
+// The interceptors of p are outer, and then the interceptors in is are called in the order.
+// p is not modified.
+func (p *Proxy) WithInterceptors(is ...Interceptors) *Proxy {
+	np := p.clone()
+	for _, i := range is {
+		np.interceptors = np.interceptors.chain(i)
+	}
+	return np
+}
+
+// chain returns the interceptors that call i first, and then call inner.
+func (i Interceptors) chain(inner Interceptors) Interceptors {
+	ret := i
+	if inner.Exec != nil {
+		if outer := i.Exec; outer != nil {
+			ret.Exec = func(next ExecFunc) ExecFunc { return outer(inner.Exec(next)) }
+		} else {
+			ret.Exec = inner.Exec
+		}
+	}
+	if inner.Query != nil {
+		if outer := i.Query; outer != nil {
+			ret.Query = func(next QueryFunc) QueryFunc { return outer(inner.Query(next)) }
+		} else {
+			ret.Query = inner.Query
+		}
+	}
+	if inner.Prepare != nil {
+		if outer := i.Prepare; outer != nil {
+			ret.Prepare = func(next PrepareFunc) PrepareFunc { return outer(inner.Prepare(next)) }
+		} else {
+			ret.Prepare = inner.Prepare
+		}
+	}
+	if inner.Begin != nil {
+		if outer := i.Begin; outer != nil {
+			ret.Begin = func(next BeginFunc) BeginFunc { return outer(inner.Begin(next)) }
+		} else {
+			ret.Begin = inner.Begin
+		}
+	}
+	return ret
+}
+
+func (i Interceptors) exec(f ExecFunc) ExecFunc {
+	if i.Exec == nil {
+		return f
+	}
+	return i.Exec(f)
+}
+
+func (i Interceptors) query(f QueryFunc) QueryFunc {
+	if i.Query == nil {
+		return f
+	}
+	return i.Query(f)
+}
+
+func (i Interceptors) prepare(f PrepareFunc) PrepareFunc {
+	if i.Prepare == nil {
+		return f
+	}
+	return i.Prepare(f)
+}
+
+func (i Interceptors) begin(f BeginFunc) BeginFunc {
+	if i.Begin == nil {
+		return f
+	}
+	return i.Begin(f)
+}
+
+// execConn calls the original ExecContext (or Exec as a fallback) method of the connection.
+func execConn(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Result, error) {
+	if execerCtx, ok := stmt.Conn.Conn.(driver.ExecerContext); ok {
+		return execerCtx.ExecContext(c, stmt.QueryString, args)
+	}
+	select {
+	default:
+	case <-c.Done():
+		return nil, c.Err()
+	}
+	dargs, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Conn.Conn.(driver.Execer).Exec(stmt.QueryString, dargs)
+}
+
+// queryConn calls the original QueryContext (or Query as a fallback) method of the connection.
+func queryConn(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Rows, error) {
+	if queryerCtx, ok := stmt.Conn.Conn.(driver.QueryerContext); ok {
+		return queryerCtx.QueryContext(c, stmt.QueryString, args)
+	}
+	select {
+	default:
+	case <-c.Done():
+		return nil, c.Err()
+	}
+	dargs, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Conn.Conn.(driver.Queryer).Query(stmt.QueryString, dargs)
+}
+
+// execStmt calls the original ExecContext (or Exec as a fallback) method of the statement.
+func execStmt(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Result, error) {
+	if execerContext, ok := stmt.Stmt.(driver.StmtExecContext); ok {
+		return execerContext.ExecContext(c, args)
+	}
+	select {
+	default:
+	case <-c.Done():
+		return nil, c.Err()
+	}
+	dargs, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Stmt.Exec(dargs)
+}
+
+// queryStmt calls the original QueryContext (or Query as a fallback) method of the statement.
+func queryStmt(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Rows, error) {
+	if queryCtx, ok := stmt.Stmt.(driver.StmtQueryContext); ok {
+		return queryCtx.QueryContext(c, args)
+	}
+	select {
+	default:
+	case <-c.Done():
+		return nil, c.Err()
+	}
+	dargs, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Stmt.Query(dargs)
+}
+
+// prepareConn calls the original PrepareContext (or Prepare as a fallback) method of the connection.
+func prepareConn(c context.Context, stmt *Stmt) (driver.Stmt, error) {
+	conn := stmt.Conn.Conn
+	if connCtx, ok := conn.(driver.ConnPrepareContext); ok {
+		return connCtx.PrepareContext(c, stmt.QueryString)
+	}
+	s, err := conn.Prepare(stmt.QueryString)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	default:
+	case <-c.Done():
+		s.Close()
+		return nil, c.Err()
+	}
+	return s, nil
+}
+
+// beginConn calls the original BeginTx (or Begin as a fallback) method of the connection.
+func beginConn(c context.Context, conn *Conn, opts driver.TxOptions) (driver.Tx, error) {
+	if connCtx, ok := conn.Conn.(driver.ConnBeginTx); ok {
+		return connCtx.BeginTx(c, opts)
+	}
+	if c.Done() != context.Background().Done() {
+		// the original driver does not support non-default transaction options.
+		// so return error if non-default transaction is requested.
+		if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+			return nil, ErrNonDefaultIsolationLevel
+		}
+		if opts.ReadOnly {
+			return nil, ErrReadOnlyTransaction
+		}
+	}
+	tx, err := conn.Conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	// check the context is already done.
+	select {
+	default:
+	case <-c.Done():
+		tx.Rollback()
+		return nil, c.Err()
+	}
+	return tx, nil
+}
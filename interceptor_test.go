@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithInterceptors(t *testing.T) {
+	var got []string
+	errRetry := errors.New("retry")
+	p := NewProxyContext(fdriver, &HooksContext{
+		PreExec: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+			got = append(got, "PreExec")
+			return nil, nil
+		},
+		PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			got = append(got, "PostExec")
+			return nil
+		},
+	}).WithInterceptors(Interceptors{
+		// retry once.
+		Exec: func(next ExecFunc) ExecFunc {
+			return func(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Result, error) {
+				got = append(got, "outer")
+				result, err := next(c, stmt, args)
+				if err == errRetry {
+					result, err = next(c, stmt, args)
+				}
+				return result, err
+			}
+		},
+	}, Interceptors{
+		// fail the first call.
+		Exec: func(next ExecFunc) ExecFunc {
+			failed := false
+			return func(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Result, error) {
+				got = append(got, "inner")
+				if !failed {
+					failed = true
+					return nil, errRetry
+				}
+				return next(c, stmt, args)
+			}
+		},
+		// short-circuit.
+		Query: func(next QueryFunc) QueryFunc {
+			return func(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Rows, error) {
+				return nil, errors.New("short-circuit")
+			}
+		},
+	})
+	sql.Register("fakedb-interceptors", p)
+	db, err := sql.Open("fakedb-interceptors", `{"Name":"interceptors","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PreExec", "outer", "inner", "inner", "PostExec"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	if _, err := db.Query("SELECT id FROM t1 WHERE id = ?", 1); err == nil || err.Error() != "short-circuit" {
+		t.Errorf("want short-circuit, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect is the SQL dialect used to interpolate the arguments into queries.
+type Dialect int
+
+const (
+	// DialectStandard quotes values as standard SQL literals.
+	// It recognizes the placeholders "?", "$1", ":name" and "@name".
+	DialectStandard Dialect = iota
+
+	// DialectMySQL quotes values for MySQL, escaping backslashes in strings.
+	// It recognizes the placeholder "?".
+	DialectMySQL
+
+	// DialectPostgreSQL quotes values for PostgreSQL, formatting byte slices as bytea literals.
+	// It recognizes the placeholder "$1".
+	DialectPostgreSQL
+)
+
+// Interpolate returns query with its placeholders replaced by args quoted in dialect.
+// It is intended for debugging, and the result must not be executed,
+// because it may not be equivalent to the query with the arguments.
+// ok is false if the placeholders don't match args.
+func Interpolate(query string, args []driver.NamedValue, dialect Dialect) (interpolated string, ok bool) {
+	var b strings.Builder
+	b.Grow(len(query))
+	next := 0 // the index of the next positional argument
+	used := 0
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := skipQuoted(query, i, c, c == '\'' && dialect == DialectMySQL)
+			b.WriteString(query[i:j])
+			i = j
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				j = len(query) - i
+			}
+			b.WriteString(query[i : i+j])
+			i += j
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				j = len(query) - i
+			} else {
+				j += 4
+			}
+			b.WriteString(query[i : i+j])
+			i += j
+		case c == '?' && dialect != DialectPostgreSQL:
+			if next >= len(args) {
+				return "", false
+			}
+			writeLiteral(&b, args[next].Value, dialect)
+			next++
+			used++
+			i++
+		case c == '$' && dialect != DialectMySQL && i+1 < len(query) && isDigit(query[i+1]):
+			j := i + 1
+			for j < len(query) && isDigit(query[j]) {
+				j++
+			}
+			n, _ := strconv.Atoi(query[i+1 : j])
+			arg, found := argByOrdinal(args, n)
+			if !found {
+				return "", false
+			}
+			writeLiteral(&b, arg.Value, dialect)
+			used++
+			i = j
+		case (c == ':' || c == '@') && dialect == DialectStandard && i+1 < len(query) && isIdentChar(query[i+1]) && (i == 0 || query[i-1] != c):
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			arg, found := argByName(args, query[i+1:j])
+			if !found {
+				// it may not be a placeholder, e.g. a time literal.
+				b.WriteString(query[i:j])
+				i = j
+				continue
+			}
+			writeLiteral(&b, arg.Value, dialect)
+			used++
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	if used < len(args) {
+		return "", false
+	}
+	return b.String(), true
+}
+
+func argByOrdinal(args []driver.NamedValue, ordinal int) (driver.NamedValue, bool) {
+	for _, arg := range args {
+		if arg.Ordinal == ordinal {
+			return arg, true
+		}
+	}
+	return driver.NamedValue{}, false
+}
+
+func argByName(args []driver.NamedValue, name string) (driver.NamedValue, bool) {
+	for _, arg := range args {
+		if arg.Name != "" && arg.Name == name {
+			return arg, true
+		}
+	}
+	return driver.NamedValue{}, false
+}
+
+// writeLiteral writes v as an SQL literal in dialect.
+func writeLiteral(w io.Writer, v driver.Value, dialect Dialect) {
+	switch dialect {
+	case DialectMySQL:
+		switch v := v.(type) {
+		case string:
+			io.WriteString(w, "'")
+			io.WriteString(w, mysqlEscaper.Replace(v))
+			io.WriteString(w, "'")
+			return
+		case time.Time:
+			io.WriteString(w, "'")
+			io.WriteString(w, v.Format("2006-01-02 15:04:05.999999"))
+			io.WriteString(w, "'")
+			return
+		}
+	case DialectPostgreSQL:
+		switch v := v.(type) {
+		case []byte:
+			io.WriteString(w, `'\x`)
+			io.WriteString(w, hex.EncodeToString(v))
+			io.WriteString(w, "'::bytea")
+			return
+		case time.Time:
+			io.WriteString(w, "'")
+			io.WriteString(w, v.Format("2006-01-02 15:04:05.999999999Z07:00"))
+			io.WriteString(w, "'")
+			return
+		}
+	}
+	formatSQLLiteral(w, v)
+}
+
+var mysqlEscaper = strings.NewReplacer(
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+	`\`, `\\`,
+	`'`, `\'`,
+)
@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	tests := []struct {
+		query   string
+		args    []driver.NamedValue
+		dialect Dialect
+		want    string
+		ok      bool
+	}{
+		{
+			query: "SELECT * FROM t1 WHERE id = ? AND name = ? AND note = '?'",
+			args:  []driver.NamedValue{{Ordinal: 1, Value: int64(1)}, {Ordinal: 2, Value: "it's"}},
+			want:  "SELECT * FROM t1 WHERE id = 1 AND name = 'it''s' AND note = '?'",
+			ok:    true,
+		},
+		{
+			query:   "SELECT * FROM t1 WHERE id = ? AND name = ?",
+			args:    []driver.NamedValue{{Ordinal: 1, Value: int64(1)}, {Ordinal: 2, Value: `it's \ok`}},
+			dialect: DialectMySQL,
+			want:    `SELECT * FROM t1 WHERE id = 1 AND name = 'it\'s \\ok'`,
+			ok:      true,
+		},
+		{
+			query:   "SELECT $2::text, $1 -- ?",
+			args:    []driver.NamedValue{{Ordinal: 1, Value: []byte{0xde, 0xad}}, {Ordinal: 2, Value: nil}},
+			dialect: DialectPostgreSQL,
+			want:    `SELECT NULL::text, '\xdead'::bytea -- ?`,
+			ok:      true,
+		},
+		{
+			query: "SELECT * FROM t1 WHERE id = :id AND time > '12:00'",
+			args:  []driver.NamedValue{{Name: "id", Ordinal: 1, Value: true}},
+			want:  "SELECT * FROM t1 WHERE id = TRUE AND time > '12:00'",
+			ok:    true,
+		},
+		{
+			query: "SELECT * FROM t1 WHERE id = ? AND name = ?",
+			args:  []driver.NamedValue{{Ordinal: 1, Value: int64(1)}},
+			ok:    false,
+		},
+		{
+			query: "SELECT * FROM t1",
+			args:  []driver.NamedValue{{Ordinal: 1, Value: int64(1)}},
+			ok:    false,
+		},
+	}
+	for _, tt := range tests {
+		got, ok := Interpolate(tt.query, tt.args, tt.dialect)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Interpolate(%q): want (%q, %t), got (%q, %t)", tt.query, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestTracerOptions_InterpolateParams(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-interpolate", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter:         log.New(buf, "", 0),
+		InterpolateParams: true,
+	})))
+	db, err := sql.Open("fakedb-trace-interpolate", `{"name":"trace-interpolate"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id, name) VALUES(?, ?)", 1, "it's"); err != nil {
+		t.Fatal(err)
+	}
+	want := ": INSERT INTO t1 (id, name) VALUES(1, 'it''s'); conn_id = "
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want %q in the log, got:\n%s", want, buf.String())
+	}
+}
@@ -0,0 +1,67 @@
+package proxy
+
+import "sync"
+
+// defaultMaxLabelValues is the default limit of the distinct values per label key of LabelLimiter.
+const defaultMaxLabelValues = 100
+
+// OverflowLabelValue is reported by LabelLimiter in place of the values over the limit.
+const OverflowLabelValue = "_other"
+
+// LabelLimiter picks the labels associated by WithLabels for the dimensions of metrics,
+// and bounds the number of the distinct values per key, so an unexpected label,
+// e.g. a user ID, doesn't explode the cardinality of the metrics.
+// It is safe for concurrent use.
+type LabelLimiter struct {
+	keys []string
+	max  int
+
+	mu   sync.Mutex
+	seen []map[string]struct{} // the values seen per key
+}
+
+// NewLabelLimiter creates new LabelLimiter which picks the labels of keys.
+// Once maxValues distinct values of a key are seen, the other values of the key are reported as OverflowLabelValue.
+// If maxValues is zero or negative, 100 is used.
+func NewLabelLimiter(keys []string, maxValues int) *LabelLimiter {
+	if maxValues <= 0 {
+		maxValues = defaultMaxLabelValues
+	}
+	seen := make([]map[string]struct{}, len(keys))
+	for i := range seen {
+		seen[i] = make(map[string]struct{})
+	}
+	return &LabelLimiter{
+		keys: append([]string(nil), keys...),
+		max:  maxValues,
+		seen: seen,
+	}
+}
+
+// Keys returns the keys of the labels picked by l.
+func (l *LabelLimiter) Keys() []string {
+	return append([]string(nil), l.keys...)
+}
+
+// Values returns the values of the keys in labels, in the order of the keys.
+// The values of the missing labels are empty.
+func (l *LabelLimiter) Values(labels map[string]string) []string {
+	ret := make([]string, len(l.keys))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, key := range l.keys {
+		v, ok := labels[key]
+		if !ok {
+			continue
+		}
+		if _, ok := l.seen[i][v]; !ok {
+			if len(l.seen[i]) >= l.max {
+				v = OverflowLabelValue
+			} else {
+				l.seen[i][v] = struct{}{}
+			}
+		}
+		ret[i] = v
+	}
+	return ret
+}
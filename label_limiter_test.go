@@ -0,0 +1,28 @@
+package proxy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLabelLimiter(t *testing.T) {
+	l := NewLabelLimiter([]string{"tenant", "region"}, 2)
+	tests := []struct {
+		labels map[string]string
+		want   []string
+	}{
+		{map[string]string{"tenant": "acme", "region": "us", "user": "alice"}, []string{"acme", "us"}},
+		{map[string]string{"tenant": "globex"}, []string{"globex", ""}},
+		{map[string]string{"tenant": "initech", "region": "eu"}, []string{OverflowLabelValue, "eu"}},
+		{map[string]string{"tenant": "acme", "region": "ap"}, []string{"acme", OverflowLabelValue}},
+		{nil, []string{"", ""}},
+	}
+	for _, tt := range tests {
+		if got := l.Values(tt.labels); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: want %v, got %v", tt.labels, tt.want, got)
+		}
+	}
+	if got := l.Keys(); !reflect.DeepEqual(got, []string{"tenant", "region"}) {
+		t.Errorf("unexpected keys: %v", got)
+	}
+}
@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"database/sql/driver"
+	"sync"
+)
+
+// lazyDriver is the sql driver registered as name, which is resolved on first use.
+type lazyDriver struct {
+	name string
+
+	// onResolve is called with the resolved driver before the driver is used, if it is not nil.
+	onResolve func(d driver.Driver)
+
+	mu sync.Mutex
+	d  driver.Driver // nil until resolved
+}
+
+// NewProxyByName creates new Proxy driver for the sql driver registered as driverName.
+// Unlike NewProxyContext, the driver is resolved on the first Open or OpenConnector,
+// so driverName may be registered after the proxy is created.
+// Open and OpenConnector return an error if driverName is not registered.
+//
+// The Driver field of the returned proxy is not the driver registered as driverName, but a wrapper of it,
+// which UnwrapDriver unwraps after the first use.
+// Resolving the driver calls its OpenConnector one extra time with the data source name of the first use,
+// and closes the connector immediately.
+func NewProxyByName(driverName string, hs ...*HooksContext) *Proxy {
+	return NewProxyContext(&lazyDriver{name: driverName}, hs...)
+}
+
+// resolve returns the driver registered as d.name.
+// dsn is the data source name to be opened, which is used to look up the driver.
+// The failures are not cached, so the driver can be registered later.
+func (d *lazyDriver) resolve(dsn string) (driver.Driver, error) {
+	d.mu.Lock()
+	drv := d.d
+	d.mu.Unlock()
+	if drv != nil {
+		return drv, nil
+	}
+
+	drv, err := lookupDriver(d.name, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if d.onResolve != nil {
+		// it is called without d.mu, because it may walk the chain of drivers by Unwrap.
+		// the concurrent resolutions may call it more than once, so it must be idempotent.
+		d.onResolve(drv)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.d = drv
+	return drv, nil
+}
+
+// Open opens a new connection by the resolved driver.
+func (d *lazyDriver) Open(name string) (driver.Conn, error) {
+	drv, err := d.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return drv.Open(name)
+}
+
+// OpenConnector opens a new connector by the resolved driver.
+// If the resolved driver doesn't implement driver.DriverContext, the connector calls Open.
+func (d *lazyDriver) OpenConnector(name string) (driver.Connector, error) {
+	drv, err := d.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if dc, ok := drv.(driver.DriverContext); ok {
+		return dc.OpenConnector(name)
+	}
+	return &fallbackConnector{
+		driver: drv,
+		name:   name,
+	}, nil
+}
+
+// Unwrap returns the resolved driver.
+// It returns nil until the driver is resolved, so walking the chain of drivers doesn't resolve it.
+func (d *lazyDriver) Unwrap() driver.Driver {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.d
+}
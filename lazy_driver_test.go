@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNewProxyByName(t *testing.T) {
+	var pings int
+	p := NewProxyByName("fakedb-lazy", &HooksContext{
+		PostPing: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			pings++
+			return nil
+		},
+	})
+	sql.Register("fakedb-lazy-proxy", p)
+
+	// the driver is not registered yet.
+	if _, err := sql.Open("fakedb-lazy-proxy", `{"name":"lazy"}`); err == nil {
+		t.Error("want error, got nil")
+	}
+	if UnwrapDriver(p) != p.Driver {
+		t.Error("want the driver not to be resolved")
+	}
+
+	sql.Register("fakedb-lazy", fdriver)
+	db, err := sql.Open("fakedb-lazy-proxy", `{"name":"lazy"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.PingContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if pings != 1 {
+		t.Errorf("want 1 PostPing call, got %d", pings)
+	}
+	if UnwrapDriver(p) != fdriver {
+		t.Errorf("want %v, got %v", fdriver, UnwrapDriver(p))
+	}
+}
+
+// countingDriverCtx counts the calls of OpenConnector.
+type countingDriverCtx struct {
+	*fakeDriverCtx
+	connectors int32
+}
+
+func (d *countingDriverCtx) OpenConnector(name string) (driver.Connector, error) {
+	atomic.AddInt32(&d.connectors, 1)
+	c, err := d.fakeDriverCtx.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConnector{Connector: c, driver: d}, nil
+}
+
+type countingConnector struct {
+	driver.Connector
+	driver *countingDriverCtx
+}
+
+func (c *countingConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func TestNewProxyByName_OpenConnector(t *testing.T) {
+	d := &countingDriverCtx{fakeDriverCtx: fdriverctx}
+	sql.Register("fakedb-lazy-count", d)
+	sql.Register("fakedb-lazy-count-proxy", NewProxyByName("fakedb-lazy-count"))
+
+	dsn := `{"Name":"lazy-count","ConnType":"fakeConnCtx"}`
+	db, err := sql.Open("fakedb-lazy-count-proxy", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	// the first use calls OpenConnector twice: once for resolving the driver, and once for the proxy.
+	if got := atomic.LoadInt32(&d.connectors); got != 2 {
+		t.Errorf("want 2 calls, got %d", got)
+	}
+
+	db, err = sql.Open("fakedb-lazy-count-proxy", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if got := atomic.LoadInt32(&d.connectors); got != 3 {
+		t.Errorf("want 3 calls, got %d", got)
+	}
+}
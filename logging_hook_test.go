@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"time"
 )
 
 type loggingHook struct {
@@ -231,3 +232,91 @@ func (h *loggingHook) isValid(ctx interface{}, conn *Conn) error {
 func (h *loggingHook) postIsValid(ctx interface{}, conn *Conn, valid bool) error {
 	return nil
 }
+
+func (h *loggingHook) preStmtClose(c context.Context, stmt *Stmt) (interface{}, error) {
+	return nil, nil
+}
+
+func (h *loggingHook) stmtClose(c context.Context, ctx interface{}, stmt *Stmt) error {
+	return nil
+}
+
+func (h *loggingHook) postStmtClose(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+	return nil
+}
+
+func (h *loggingHook) preRowsClose(c context.Context, rows *Rows) (interface{}, error) {
+	return nil, nil
+}
+
+func (h *loggingHook) rowsClose(c context.Context, ctx interface{}, rows *Rows) error {
+	return nil
+}
+
+func (h *loggingHook) postRowsClose(c context.Context, ctx interface{}, rows *Rows, err error) error {
+	return nil
+}
+
+func (h *loggingHook) postRows(c context.Context, rows *Rows, count int64, d time.Duration) error {
+	return nil
+}
+
+func (h *loggingHook) postLastInsertId(c context.Context, result *Result, id int64, err error) error {
+	return nil
+}
+
+func (h *loggingHook) postRowsAffected(c context.Context, result *Result, n int64, err error) error {
+	return nil
+}
+
+func (h *loggingHook) preConnect(c context.Context, connector *Connector) (interface{}, error) {
+	return nil, nil
+}
+
+func (h *loggingHook) connect(c context.Context, ctx interface{}, connector *Connector, conn *Conn) error {
+	return nil
+}
+
+func (h *loggingHook) postConnect(c context.Context, ctx interface{}, connector *Connector, conn *Conn, err error) error {
+	return nil
+}
+
+func (h *loggingHook) preConnectorClose(c context.Context, connector *Connector) (interface{}, error) {
+	return nil, nil
+}
+
+func (h *loggingHook) postConnectorClose(c context.Context, ctx interface{}, connector *Connector, err error) error {
+	return nil
+}
+
+func (h *loggingHook) onCanceled(c context.Context, op Operation, d time.Duration, err error) error {
+	return nil
+}
+
+func (h *loggingHook) columns(c context.Context, rows *Rows) error {
+	return nil
+}
+
+func (h *loggingHook) preSavepoint(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error) {
+	return nil, nil
+}
+
+func (h *loggingHook) savepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error {
+	return nil
+}
+
+func (h *loggingHook) postSavepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error {
+	return nil
+}
+
+func (h *loggingHook) columnConverter(stmt *Stmt, idx int, conv driver.ValueConverter) driver.ValueConverter {
+	return conv
+}
+
+func (h *loggingHook) oncePerConn(c context.Context, conn *Conn) error {
+	return nil
+}
+
+func (h *loggingHook) rewriteDSN(c context.Context, name string) (string, error) {
+	return name, nil
+}
@@ -0,0 +1,10 @@
+module github.com/shogo82148/go-sql-proxy/logrusproxy
+
+go 1.25.0
+
+require (
+	github.com/shogo82148/go-sql-proxy v0.8.0 // placeholder: needs an unreleased version of the root module, see README
+	github.com/sirupsen/logrus v1.10.2
+)
+
+require golang.org/x/sys v0.13.0 // indirect
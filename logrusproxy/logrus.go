@@ -0,0 +1,78 @@
+// Package logrusproxy integrates go-sql-proxy with github.com/sirupsen/logrus.
+package logrusproxy
+
+import (
+	"context"
+	"time"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"github.com/sirupsen/logrus"
+)
+
+// Options holds the options of NewHooks.
+type Options struct {
+	// Level is the level of the entries of the operations.
+	// If it is zero, which is logrus.PanicLevel, logrus.DebugLevel is used.
+	Level logrus.Level
+
+	// SlowQuery is a threshold duration of slow operations.
+	// The entries of the operations that take SlowQuery or longer are logged at logrus.WarnLevel.
+	// If it is zero, no operations are slow.
+	SlowQuery time.Duration
+
+	// OmitArgs omits the arguments of queries from the entries.
+	OmitArgs bool
+}
+
+// NewHooks creates new HooksContext which logs the operations to logger as logrus entries.
+// The entries have the fields "query", "args", "duration", "conn_id", "tx_id", "error" and "labels",
+// and are omitted if they are empty.
+// The failed operations are logged at logrus.ErrorLevel.
+// If logger is nil, logrus.StandardLogger() is used.
+func NewHooks(logger *logrus.Logger, opt Options) *proxy.HooksContext {
+	level := opt.Level
+	if level == logrus.PanicLevel {
+		level = logrus.DebugLevel
+	}
+	return proxy.NewEventHooks(func(c context.Context, e *proxy.Event) {
+		l := logger
+		if l == nil {
+			l = logrus.StandardLogger()
+		}
+		lv := level
+		if e.Error != "" {
+			lv = logrus.ErrorLevel
+		} else if opt.SlowQuery > 0 && e.Duration >= opt.SlowQuery {
+			lv = logrus.WarnLevel
+		}
+		if !l.IsLevelEnabled(lv) {
+			return
+		}
+
+		fields := make(logrus.Fields, 7)
+		if e.Query != "" {
+			fields["query"] = e.Query
+		}
+		if len(e.Args) > 0 && !opt.OmitArgs {
+			args := make([]interface{}, len(e.Args))
+			for i, arg := range e.Args {
+				args[i] = arg.Value
+			}
+			fields["args"] = args
+		}
+		fields["duration"] = e.Duration
+		if e.ConnID != 0 {
+			fields["conn_id"] = e.ConnID
+		}
+		if e.TxID != 0 {
+			fields["tx_id"] = e.TxID
+		}
+		if e.Error != "" {
+			fields["error"] = e.Error
+		}
+		if len(e.Labels) > 0 {
+			fields["labels"] = e.Labels
+		}
+		l.WithContext(c).WithFields(fields).Log(lv, e.Operation.String())
+	})
+}
@@ -0,0 +1,76 @@
+package logrusproxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func exec(t *testing.T, h *proxy.HooksContext, c context.Context, args []driver.NamedValue, err error) {
+	t.Helper()
+	stmt := &proxy.Stmt{QueryString: "INSERT INTO t1 (id, name) VALUES(?, ?)"}
+	ctx, e := h.PreExec(c, stmt, args)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e := h.PostExec(c, ctx, stmt, args, nil, err); e != nil {
+		t.Fatal(e)
+	}
+}
+
+func TestNewHooks(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	h := NewHooks(logger, Options{})
+
+	c := proxy.WithLabels(context.Background(), map[string]string{"job": "test"})
+	args := []driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: "foo"},
+	}
+	exec(t, h, c, args, nil)
+	exec(t, h, c, args, errors.New("exec failed"))
+
+	entries := hook.AllEntries()
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Level != logrus.DebugLevel || e.Message != "Exec" || e.Data["query"] != "INSERT INTO t1 (id, name) VALUES(?, ?)" {
+		t.Errorf("unexpected entry: %v %v", e.Message, e.Data)
+	}
+	if got, ok := e.Data["args"].([]interface{}); !ok || len(got) != 2 || got[0] != int64(1) || got[1] != "foo" {
+		t.Errorf("unexpected args: %#v", e.Data["args"])
+	}
+	if got, ok := e.Data["labels"].(map[string]string); !ok || got["job"] != "test" {
+		t.Errorf("unexpected labels: %#v", e.Data["labels"])
+	}
+	if e := entries[1]; e.Level != logrus.ErrorLevel || e.Data["error"] != "exec failed" {
+		t.Errorf("unexpected entry: %v %v", e.Message, e.Data)
+	}
+}
+
+func TestNewHooks_Level(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.InfoLevel)
+	h := NewHooks(logger, Options{OmitArgs: true})
+
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+	exec(t, h, context.Background(), args, nil)
+	if n := len(hook.AllEntries()); n != 0 {
+		t.Errorf("want no entries below the level of the logger, got %d", n)
+	}
+	exec(t, h, context.Background(), args, errors.New("exec failed"))
+	entries := hook.AllEntries()
+	if len(entries) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(entries))
+	}
+	if _, ok := entries[0].Data["args"]; ok {
+		t.Errorf("want no args, got %v", entries[0].Data["args"])
+	}
+}
@@ -0,0 +1,78 @@
+package proxy
+
+import "strings"
+
+// MultiOutputError is returned by the Outputter created by MultiOutputter
+// when some of the outputs fail.
+type MultiOutputError []error
+
+// Error returns the messages of the errors joined by "; ".
+func (e MultiOutputError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "proxy: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of the outputs, for errors.Is and errors.As.
+func (e MultiOutputError) Unwrap() []error {
+	return e
+}
+
+// MultiOutputter creates an Outputter that duplicates its logs to all the outs, e.g. stderr and a file.
+// The logs are output to all the outs, even if some of them fail,
+// and the errors are returned as MultiOutputError.
+// It implements LevelOutputter, and passes the levels to the outs that implement LevelOutputter.
+func MultiOutputter(outs ...Outputter) Outputter {
+	all := make(multiOutputter, 0, len(outs))
+	for _, o := range outs {
+		if o == nil {
+			continue
+		}
+		if mo, ok := o.(multiOutputter); ok {
+			all = append(all, mo...)
+		} else {
+			all = append(all, o)
+		}
+	}
+	return all
+}
+
+type multiOutputter []Outputter
+
+// Output outputs s to all the outs.
+func (m multiOutputter) Output(calldepth int, s string) error {
+	var errs MultiOutputError
+	for _, o := range m {
+		// +1 for the Output method itself.
+		if err := o.Output(calldepth+1, s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// OutputLevel outputs s to all the outs with the level.
+func (m multiOutputter) OutputLevel(calldepth int, level TraceLevel, s string) error {
+	var errs MultiOutputError
+	for _, o := range m {
+		var err error
+		// +1 for the OutputLevel method itself.
+		if lo, ok := o.(LevelOutputter); ok {
+			err = lo.OutputLevel(calldepth+1, level, s)
+		} else {
+			err = o.Output(calldepth+1, s)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
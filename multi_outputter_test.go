@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+type failOutputter struct {
+	err error
+}
+
+func (o failOutputter) Output(calldepth int, s string) error {
+	return o.err
+}
+
+func TestMultiOutputter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	rec := &levelRecorder{}
+	errOutput := errors.New("output failed")
+	o := MultiOutputter(
+		log.New(buf, "", log.Lshortfile),
+		MultiOutputter(failOutputter{err: errOutput}, nil),
+		rec,
+	)
+
+	err := o.Output(1, "hello")
+	if merr, ok := err.(MultiOutputError); !ok || len(merr) != 1 || merr[0] != errOutput {
+		t.Fatalf("want MultiOutputError of %v, got %v", errOutput, err)
+	}
+	if want := "proxy: output failed"; err.Error() != want {
+		t.Errorf("want %q, got %q", want, err.Error())
+	}
+	// the other outputs are not affected by the failure.
+	if got := buf.String(); !strings.HasPrefix(got, "multi_outputter_test.go:") || !strings.HasSuffix(got, ": hello\n") {
+		t.Errorf("want the log with the caller, got %q", got)
+	}
+	if len(rec.logs) != 1 || rec.logs[0] != "hello" {
+		t.Errorf("want [hello], got %v", rec.logs)
+	}
+
+	err = o.(LevelOutputter).OutputLevel(1, TraceLevelWarn, "world")
+	if merr, ok := err.(MultiOutputError); !ok || len(merr) != 1 || merr[0] != errOutput {
+		t.Errorf("want %v, got %v", errOutput, err)
+	}
+	if len(rec.levels) != 2 || rec.levels[1] != TraceLevelWarn {
+		t.Errorf("want the level to be passed, got %v", rec.levels)
+	}
+
+	if err := MultiOutputter(rec).Output(1, "ok"); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+}
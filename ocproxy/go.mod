@@ -0,0 +1,10 @@
+module github.com/shogo82148/go-sql-proxy/ocproxy
+
+go 1.25.0
+
+require (
+	github.com/shogo82148/go-sql-proxy v0.8.0 // placeholder: needs an unreleased version of the root module, see README
+	go.opencensus.io v0.24.0
+)
+
+require github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
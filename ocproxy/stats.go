@@ -0,0 +1,67 @@
+package ocproxy
+
+import (
+	"context"
+	"time"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// MeasureLatency is the latency of the operations in milliseconds.
+var MeasureLatency = stats.Float64("go-sql-proxy/latency", "The latency of the operations", stats.UnitMilliseconds)
+
+// the tag keys of the measurements.
+var (
+	// KeyOperation is the name of the operation, e.g. "Exec" and "Commit".
+	KeyOperation = tag.MustNewKey("sql_operation")
+
+	// KeyStatus is the result of the operation, "ok" or "error".
+	KeyStatus = tag.MustNewKey("sql_status")
+)
+
+// DefaultLatencyDistribution is the bucket boundaries of LatencyView in milliseconds.
+var DefaultLatencyDistribution = view.Distribution(0, 1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000)
+
+// the views of MeasureLatency.
+var (
+	// LatencyView is the distribution of the latencies by the operations and the results.
+	LatencyView = &view.View{
+		Name:        "go-sql-proxy/latency",
+		Description: "The distribution of the latencies of the operations",
+		Measure:     MeasureLatency,
+		TagKeys:     []tag.Key{KeyOperation, KeyStatus},
+		Aggregation: DefaultLatencyDistribution,
+	}
+
+	// CallsView is the number of the operations by the operations and the results.
+	CallsView = &view.View{
+		Name:        "go-sql-proxy/calls",
+		Description: "The number of the operations",
+		Measure:     MeasureLatency,
+		TagKeys:     []tag.Key{KeyOperation, KeyStatus},
+		Aggregation: view.Count(),
+	}
+)
+
+// DefaultViews is the views recorded by the hooks created by NewOCStatsHooks.
+// Register them with view.Register to export them.
+var DefaultViews = []*view.View{LatencyView, CallsView}
+
+// NewOCStatsHooks creates new HooksContext which records MeasureLatency of the operations
+// with KeyOperation and KeyStatus tags.
+// The tags in the contexts of the operations are also attached to the measurements.
+func NewOCStatsHooks() *proxy.HooksContext {
+	return proxy.NewEventHooks(func(c context.Context, e *proxy.Event) {
+		status := "ok"
+		if e.Error != "" {
+			status = "error"
+		}
+		stats.RecordWithTags(c, []tag.Mutator{
+			tag.Upsert(KeyOperation, e.Operation.String()),
+			tag.Upsert(KeyStatus, status),
+		}, MeasureLatency.M(float64(e.Duration)/float64(time.Millisecond)))
+	})
+}
@@ -0,0 +1,78 @@
+package ocproxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+func TestNewOCStatsHooks(t *testing.T) {
+	if err := view.Register(DefaultViews...); err != nil {
+		t.Fatal(err)
+	}
+	defer view.Unregister(DefaultViews...)
+	h := NewOCStatsHooks()
+
+	c := context.Background()
+	stmt := &proxy.Stmt{QueryString: "INSERT INTO t1 (id) VALUES(1)"}
+	for _, execErr := range []error{nil, nil, errors.New("exec failed")} {
+		ctx, err := h.PreExec(c, stmt, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := h.PostExec(c, ctx, stmt, nil, nil, execErr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := view.RetrieveData(CallsView.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int64{}
+	for _, row := range rows {
+		var op, status string
+		for _, tg := range row.Tags {
+			switch tg.Key {
+			case KeyOperation:
+				op = tg.Value
+			case KeyStatus:
+				status = tg.Value
+			}
+		}
+		counts[op+"/"+status] = row.Data.(*view.CountData).Value
+	}
+	if got := counts["Exec/ok"]; got != 2 {
+		t.Errorf("want 2 succeeded Exec, got %d", got)
+	}
+	if got := counts["Exec/error"]; got != 1 {
+		t.Errorf("want 1 failed Exec, got %d", got)
+	}
+
+	rows, err = view.RetrieveData(LatencyView.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	for _, row := range rows {
+		if hasTag(row.Tags, KeyStatus, "ok") {
+			n += row.Data.(*view.DistributionData).Count
+		}
+	}
+	if n != 2 {
+		t.Errorf("want 2 latencies of succeeded Exec, got %d", n)
+	}
+}
+
+func hasTag(tags []tag.Tag, key tag.Key, value string) bool {
+	for _, tg := range tags {
+		if tg.Key == key && tg.Value == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+// Package ocproxy integrates go-sql-proxy with OpenCensus.
+package ocproxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.opencensus.io/trace"
+)
+
+// the attribute keys of the spans, which follow the semantic conventions for database client calls.
+const (
+	dbSystemKey    = "db.system"
+	dbStatementKey = "db.statement"
+	dbOperationKey = "db.operation"
+)
+
+// Option configures the hooks created by NewOCHooks.
+type Option func(*config)
+
+type config struct {
+	attrs         []trace.Attribute
+	omitStatement bool
+	sampler       trace.Sampler
+}
+
+// WithAttributes adds the attributes to all the spans, e.g. "db.name" and "server.address".
+func WithAttributes(attrs ...trace.Attribute) Option {
+	return func(c *config) {
+		c.attrs = append(c.attrs, attrs...)
+	}
+}
+
+// WithDBSystem sets the "db.system" attribute of the spans, e.g. "mysql" and "postgresql".
+func WithDBSystem(system string) Option {
+	return WithAttributes(trace.StringAttribute(dbSystemKey, system))
+}
+
+// WithoutStatement omits the "db.statement" attribute from the spans,
+// e.g. if the queries may contain sensitive data.
+func WithoutStatement() Option {
+	return func(c *config) {
+		c.omitStatement = true
+	}
+}
+
+// WithSampler sets the sampler of the spans.
+// If it is not set, the default sampler of OpenCensus is used.
+func WithSampler(sampler trace.Sampler) Option {
+	return func(c *config) {
+		c.sampler = sampler
+	}
+}
+
+// NewOCHooks creates new HooksContext which records the OpenCensus spans of Exec, Query, Begin, Commit and Rollback.
+// The spans are the children of the spans associated with the contexts of the operations,
+// and have the "db.statement" and "db.operation" attributes.
+// The errors of the operations are recorded into the statuses of the spans.
+// The spans of Query end when the queries return, before the rows are read.
+func NewOCHooks(opts ...Option) *proxy.HooksContext {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	startOpts := []trace.StartOption{trace.WithSpanKind(trace.SpanKindClient)}
+	if cfg.sampler != nil {
+		startOpts = append(startOpts, trace.WithSampler(cfg.sampler))
+	}
+
+	start := func(c context.Context, operation, query string) *trace.Span {
+		name := operation
+		if name == "" {
+			name = "SQL"
+		}
+		_, span := trace.StartSpan(c, name, startOpts...)
+		if !span.IsRecordingEvents() {
+			return span
+		}
+		attrs := make([]trace.Attribute, 0, len(cfg.attrs)+2)
+		attrs = append(attrs, cfg.attrs...)
+		if query != "" && !cfg.omitStatement {
+			attrs = append(attrs, trace.StringAttribute(dbStatementKey, query))
+		}
+		if operation != "" {
+			attrs = append(attrs, trace.StringAttribute(dbOperationKey, operation))
+		}
+		span.AddAttributes(attrs...)
+		return span
+	}
+	end := func(ctx interface{}, err error) error {
+		span, ok := ctx.(*trace.Span)
+		if !ok {
+			return nil
+		}
+		if err != nil && err != driver.ErrSkip {
+			span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+		}
+		span.End()
+		return nil
+	}
+
+	return &proxy.HooksContext{
+		PreExec: func(c context.Context, stmt *proxy.Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return start(c, operationName(stmt.QueryString), stmt.QueryString), nil
+		},
+		PostExec: func(_ context.Context, ctx interface{}, _ *proxy.Stmt, _ []driver.NamedValue, _ driver.Result, err error) error {
+			return end(ctx, err)
+		},
+		PreQuery: func(c context.Context, stmt *proxy.Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return start(c, operationName(stmt.QueryString), stmt.QueryString), nil
+		},
+		PostQuery: func(_ context.Context, ctx interface{}, _ *proxy.Stmt, _ []driver.NamedValue, _ driver.Rows, err error) error {
+			return end(ctx, err)
+		},
+		PreBegin: func(c context.Context, _ *proxy.Conn) (interface{}, error) {
+			return start(c, "BEGIN", ""), nil
+		},
+		PostBegin: func(_ context.Context, ctx interface{}, _ *proxy.Conn, err error) error {
+			return end(ctx, err)
+		},
+		PreCommit: func(c context.Context, _ *proxy.Tx) (interface{}, error) {
+			return start(c, "COMMIT", ""), nil
+		},
+		PostCommit: func(_ context.Context, ctx interface{}, _ *proxy.Tx, err error) error {
+			return end(ctx, err)
+		},
+		PreRollback: func(c context.Context, _ *proxy.Tx) (interface{}, error) {
+			return start(c, "ROLLBACK", ""), nil
+		},
+		PostRollback: func(_ context.Context, ctx interface{}, _ *proxy.Tx, err error) error {
+			return end(ctx, err)
+		},
+	}
+}
+
+// operationName returns the first keyword of query in upper case, e.g. "SELECT".
+func operationName(query string) string {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	i := 0
+	for i < len(query) && isLetter(query[i]) {
+		i++
+	}
+	return strings.ToUpper(query[:i])
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,124 @@
+package ocproxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.opencensus.io/trace"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, s)
+}
+
+func TestNewOCHooks(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+	h := NewOCHooks(WithDBSystem("mysql"), WithSampler(trace.AlwaysSample()))
+
+	c, parent := trace.StartSpan(context.Background(), "parent", trace.WithSampler(trace.AlwaysSample()))
+	stmt := &proxy.Stmt{QueryString: "SELECT id FROM t1 WHERE id = ?"}
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+	ctx, err := h.PreQuery(c, stmt, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostQuery(c, ctx, stmt, args, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	errExec := errors.New("exec failed")
+	stmt = &proxy.Stmt{QueryString: "INSERT INTO t1 (id) VALUES(?)"}
+	ctx, err = h.PreExec(c, stmt, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostExec(c, ctx, stmt, args, nil, errExec); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := recorder.spans
+	if len(spans) != 3 {
+		t.Fatalf("want 3 spans, got %d", len(spans))
+	}
+
+	query := spans[0]
+	if query.Name != "SELECT" || query.SpanKind != trace.SpanKindClient {
+		t.Errorf("unexpected span: %s %d", query.Name, query.SpanKind)
+	}
+	if query.ParentSpanID != parent.SpanContext().SpanID {
+		t.Error("want the span to be the child of the parent span, but not")
+	}
+	for key, want := range map[string]string{
+		"db.system":    "mysql",
+		"db.statement": "SELECT id FROM t1 WHERE id = ?",
+		"db.operation": "SELECT",
+	} {
+		if got := query.Attributes[key]; got != want {
+			t.Errorf("%s: want %q, got %v", key, want, got)
+		}
+	}
+	if query.Code != trace.StatusCodeOK {
+		t.Errorf("want no errors, got %v", query.Status)
+	}
+
+	exec := spans[1]
+	if exec.Name != "INSERT" || exec.Code != trace.StatusCodeUnknown || exec.Message != "exec failed" {
+		t.Errorf("unexpected span: %s %v", exec.Name, exec.Status)
+	}
+}
+
+func TestNewOCHooks_WithoutStatement(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+	h := NewOCHooks(WithoutStatement(), WithSampler(trace.AlwaysSample()))
+
+	c := context.Background()
+	ctx, err := h.PreBegin(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostBegin(c, ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	stmt := &proxy.Stmt{QueryString: "DELETE FROM t1"}
+	ctx, err = h.PreExec(c, stmt, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostExec(c, ctx, stmt, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	ctx, err = h.PreRollback(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostRollback(c, ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, span := range recorder.spans {
+		names = append(names, span.Name)
+		if v, ok := span.Attributes["db.statement"]; ok {
+			t.Errorf("want no db.statement, got %v", v)
+		}
+	}
+	if len(names) != 3 || names[0] != "BEGIN" || names[1] != "DELETE" || names[2] != "ROLLBACK" {
+		t.Errorf("want [BEGIN DELETE ROLLBACK], got %v", names)
+	}
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import "fmt"
+
+// Operation is a kind of the operations that the proxy hooks.
+type Operation int
+
+const (
+	// OpUnknown is an unknown operation.
+	OpUnknown Operation = iota
+
+	// OpOpen is the operation of opening a new connection.
+	OpOpen
+
+	// OpPing is the operation of Conn.Ping.
+	OpPing
+
+	// OpPrepare is the operation of Conn.PrepareContext.
+	OpPrepare
+
+	// OpExec is the operation of Conn.ExecContext and Stmt.ExecContext.
+	OpExec
+
+	// OpQuery is the operation of Conn.QueryContext and Stmt.QueryContext.
+	OpQuery
+
+	// OpBegin is the operation of Conn.BeginTx.
+	OpBegin
+
+	// OpCommit is the operation of Tx.Commit.
+	OpCommit
+
+	// OpRollback is the operation of Tx.Rollback.
+	OpRollback
+
+	// OpClose is the operation of Conn.Close.
+	OpClose
+
+	// OpResetSession is the operation of Conn.ResetSession.
+	OpResetSession
+
+	// OpIsValid is the operation of Conn.IsValid.
+	OpIsValid
+
+	// OpStmtClose is the operation of Stmt.Close.
+	OpStmtClose
+
+	// OpRowsClose is the operation of Rows.Close.
+	OpRowsClose
+
+	// OpInitConn is the operation of the OncePerConn hook.
+	OpInitConn
+
+	// OpConnectorClose is the operation of Connector.Close.
+	OpConnectorClose
+
+	numOperations
+)
+
+// OperationSet is a set of operations.
+type OperationSet uint64
+
+// NewOperationSet returns a set of ops.
+func NewOperationSet(ops ...Operation) OperationSet {
+	var s OperationSet
+	for _, op := range ops {
+		s |= 1 << uint(op)
+	}
+	return s
+}
+
+// Contains reports whether op is in the set.
+func (s OperationSet) Contains(op Operation) bool {
+	return s&(1<<uint(op)) != 0
+}
+
+var operationNames = [...]string{
+	OpUnknown:        "Unknown",
+	OpOpen:           "Open",
+	OpPing:           "Ping",
+	OpPrepare:        "Prepare",
+	OpExec:           "Exec",
+	OpQuery:          "Query",
+	OpBegin:          "Begin",
+	OpCommit:         "Commit",
+	OpRollback:       "Rollback",
+	OpClose:          "Close",
+	OpResetSession:   "ResetSession",
+	OpIsValid:        "IsValid",
+	OpStmtClose:      "StmtClose",
+	OpRowsClose:      "RowsClose",
+	OpInitConn:       "InitConn",
+	OpConnectorClose: "ConnectorClose",
+}
+
+// String returns the name of the operation.
+func (op Operation) String() string {
+	if op < 0 || op >= numOperations {
+		return fmt.Sprintf("Operation(%d)", int(op))
+	}
+	return operationNames[op]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (op Operation) MarshalText() ([]byte, error) {
+	return []byte(op.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (op *Operation) UnmarshalText(text []byte) error {
+	for i, name := range operationNames {
+		if name == string(text) {
+			*op = Operation(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("proxy: unknown operation %q", text)
+}
@@ -0,0 +1,34 @@
+package proxy
+
+import "testing"
+
+func TestOperation(t *testing.T) {
+	for op := OpUnknown; op < numOperations; op++ {
+		text, err := op.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Operation
+		if err := got.UnmarshalText(text); err != nil {
+			t.Fatal(err)
+		}
+		if got != op {
+			t.Errorf("want %v, got %v", op, got)
+		}
+	}
+
+	var op Operation
+	if err := op.UnmarshalText([]byte("Unknown Operation")); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestOperationSet(t *testing.T) {
+	s := NewOperationSet(OpExec, OpQuery)
+	for op := OpUnknown; op < numOperations; op++ {
+		want := op == OpExec || op == OpQuery
+		if got := s.Contains(op); got != want {
+			t.Errorf("%s: want %t, got %t", op, want, got)
+		}
+	}
+}
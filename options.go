@@ -0,0 +1,72 @@
+package proxy
+
+import "database/sql/driver"
+
+// Option configures a Proxy created by NewProxyWithOptions.
+type Option func(p *Proxy)
+
+// NewProxyWithOptions creates new Proxy driver configured by opts.
+// The options are applied in order.
+func NewProxyWithOptions(driver driver.Driver, opts ...Option) *Proxy {
+	p := newProxy(driver, nil)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+	return p
+}
+
+// WithHookSets installs the hook sets hs, in the same way as NewProxyContext.
+// If it is given more than once, the hook sets are appended.
+func WithHookSets(hs ...*HooksContext) Option {
+	return func(p *Proxy) {
+		hooksSlice := make([]hooks, 0, len(hs))
+		for _, hk := range hs {
+			if hk != nil {
+				hooksSlice = append(hooksSlice, hk)
+			}
+		}
+		hooksSlice = dedupHooks(p.Driver, hooksSlice)
+		p.hooks = appendHooks(p.hooks, hooksSlice...)
+	}
+}
+
+// WithErrorPolicy sets the policy of the errors returned by the Post hooks.
+// See Proxy.WithErrorPolicy.
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(p *Proxy) {
+		p.errorPolicy = policy
+	}
+}
+
+// WithOperationMask restricts the hooks to ops.
+// See Proxy.WithOperations.
+func WithOperationMask(ops ...Operation) Option {
+	return func(p *Proxy) {
+		p.disabledOps = ^NewOperationSet(ops...)
+	}
+}
+
+// WithConnIDGenerator replaces the generator of the connection IDs returned by Conn.ID.
+// gen must be safe for concurrent use.
+func WithConnIDGenerator(gen func() int64) Option {
+	return func(p *Proxy) {
+		p.connIDGen = gen
+	}
+}
+
+// newConnID returns a new connection ID.
+func (p *Proxy) newConnID() int64 {
+	if p != nil && p.connIDGen != nil {
+		return p.connIDGen()
+	}
+	return newConnID()
+}
+
+// WithExpvar publishes the statistics of the proxy via expvar. See Proxy.PublishExpvar.
+func WithExpvar(opt ExpvarOptions) Option {
+	return func(p *Proxy) {
+		p.PublishExpvar(opt)
+	}
+}
@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestNewProxyWithOptions(t *testing.T) {
+	errHook := errors.New("hook error")
+	var execs, pings int
+	var id int64 = 1000
+	p := NewProxyWithOptions(
+		fdriver,
+		WithHookSets(&HooksContext{
+			PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+				execs++
+				return errHook
+			},
+			PostPing: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+				pings++
+				return nil
+			},
+		}),
+		WithErrorPolicy(ErrorPolicyReplace),
+		WithOperationMask(OpExec),
+		WithConnIDGenerator(func() int64 {
+			id++
+			return id
+		}),
+	)
+
+	conn := newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+	if conn.ID() != 1001 {
+		t.Errorf("want connection ID 1001, got %d", conn.ID())
+	}
+
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+	if _, err := conn.ExecContext(context.Background(), "INSERT INTO t1 (id) VALUES(?)", args); err != errHook {
+		t.Errorf("want %v, got %v", errHook, err)
+	}
+	if err := conn.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if execs != 1 {
+		t.Errorf("want 1 PostExec call, got %d", execs)
+	}
+	if pings != 0 {
+		t.Errorf("want PostPing to be masked, got %d calls", pings)
+	}
+}
@@ -0,0 +1,107 @@
+package otelproxy
+
+import (
+	"context"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// instrumentationName is the name of the meter used by NewOTelMetricsHooks.
+const instrumentationName = "github.com/shogo82148/go-sql-proxy/otelproxy"
+
+const statusKey = attribute.Key("status")
+
+// WithLabels adds the labels associated with the contexts by proxy.WithLabels
+// as the attributes of the metrics created by NewOTelMetricsHooks, e.g. "tenant".
+// The missing labels are omitted.
+// The number of the distinct values per key is limited by WithMaxLabelValues.
+func WithLabels(keys ...string) Option {
+	return func(c *config) {
+		c.labels = append(c.labels, keys...)
+	}
+}
+
+// WithMaxLabelValues sets the maximum number of the distinct values per key of the labels added by WithLabels.
+// The other values are reported as proxy.OverflowLabelValue.
+// The default is 100.
+func WithMaxLabelValues(n int) Option {
+	return func(c *config) {
+		c.maxLabelValues = n
+	}
+}
+
+// NewOTelMetricsHooks creates new HooksContext which records the metrics of the operations with the meter of mp:
+//
+//   - "db.client.operation.duration": the histogram of the durations of the operations in seconds
+//   - "db.client.operations": the counter of the operations
+//   - "db.client.connection.count": the number of the open connections
+//
+// The metrics of the operations have the "db.operation" attribute, e.g. "Exec" and "Commit",
+// and the "status" attribute, which is "ok" or "error".
+// The attributes added by WithAttributes and WithDBSystem are attached to all the metrics,
+// and the labels added by WithLabels are attached to the metrics of the operations.
+func NewOTelMetricsHooks(mp metric.MeterProvider, opts ...Option) (*proxy.HooksContext, error) {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	meter := mp.Meter(instrumentationName)
+
+	duration, err := meter.Float64Histogram(
+		"db.client.operation.duration",
+		metric.WithDescription("Duration of database client operations."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	calls, err := meter.Int64Counter(
+		"db.client.operations",
+		metric.WithDescription("Number of database client operations."),
+		metric.WithUnit("{operation}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	conns, err := meter.Int64UpDownCounter(
+		"db.client.connection.count",
+		metric.WithDescription("Number of open connections."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	connAttrs := metric.WithAttributeSet(attribute.NewSet(cfg.attrs...))
+	limiter := proxy.NewLabelLimiter(cfg.labels, cfg.maxLabelValues)
+	labelKeys := limiter.Keys()
+
+	return proxy.NewEventHooks(func(c context.Context, e *proxy.Event) {
+		status := "ok"
+		if e.Error != "" {
+			status = "error"
+		}
+		attrs := make([]attribute.KeyValue, 0, len(cfg.attrs)+2+len(labelKeys))
+		attrs = append(attrs, cfg.attrs...)
+		attrs = append(attrs, dbOperationKey.String(e.Operation.String()), statusKey.String(status))
+		for i, v := range limiter.Values(e.Labels) {
+			if v != "" {
+				attrs = append(attrs, attribute.String(labelKeys[i], v))
+			}
+		}
+		set := metric.WithAttributeSet(attribute.NewSet(attrs...))
+		duration.Record(c, e.Duration.Seconds(), set)
+		calls.Add(c, 1, set)
+
+		switch e.Operation {
+		case proxy.OpOpen:
+			if e.Error == "" {
+				conns.Add(c, 1, connAttrs)
+			}
+		case proxy.OpClose:
+			// the connection is discarded even if it fails to close.
+			conns.Add(c, -1, connAttrs)
+		}
+	}), nil
+}
@@ -0,0 +1,152 @@
+package otelproxy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestNewOTelMetricsHooks(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	h, err := NewOTelMetricsHooks(mp, WithDBSystem("mysql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := context.Background()
+	for i := 0; i < 2; i++ {
+		ctx, err := h.PreOpen(c, "dsn")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := h.PostOpen(c, ctx, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stmt := &proxy.Stmt{QueryString: "INSERT INTO t1 (id) VALUES(1)"}
+	for _, execErr := range []error{nil, nil, errors.New("exec failed")} {
+		ctx, err := h.PreExec(c, stmt, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := h.PostExec(c, ctx, stmt, nil, nil, execErr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, err := h.PreClose(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostClose(c, ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(c, &rm); err != nil {
+		t.Fatal(err)
+	}
+	metrics := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+
+	execAttrs := func(status string) attribute.Distinct {
+		set := attribute.NewSet(
+			attribute.String("db.system", "mysql"),
+			attribute.String("db.operation", "Exec"),
+			attribute.String("status", status),
+		)
+		return set.Equivalent()
+	}
+
+	calls, ok := metrics["db.client.operations"].Data.(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("want db.client.operations, got %v", metrics["db.client.operations"])
+	}
+	counts := map[attribute.Distinct]int64{}
+	for _, dp := range calls.DataPoints {
+		counts[dp.Attributes.Equivalent()] = dp.Value
+	}
+	if got := counts[execAttrs("ok")]; got != 2 {
+		t.Errorf("want 2 succeeded Exec, got %d", got)
+	}
+	if got := counts[execAttrs("error")]; got != 1 {
+		t.Errorf("want 1 failed Exec, got %d", got)
+	}
+
+	duration, ok := metrics["db.client.operation.duration"].Data.(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatalf("want db.client.operation.duration, got %v", metrics["db.client.operation.duration"])
+	}
+	var n uint64
+	for _, dp := range duration.DataPoints {
+		if dp.Attributes.Equivalent() == execAttrs("ok") {
+			n = dp.Count
+		}
+	}
+	if n != 2 {
+		t.Errorf("want 2 durations of succeeded Exec, got %d", n)
+	}
+	if unit := metrics["db.client.operation.duration"].Unit; unit != "s" {
+		t.Errorf("want the unit s, got %s", unit)
+	}
+
+	conns, ok := metrics["db.client.connection.count"].Data.(metricdata.Sum[int64])
+	if !ok || len(conns.DataPoints) != 1 || conns.DataPoints[0].Value != 1 {
+		t.Errorf("want 1 open connection, got %v", metrics["db.client.connection.count"].Data)
+	}
+}
+
+func TestNewOTelMetricsHooks_Labels(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	h, err := NewOTelMetricsHooks(mp, WithLabels("tenant"), WithMaxLabelValues(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt := &proxy.Stmt{QueryString: "INSERT INTO t1 (id) VALUES(1)"}
+	for _, tenant := range []string{"acme", "acme", "globex", ""} {
+		c := context.Background()
+		if tenant != "" {
+			c = proxy.WithLabel(c, "tenant", tenant)
+		}
+		ctx, err := h.PreExec(c, stmt, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := h.PostExec(c, ctx, stmt, nil, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "db.client.operations" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				v, _ := dp.Attributes.Value("tenant")
+				counts[v.AsString()] += dp.Value
+			}
+		}
+	}
+	want := map[string]int64{"acme": 2, proxy.OverflowLabelValue: 1, "": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("want %v, got %v", want, counts)
+	}
+}
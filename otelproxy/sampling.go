@@ -0,0 +1,29 @@
+// Package otelproxy integrates go-sql-proxy with OpenTelemetry.
+package otelproxy
+
+import (
+	"context"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// IsSampled reports whether the span associated with ctx is recorded or sampled.
+func IsSampled(ctx context.Context) bool {
+	span := trace.SpanFromContext(ctx)
+	return span.IsRecording() || span.SpanContext().IsSampled()
+}
+
+// SampledOnly returns hooks that call h only for the operations
+// executed with a context that has a recorded or sampled span.
+// It is useful for outputting detailed logs exactly for the requests
+// that you can find in the trace backend.
+func SampledOnly(h *proxy.HooksContext) *proxy.HooksContext {
+	return proxy.When(IsSampled, h)
+}
+
+// NewSampledTraceHooks creates new HooksContext which trace SQL queries
+// executed with a context that has a recorded or sampled span.
+func NewSampledTraceHooks(opt proxy.TracerOptions) *proxy.HooksContext {
+	return SampledOnly(proxy.NewTraceHooks(opt))
+}
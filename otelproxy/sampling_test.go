@@ -0,0 +1,62 @@
+package otelproxy
+
+import (
+	"context"
+	"testing"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func sampledContext(flags trace.TraceFlags) context.Context {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01},
+		SpanID:     trace.SpanID{0x01},
+		TraceFlags: flags,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+func TestIsSampled(t *testing.T) {
+	if IsSampled(context.Background()) {
+		t.Error("want not sampled without spans, but sampled")
+	}
+	if IsSampled(sampledContext(0)) {
+		t.Error("want not sampled, but sampled")
+	}
+	if !IsSampled(sampledContext(trace.FlagsSampled)) {
+		t.Error("want sampled, but not")
+	}
+}
+
+func TestSampledOnly(t *testing.T) {
+	var count int
+	h := SampledOnly(&proxy.HooksContext{
+		Ping: func(c context.Context, ctx interface{}, conn *proxy.Conn) error {
+			count++
+			return nil
+		},
+	})
+
+	ping := func(c context.Context) {
+		ctx, err := h.PrePing(c, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Ping(c, ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.PostPing(c, ctx, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ping(sampledContext(0))
+	if count != 0 {
+		t.Errorf("want 0, got %d", count)
+	}
+	ping(sampledContext(trace.FlagsSampled))
+	if count != 1 {
+		t.Errorf("want 1, got %d", count)
+	}
+}
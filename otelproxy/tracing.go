@@ -0,0 +1,159 @@
+package otelproxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// the attribute keys of the semantic conventions for database client calls.
+const (
+	dbSystemKey    = attribute.Key("db.system")
+	dbStatementKey = attribute.Key("db.statement")
+	dbOperationKey = attribute.Key("db.operation")
+)
+
+// Option configures the hooks created by NewOTelHooks.
+type Option func(*config)
+
+type config struct {
+	attrs          []attribute.KeyValue
+	omitStatement  bool
+	labels         []string
+	maxLabelValues int
+}
+
+// WithAttributes adds the attributes to all the spans, e.g. "db.name" and "server.address".
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(c *config) {
+		c.attrs = append(c.attrs, attrs...)
+	}
+}
+
+// WithDBSystem sets the "db.system" attribute of the spans, e.g. "mysql" and "postgresql".
+func WithDBSystem(system string) Option {
+	return WithAttributes(dbSystemKey.String(system))
+}
+
+// WithoutStatement omits the "db.statement" attribute from the spans,
+// e.g. if the queries may contain sensitive data.
+func WithoutStatement() Option {
+	return func(c *config) {
+		c.omitStatement = true
+	}
+}
+
+// NewOTelHooks creates new HooksContext which records the spans of Exec, Query, Begin, Commit and Rollback
+// with tracer. The spans are the children of the spans associated with the contexts of the operations,
+// and have the "db.statement" and "db.operation" attributes of the semantic conventions.
+// The errors of the operations are recorded into the spans.
+// The spans of Query end when the queries return, before the rows are read.
+// The operations that fail with driver.ErrSkip are not recorded,
+// because database/sql retries them in another way, which is recorded instead.
+func NewOTelHooks(tracer trace.Tracer, opts ...Option) *proxy.HooksContext {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	// The spans are started by the Post hooks with the start times recorded by the Pre hooks,
+	// because OpenTelemetry can't discard the spans that are started.
+	start := func(c context.Context, operation, query string) *pendingSpan {
+		return &pendingSpan{
+			c:         c,
+			operation: operation,
+			query:     query,
+			start:     time.Now(),
+		}
+	}
+	end := func(ctx interface{}, err error) error {
+		p, ok := ctx.(*pendingSpan)
+		if !ok || err == driver.ErrSkip {
+			return nil
+		}
+		attrs := make([]attribute.KeyValue, 0, len(cfg.attrs)+2)
+		attrs = append(attrs, cfg.attrs...)
+		if p.query != "" && !cfg.omitStatement {
+			attrs = append(attrs, dbStatementKey.String(p.query))
+		}
+		if p.operation != "" {
+			attrs = append(attrs, dbOperationKey.String(p.operation))
+		}
+		name := p.operation
+		if name == "" {
+			name = "SQL"
+		}
+		_, span := tracer.Start(p.c, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+			trace.WithTimestamp(p.start),
+		)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		return nil
+	}
+
+	return &proxy.HooksContext{
+		PreExec: func(c context.Context, stmt *proxy.Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return start(c, operationName(stmt.QueryString), stmt.QueryString), nil
+		},
+		PostExec: func(_ context.Context, ctx interface{}, _ *proxy.Stmt, _ []driver.NamedValue, _ driver.Result, err error) error {
+			return end(ctx, err)
+		},
+		PreQuery: func(c context.Context, stmt *proxy.Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return start(c, operationName(stmt.QueryString), stmt.QueryString), nil
+		},
+		PostQuery: func(_ context.Context, ctx interface{}, _ *proxy.Stmt, _ []driver.NamedValue, _ driver.Rows, err error) error {
+			return end(ctx, err)
+		},
+		PreBegin: func(c context.Context, _ *proxy.Conn) (interface{}, error) {
+			return start(c, "BEGIN", ""), nil
+		},
+		PostBegin: func(_ context.Context, ctx interface{}, _ *proxy.Conn, err error) error {
+			return end(ctx, err)
+		},
+		PreCommit: func(c context.Context, _ *proxy.Tx) (interface{}, error) {
+			return start(c, "COMMIT", ""), nil
+		},
+		PostCommit: func(_ context.Context, ctx interface{}, _ *proxy.Tx, err error) error {
+			return end(ctx, err)
+		},
+		PreRollback: func(c context.Context, _ *proxy.Tx) (interface{}, error) {
+			return start(c, "ROLLBACK", ""), nil
+		},
+		PostRollback: func(_ context.Context, ctx interface{}, _ *proxy.Tx, err error) error {
+			return end(ctx, err)
+		},
+	}
+}
+
+// pendingSpan is the span which is not started yet.
+type pendingSpan struct {
+	c         context.Context
+	operation string
+	query     string
+	start     time.Time
+}
+
+// operationName returns the first keyword of query in upper case, e.g. "SELECT".
+func operationName(query string) string {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	i := 0
+	for i < len(query) && isLetter(query[i]) {
+		i++
+	}
+	return strings.ToUpper(query[:i])
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
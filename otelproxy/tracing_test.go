@@ -0,0 +1,168 @@
+package otelproxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewOTelHooks(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	h := NewOTelHooks(tp.Tracer("test"), WithDBSystem("mysql"))
+
+	c, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	stmt := &proxy.Stmt{QueryString: "SELECT id FROM t1 WHERE id = ?"}
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+	ctx, err := h.PreQuery(c, stmt, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostQuery(c, ctx, stmt, args, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	errExec := errors.New("exec failed")
+	stmt = &proxy.Stmt{QueryString: "INSERT INTO t1 (id) VALUES(?)"}
+	ctx, err = h.PreExec(c, stmt, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostExec(c, ctx, stmt, args, nil, errExec); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("want 3 spans, got %d", len(spans))
+	}
+
+	query := spans[0]
+	if query.Name() != "SELECT" || query.SpanKind() != trace.SpanKindClient {
+		t.Errorf("unexpected span: %s %s", query.Name(), query.SpanKind())
+	}
+	if query.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("want the span to be the child of the parent span, but not")
+	}
+	attrs := attribute.NewSet(query.Attributes()...)
+	for key, want := range map[attribute.Key]string{
+		"db.system":    "mysql",
+		"db.statement": "SELECT id FROM t1 WHERE id = ?",
+		"db.operation": "SELECT",
+	} {
+		if v, _ := attrs.Value(key); v.AsString() != want {
+			t.Errorf("%s: want %q, got %q", key, want, v.AsString())
+		}
+	}
+	if query.Status().Code != codes.Unset {
+		t.Errorf("want no errors, got %v", query.Status())
+	}
+
+	exec := spans[1]
+	if exec.Name() != "INSERT" || exec.Status().Code != codes.Error || exec.Status().Description != "exec failed" {
+		t.Errorf("unexpected span: %s %v", exec.Name(), exec.Status())
+	}
+	if len(exec.Events()) != 1 || exec.Events()[0].Name != "exception" {
+		t.Errorf("want the error to be recorded, got %v", exec.Events())
+	}
+}
+
+func TestNewOTelHooks_Tx(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	h := NewOTelHooks(tp.Tracer("test"), WithoutStatement())
+
+	c := context.Background()
+	ctx, err := h.PreBegin(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostBegin(c, ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	stmt := &proxy.Stmt{QueryString: "DELETE FROM t1"}
+	ctx, err = h.PreExec(c, stmt, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostExec(c, ctx, stmt, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	ctx, err = h.PreRollback(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.PostRollback(c, ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name())
+		attrs := attribute.NewSet(span.Attributes()...)
+		if v, ok := attrs.Value("db.statement"); ok {
+			t.Errorf("want no db.statement, got %q", v.AsString())
+		}
+	}
+	if len(names) != 3 || names[0] != "BEGIN" || names[1] != "DELETE" || names[2] != "ROLLBACK" {
+		t.Errorf("want [BEGIN DELETE ROLLBACK], got %v", names)
+	}
+}
+
+// skipDriver is a driver whose connections return driver.ErrSkip for Exec,
+// so database/sql falls back to Prepare and Exec of the statement.
+type skipDriver struct{}
+
+func (skipDriver) Open(name string) (driver.Conn, error) { return skipConn{}, nil }
+
+type skipConn struct{}
+
+func (skipConn) Prepare(query string) (driver.Stmt, error) { return skipStmt{}, nil }
+func (skipConn) Close() error                              { return nil }
+func (skipConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+func (skipConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+
+type skipStmt struct{}
+
+func (skipStmt) Close() error                                    { return nil }
+func (skipStmt) NumInput() int                                   { return -1 }
+func (skipStmt) Exec(args []driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (skipStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestNewOTelHooks_ErrSkip(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	sql.Register("otelproxy-err-skip", proxy.NewProxyContext(skipDriver{}, NewOTelHooks(tp.Tracer("test"))))
+	db, err := sql.Open("otelproxy-err-skip", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("want 1 span, got %d", len(spans))
+	}
+	if spans[0].Name() != "INSERT" || spans[0].Status().Code != codes.Unset {
+		t.Errorf("unexpected span: %s %v", spans[0].Name(), spans[0].Status())
+	}
+}
@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+)
+
+// PoolSnapshot is a snapshot of the connection pool of a database
+// correlated with the statistics of the operations through it.
+type PoolSnapshot struct {
+	// Time is the time when the snapshot is taken.
+	Time time.Time
+
+	// DB is the statistics of the connection pool reported by sql.DB.Stats,
+	// e.g. WaitCount, InUse and Idle.
+	DB sql.DBStats
+
+	// Acquisitions is the number of the connections reused from the pool.
+	// It is observed by the calls of ResetSession, which database/sql makes before reusing connections.
+	Acquisitions int64
+
+	// ResetErrors is the number of the connections that failed to reset their sessions,
+	// which are discarded from the pool.
+	ResetErrors int64
+
+	// InvalidConnections is the number of the connections that are reported invalid by IsValid,
+	// which are discarded instead of returned to the pool.
+	InvalidConnections int64
+
+	// Stats is the statistics of the operations aggregated by the hooks.
+	Stats StatsSnapshot
+}
+
+// PoolMonitor correlates the statistics of the connection pool of a database with the operations through it.
+// It is created by NewPoolMonitorHooks.
+type PoolMonitor struct {
+	stats *StatsAggregator
+
+	// the counters updated atomically.
+	acquisitions int64
+	resetErrors  int64
+	invalid      int64
+}
+
+// NewPoolMonitorHooks creates new HooksContext which observes the acquisitions of the pooled connections
+// and aggregates the statistics of the operations in the same manner as NewStatsHooks,
+// and PoolMonitor which merges them with the statistics of sql.DB.
+func NewPoolMonitorHooks(opt StatsOptions) (*HooksContext, *PoolMonitor) {
+	statsHooks, stats := NewStatsHooks(opt)
+	m := &PoolMonitor{
+		stats: stats,
+	}
+	poolHooks := &HooksContext{
+		PostResetSession: func(_ context.Context, _ interface{}, _ *Conn, err error) error {
+			atomic.AddInt64(&m.acquisitions, 1)
+			if err != nil {
+				atomic.AddInt64(&m.resetErrors, 1)
+			}
+			return nil
+		},
+		PostIsValid: func(_ interface{}, _ *Conn, valid bool) error {
+			if !valid {
+				atomic.AddInt64(&m.invalid, 1)
+			}
+			return nil
+		},
+	}
+	return Compose(statsHooks, poolHooks), m
+}
+
+// Snapshot returns a snapshot of the connection pool of db and the operations observed by the hooks.
+// db should be opened with the proxy that the hooks are installed in.
+func (m *PoolMonitor) Snapshot(db *sql.DB) PoolSnapshot {
+	return PoolSnapshot{
+		Time:               time.Now(),
+		DB:                 db.Stats(),
+		Acquisitions:       atomic.LoadInt64(&m.acquisitions),
+		ResetErrors:        atomic.LoadInt64(&m.resetErrors),
+		InvalidConnections: atomic.LoadInt64(&m.invalid),
+		Stats:              m.stats.Snapshot(),
+	}
+}
+
+// Watch calls f with a snapshot of db every interval, e.g. to export the gauges of the pool to monitoring systems.
+// It blocks until c is canceled, and returns the error of c.
+func (m *PoolMonitor) Watch(c context.Context, db *sql.DB, interval time.Duration, f func(PoolSnapshot)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Done():
+			return c.Err()
+		case <-ticker.C:
+			f(m.Snapshot(db))
+		}
+	}
+}
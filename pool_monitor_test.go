@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestNewPoolMonitorHooks(t *testing.T) {
+	hooks, monitor := NewPoolMonitorHooks(StatsOptions{})
+	sql.Register("fakedb-pool-monitor", NewProxyContext(fdriver, hooks))
+	db, err := sql.Open("fakedb-pool-monitor", `{"Name":"pool-monitor","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?)", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot := monitor.Snapshot(db)
+	if snapshot.DB.OpenConnections != 1 || snapshot.DB.Idle != 1 || snapshot.DB.InUse != 0 {
+		t.Errorf("unexpected pool stats: %#v", snapshot.DB)
+	}
+	if snapshot.Acquisitions != 2 {
+		t.Errorf("want 2 acquisitions, got %d", snapshot.Acquisitions)
+	}
+	if snapshot.ResetErrors != 0 || snapshot.InvalidConnections != 0 {
+		t.Errorf("want no discarded connections, got %#v", snapshot)
+	}
+	if n := snapshot.Stats.Operations[OpExec].Count; n != 3 {
+		t.Errorf("want 3 Exec, got %d", n)
+	}
+}
+
+func TestPoolMonitor_Watch(t *testing.T) {
+	hooks, monitor := NewPoolMonitorHooks(StatsOptions{})
+	sql.Register("fakedb-pool-monitor-watch", NewProxyContext(fdriver, hooks))
+	db, err := sql.Open("fakedb-pool-monitor-watch", `{"Name":"pool-monitor-watch","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	c, cancel := context.WithCancel(context.Background())
+	var snapshots []PoolSnapshot
+	err = monitor.Watch(c, db, time.Millisecond, func(s PoolSnapshot) {
+		snapshots = append(snapshots, s)
+		if len(snapshots) == 2 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+	if len(snapshots) != 2 || !snapshots[0].Time.Before(snapshots[1].Time) {
+		t.Errorf("unexpected snapshots: %v", snapshots)
+	}
+}
@@ -0,0 +1,134 @@
+// Package promproxy integrates go-sql-proxy with Prometheus.
+package promproxy
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	proxy "github.com/shogo82148/go-sql-proxy"
+)
+
+// Option configures the hooks created by NewPrometheusHooks.
+type Option func(*config)
+
+type config struct {
+	namespace      string
+	constLabels    prometheus.Labels
+	buckets        []float64
+	fingerprint    bool
+	labels         []string
+	maxLabelValues int
+}
+
+// WithNamespace sets the namespace of the metrics, e.g. "myapp" for "myapp_sql_operations_total".
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels adds the labels to all the metrics, e.g. the name of the database.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// WithBuckets sets the buckets of the histogram of the durations in seconds.
+// The default is prometheus.DefBuckets.
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// WithFingerprint adds the "query" label, which is the proxy.Fingerprint of the query
+// for Prepare, Exec and Query, and empty for the other operations.
+// Beware of the cardinality of the metrics if the application builds the queries dynamically.
+func WithFingerprint() Option {
+	return func(c *config) {
+		c.fingerprint = true
+	}
+}
+
+// WithLabels adds the labels associated with the contexts by proxy.WithLabels to the metrics, e.g. "tenant".
+// The keys must be valid label names of Prometheus, and must not conflict with the other labels.
+// The values of the missing labels are empty.
+// The number of the distinct values per key is limited by WithMaxLabelValues.
+func WithLabels(keys ...string) Option {
+	return func(c *config) {
+		c.labels = append(c.labels, keys...)
+	}
+}
+
+// WithMaxLabelValues sets the maximum number of the distinct values per key of the labels added by WithLabels.
+// The other values are reported as proxy.OverflowLabelValue.
+// The default is 100.
+func WithMaxLabelValues(n int) Option {
+	return func(c *config) {
+		c.maxLabelValues = n
+	}
+}
+
+// NewPrometheusHooks creates new HooksContext which maintains the metrics of the operations,
+// and registers them to reg:
+//
+//   - sql_operations_total: the counter of the operations
+//   - sql_operation_duration_seconds: the histogram of the durations of the operations
+//
+// The metrics have the "operation" label, e.g. "Exec" and "Commit",
+// and the "status" label, which is "ok" or "error".
+func NewPrometheusHooks(reg prometheus.Registerer, opts ...Option) (*proxy.HooksContext, error) {
+	cfg := &config{
+		buckets: prometheus.DefBuckets,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	labels := []string{"operation", "status"}
+	if cfg.fingerprint {
+		labels = append(labels, "query")
+	}
+	limiter := proxy.NewLabelLimiter(cfg.labels, cfg.maxLabelValues)
+	labels = append(labels, cfg.labels...)
+
+	total := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   cfg.namespace,
+		Name:        "sql_operations_total",
+		Help:        "Total number of SQL operations.",
+		ConstLabels: cfg.constLabels,
+	}, labels)
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   cfg.namespace,
+		Name:        "sql_operation_duration_seconds",
+		Help:        "Duration of SQL operations in seconds.",
+		ConstLabels: cfg.constLabels,
+		Buckets:     cfg.buckets,
+	}, labels)
+	if err := reg.Register(total); err != nil {
+		return nil, err
+	}
+	if err := reg.Register(duration); err != nil {
+		reg.Unregister(total)
+		return nil, err
+	}
+
+	return proxy.NewEventHooks(func(c context.Context, e *proxy.Event) {
+		status := "ok"
+		if e.Error != "" {
+			status = "error"
+		}
+		values := []string{e.Operation.String(), status}
+		if cfg.fingerprint {
+			var query string
+			switch e.Operation {
+			case proxy.OpPrepare, proxy.OpExec, proxy.OpQuery:
+				query = proxy.Fingerprint(e.Query)
+			}
+			values = append(values, query)
+		}
+		values = append(values, limiter.Values(e.Labels)...)
+		total.WithLabelValues(values...).Inc()
+		duration.WithLabelValues(values...).Observe(e.Duration.Seconds())
+	}), nil
+}
@@ -0,0 +1,106 @@
+package promproxy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	proxy "github.com/shogo82148/go-sql-proxy"
+)
+
+func exec(t *testing.T, h *proxy.HooksContext, query string, err error) {
+	t.Helper()
+	c := context.Background()
+	stmt := &proxy.Stmt{QueryString: query}
+	ctx, e := h.PreExec(c, stmt, nil)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e := h.PostExec(c, ctx, stmt, nil, nil, err); e != nil {
+		t.Fatal(e)
+	}
+}
+
+func TestNewPrometheusHooks(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h, err := NewPrometheusHooks(reg, WithNamespace("test"), WithConstLabels(prometheus.Labels{"db": "main"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(t, h, "INSERT INTO t1 (id) VALUES(1)", nil)
+	exec(t, h, "INSERT INTO t1 (id) VALUES(2)", nil)
+	exec(t, h, "INSERT INTO t1 (id) VALUES(3)", errors.New("exec failed"))
+
+	want := `
+# HELP test_sql_operations_total Total number of SQL operations.
+# TYPE test_sql_operations_total counter
+test_sql_operations_total{db="main",operation="Exec",status="error"} 1
+test_sql_operations_total{db="main",operation="Exec",status="ok"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "test_sql_operations_total"); err != nil {
+		t.Error(err)
+	}
+	if n, err := testutil.GatherAndCount(reg, "test_sql_operation_duration_seconds"); err != nil || n != 2 {
+		t.Errorf("want 2 histograms, got %d, %v", n, err)
+	}
+
+	// the metrics are already registered.
+	if _, err := NewPrometheusHooks(reg, WithNamespace("test"), WithConstLabels(prometheus.Labels{"db": "main"})); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestNewPrometheusHooks_Fingerprint(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h, err := NewPrometheusHooks(reg, WithFingerprint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec(t, h, "INSERT INTO t1 (id) VALUES(1)", nil)
+	exec(t, h, "INSERT INTO t1 (id) VALUES(2)", nil)
+
+	want := `
+# HELP sql_operations_total Total number of SQL operations.
+# TYPE sql_operations_total counter
+sql_operations_total{operation="Exec",query="insert into t1(id) values(?)",status="ok"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "sql_operations_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewPrometheusHooks_Labels(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h, err := NewPrometheusHooks(reg, WithLabels("tenant"), WithMaxLabelValues(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := &proxy.Stmt{QueryString: "INSERT INTO t1 (id) VALUES(1)"}
+	for _, tenant := range []string{"acme", "acme", "globex", ""} {
+		c := context.Background()
+		if tenant != "" {
+			c = proxy.WithLabel(c, "tenant", tenant)
+		}
+		ctx, err := h.PreExec(c, stmt, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := h.PostExec(c, ctx, stmt, nil, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := `
+# HELP sql_operations_total Total number of SQL operations.
+# TYPE sql_operations_total counter
+sql_operations_total{operation="Exec",status="ok",tenant=""} 1
+sql_operations_total{operation="Exec",status="ok",tenant="_other"} 1
+sql_operations_total{operation="Exec",status="ok",tenant="acme"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "sql_operations_total"); err != nil {
+		t.Error(err)
+	}
+}
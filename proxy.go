@@ -5,6 +5,10 @@ package proxy
 import (
 	"context"
 	"database/sql/driver"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
 )
 
 // namedValueChecker is the same as driver.NamedValueChecker.
@@ -21,89 +25,429 @@ type namedValueChecker interface {
 type Proxy struct {
 	Driver driver.Driver
 	hooks  hooks
+	stats  proxyStats
+
+	// the hooks replaced by Proxy.SetHooks. They take precedence over hooks.
+	swapped atomic.Value // of *hooksValue
+
+	// the operations that skip the hooks. See Proxy.WithOperations.
+	disabledOps OperationSet
+
+	// the options of Proxy.WithHookTiming.
+	hookTiming *HookTimingOptions
+
+	// the interceptors installed by Proxy.WithInterceptors.
+	interceptors Interceptors
+
+	// the policy of the errors returned by the Post hooks. See Proxy.WithErrorPolicy.
+	errorPolicy ErrorPolicy
+
+	// the options of Proxy.WithProxyError.
+	proxyError *ProxyErrorOptions
+
+	// the generator of the connection IDs. See WithConnIDGenerator.
+	connIDGen func() int64
+
+	// the hook sets routed by the data source names. See Proxy.WithDSNHooks.
+	dsnRoutes []dsnRoute
 }
 
 // NewProxy creates new Proxy driver.
 // Deprecated: You should use NewProxyContext instead.
 func NewProxy(driver driver.Driver, hs ...*Hooks) *Proxy {
-	switch {
-	case len(hs) == 0:
-		return &Proxy{
-			Driver: driver,
-		}
-	case len(hs) == 1 && hs[0] != nil:
-		return &Proxy{
-			Driver: driver,
-			hooks:  hs[0],
+	hooksSlice := make([]hooks, 0, len(hs))
+	for _, hk := range hs {
+		if hk != nil {
+			hooksSlice = append(hooksSlice, hk)
 		}
 	}
+	return newProxy(driver, hooksSlice)
+}
 
+// NewProxyContext creates new Proxy driver.
+// The hook sets are called in the order of their priorities, and then in the order of hs.
+// If driver is also a Proxy, the hook sets already installed in it are not installed again.
+func NewProxyContext(driver driver.Driver, hs ...*HooksContext) *Proxy {
 	hooksSlice := make([]hooks, 0, len(hs))
 	for _, hk := range hs {
 		if hk != nil {
 			hooksSlice = append(hooksSlice, hk)
 		}
 	}
-	return &Proxy{
-		Driver: driver,
-		hooks:  multipleHooks(hooksSlice),
+	return newProxy(driver, hooksSlice)
+}
+
+// TryNewProxyContext is the same as NewProxyContext,
+// but returns ErrNilDriver instead of panicking when driver is nil.
+func TryNewProxyContext(driver driver.Driver, hs ...*HooksContext) (*Proxy, error) {
+	if isNil(driver) {
+		return nil, ErrNilDriver
 	}
+	return NewProxyContext(driver, hs...), nil
 }
 
-// NewProxyContext creates new Proxy driver.
-func NewProxyContext(driver driver.Driver, hs ...*HooksContext) *Proxy {
-	switch {
-	case len(hs) == 0:
+func newProxy(driver driver.Driver, hs []hooks) *Proxy {
+	if isNil(driver) {
+		// fail fast, otherwise it panics on first use with an obscure message.
+		panic(ErrNilDriver)
+	}
+	hs = dedupHooks(driver, hs)
+	sortHooks(hs)
+	switch len(hs) {
+	case 0:
 		return &Proxy{
 			Driver: driver,
 		}
-	case len(hs) == 1 && hs[0] != nil:
+	case 1:
 		return &Proxy{
 			Driver: driver,
 			hooks:  hs[0],
 		}
 	}
+	return &Proxy{
+		Driver: driver,
+		hooks:  multipleHooks(hs),
+	}
+}
 
+// With returns a new Proxy that shares the underlying driver with p.
+// The new proxy calls the hooks of p first, and then calls hs,
+// unless the priorities of the hook sets say otherwise.
+// p is not modified.
+func (p *Proxy) With(hs ...*HooksContext) *Proxy {
 	hooksSlice := make([]hooks, 0, len(hs))
 	for _, hk := range hs {
 		if hk != nil {
 			hooksSlice = append(hooksSlice, hk)
 		}
 	}
+	np := p.clone()
+	if np.hookTiming != nil {
+		for i, hk := range hooksSlice {
+			hooksSlice[i] = np.timeHooks(hk)
+		}
+	}
+	np.hooks = appendHooks(np.hooks, hooksSlice...)
+	return np
+}
+
+// clone returns a new Proxy that has the same driver, hooks and options as p, but not the statistics.
+// The methods deriving a new proxy from p call it, and then change only their own fields.
+func (p *Proxy) clone() *Proxy {
 	return &Proxy{
-		Driver: driver,
-		hooks:  multipleHooks(hooksSlice),
+		Driver:       p.Driver,
+		hooks:        p.loadHooks(),
+		disabledOps:  p.disabledOps,
+		hookTiming:   p.hookTiming,
+		interceptors: p.interceptors,
+		errorPolicy:  p.errorPolicy,
+		proxyError:   p.proxyError,
+		connIDGen:    p.connIDGen,
+		dsnRoutes:    p.dsnRoutes,
+	}
+}
+
+// SetHooks replaces the hook sets installed in the proxy with hs.
+// It is safe to call it concurrently with the operations on the proxy,
+// so the hooks can be reconfigured without recreating the driver and sql.DB.
+// The operations in progress keep using the old hook sets.
+// The proxies created from p by With and the other methods are not affected.
+func (p *Proxy) SetHooks(hs ...*HooksContext) {
+	hooksSlice := make([]hooks, 0, len(hs))
+	for _, hk := range hs {
+		if hk != nil {
+			hooksSlice = append(hooksSlice, hk)
+		}
+	}
+	h := newProxy(p.Driver, hooksSlice).hooks
+	if p.hookTiming != nil {
+		h = p.timeHooks(h)
+	}
+	p.swapped.Store(&hooksValue{hooks: h})
+}
+
+// hooksValue is a box for storing hooks into atomic.Value.
+type hooksValue struct {
+	hooks hooks
+}
+
+// loadHooks returns the hook sets installed in the proxy.
+func (p *Proxy) loadHooks() hooks {
+	if v, ok := p.swapped.Load().(*hooksValue); ok {
+		return v.hooks
+	}
+	return p.hooks
+}
+
+// appendHooks returns the hooks that call base first, and then call hs.
+func appendHooks(base hooks, hs ...hooks) hooks {
+	if len(hs) == 0 {
+		return base
+	}
+	if base == nil && len(hs) == 1 {
+		return hs[0]
+	}
+
+	var hooksSlice []hooks
+	if h, ok := base.(multipleHooks); ok {
+		hooksSlice = make([]hooks, 0, len(h)+len(hs))
+		hooksSlice = append(hooksSlice, h...)
+	} else if base != nil {
+		hooksSlice = make([]hooks, 0, len(hs)+1)
+		hooksSlice = append(hooksSlice, base)
+	} else {
+		hooksSlice = make([]hooks, 0, len(hs))
+	}
+	hooksSlice = append(hooksSlice, hs...)
+	sortHooks(hooksSlice)
+	return multipleHooks(hooksSlice)
+}
+
+// hookPrioritizer is implemented by the hook sets that have priorities.
+type hookPrioritizer interface {
+	priority() int
+}
+
+func hooksPriority(h hooks) int {
+	if p, ok := h.(hookPrioritizer); ok {
+		return p.priority()
 	}
+	return 0
 }
 
-func (p *Proxy) getHooks(ctx context.Context) hooks {
+// sortHooks sorts hs by their priorities in descending order, so the hook sets with higher priorities are outer.
+// The order of the hook sets that have the same priority is kept.
+func sortHooks(hs []hooks) {
+	sort.SliceStable(hs, func(i, j int) bool {
+		return hooksPriority(hs[i]) > hooksPriority(hs[j])
+	})
+}
+
+// WithOperations returns a new Proxy that calls the hooks only for ops.
+// The other operations skip the hooks entirely, including their setup,
+// so it reduces the overhead of the operations that no hooks are interested in.
+// The hooks associated with contexts by WithHooks are also skipped.
+// p is not modified.
+func (p *Proxy) WithOperations(ops ...Operation) *Proxy {
+	np := p.clone()
+	np.disabledOps = ^NewOperationSet(ops...)
+	return np
+}
+
+// connHooks is the hook sets of a connection.
+// The hook sets of the proxy and the hook set routed by the data source name are merged once per connection,
+// so the operations don't merge and sort them every time.
+type connHooks struct {
+	// routed is the hook set routed by the data source name. See Proxy.WithDSNHooks.
+	routed hooks
+
+	merged atomic.Value // of *mergedHooks
+}
+
+// mergedHooks is the hook sets merged for a connection.
+type mergedHooks struct {
+	// src is the hook sets installed by SetHooks which base is built from.
+	// It is nil if base is built from Proxy.hooks.
+	src *hooksValue
+
+	// base is the hook sets of the proxy and the routed hook set.
+	base hooks
+
+	// bus is base and the hooks of the event bus.
+	bus hooks
+
+	// appended is the hook sets appended by AppendHooks, and withAppended is the hook sets merged with them.
+	// They are cached for the last context, because the operations of a request usually share it.
+	appended     multipleHooks
+	withAppended *mergedHooks
+}
+
+func newMergedHooks(base hooks) *mergedHooks {
+	return &mergedHooks{
+		base: base,
+		bus:  appendHooks(base, defaultEventBus.hooks),
+	}
+}
+
+// hooks returns the merged hook sets, and the hooks of the event bus if it has any subscribers.
+func (m *mergedHooks) hooks() hooks {
+	if defaultEventBus.active() {
+		return m.bus
+	}
+	return m.base
+}
+
+// mergeHooks returns the hook sets of the proxy merged with the routed hook set of ch.
+// ch may be nil, e.g. the connection is not opened by a Proxy.
+func (p *Proxy) mergeHooks(ch *connHooks) *mergedHooks {
+	v, _ := p.swapped.Load().(*hooksValue)
+	if ch != nil {
+		if m, _ := ch.merged.Load().(*mergedHooks); m != nil && m.src == v {
+			return m
+		}
+	}
+	base := p.hooks
+	if v != nil {
+		base = v.hooks
+	}
+	if ch != nil && ch.routed != nil {
+		base = appendHooks(base, ch.routed)
+	}
+	m := newMergedHooks(base)
+	m.src = v
+	if ch != nil {
+		ch.merged.Store(m)
+	}
+	return m
+}
+
+// appendContextHooks returns the hook sets of m merged with hs, the hook sets appended by AppendHooks.
+func (m *mergedHooks) appendContextHooks(ch *connHooks, hs multipleHooks) *mergedHooks {
+	if m.withAppended != nil && len(m.appended) == len(hs) && &m.appended[0] == &hs[0] {
+		return m.withAppended
+	}
+	appended := newMergedHooks(appendHooks(m.base, hs...))
+	if ch != nil {
+		ch.merged.Store(&mergedHooks{
+			src:          m.src,
+			base:         m.base,
+			bus:          m.bus,
+			appended:     hs,
+			withAppended: appended,
+		})
+	}
+	return appended
+}
+
+// hooksFor returns the hooks of the proxy for op, executed on the connection that has ch.
+// It returns nil if op is disabled by WithOperations.
+func (p *Proxy) hooksFor(op Operation, ch *connHooks) hooks {
+	if p.disabledOps.Contains(op) {
+		return nil
+	}
+	return p.mergeHooks(ch).hooks()
+}
+
+// baseHooks returns the hooks of the proxy and the routed hook set of ch, without the event bus.
+func (p *Proxy) baseHooks(ch *connHooks) hooks {
+	return p.mergeHooks(ch).base
+}
+
+func (p *Proxy) getHooks(ctx context.Context, op Operation, ch *connHooks) hooks {
+	if p.disabledOps.Contains(op) {
+		return nil
+	}
+	if skip, _ := ctx.Value(contextSkipHooksKey{}).(bool); skip {
+		return nil
+	}
+	appended, _ := ctx.Value(contextAppendedHooksKey{}).(multipleHooks)
 	if h, ok := ctx.Value(contextHooksKey{}).(hooks); ok {
 		// Make the caller nil check easy.
 		if h == (*Hooks)(nil) || h == (*HooksContext)(nil) {
-			return nil
+			h = nil
 		}
-		return h
+		// the hooks associated with the context replace the hooks of the proxy,
+		// so they are not cached in the connection.
+		return withEventBus(appendHooks(h, appended...))
 	}
-	return p.hooks
+	m := p.mergeHooks(ch)
+	if len(appended) > 0 {
+		m = m.appendContextHooks(ch, appended)
+	}
+	return m.hooks()
+}
+
+// notifyCanceled calls the OnCanceled hook if the operation op failed
+// because c is canceled or its deadline is exceeded.
+func notifyCanceled(c context.Context, hooks hooks, op Operation, start time.Time, err error) {
+	if err == nil || c.Err() == nil {
+		return
+	}
+	hooks.onCanceled(c, op, time.Since(start), c.Err())
+}
+
+// HookSetInfo describes a hook set installed in a Proxy.
+type HookSetInfo struct {
+	// Name is the name of the hook set.
+	// It is empty if the hook set has no name.
+	Name string
+
+	// Kind is the kind of the hook set, e.g. "HooksContext" or "Hooks".
+	Kind string
+
+	// Value is the hook set itself, e.g. *HooksContext or *Hooks.
+	Value interface{}
+}
+
+// hookSetDescriber is implemented by the hook sets that can describe themselves.
+type hookSetDescriber interface {
+	describe() []HookSetInfo
+}
+
+func describeHooks(h hooks) []HookSetInfo {
+	if h == nil {
+		return nil
+	}
+	if d, ok := h.(hookSetDescriber); ok {
+		return d.describe()
+	}
+	return []HookSetInfo{{
+		Kind:  fmt.Sprintf("%T", h),
+		Value: h,
+	}}
+}
+
+// Hooks returns the descriptions of the hook sets installed in the proxy,
+// in the order they are called.
+// The hooks associated with contexts by WithHooks are not included.
+func (p *Proxy) Hooks() []HookSetInfo {
+	return describeHooks(p.loadHooks())
+}
+
+// LookupHooks returns the first hook set installed in the proxy that has the name.
+func (p *Proxy) LookupHooks(name string) (HookSetInfo, bool) {
+	for _, info := range p.Hooks() {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return HookSetInfo{}, false
 }
 
 // Open creates new connection which is wrapped by Conn.
 // It will triggers PreOpen, Open, PostOpen hooks.
-func (p *Proxy) Open(name string) (driver.Conn, error) {
+func (p *Proxy) Open(name string) (ret driver.Conn, err error) {
 	c := context.Background()
-	var err error
 	var ctx interface{}
 	var conn driver.Conn
 	var myconn *Conn
+	start := time.Now()
+	defer p.stats.observe(OpOpen, start, &err)
+	defer func() {
+		if err == nil {
+			p.stats.countOpen(myconn)
+		}
+	}()
+	ch := &connHooks{routed: p.routeDSN(name)}
+	hooks := p.hooksFor(OpOpen, ch)
+
+	if hooks != nil {
+		if name, err = hooks.rewriteDSN(c, name); err != nil {
+			return nil, err
+		}
 
-	if p.hooks != nil {
 		// Setup PostOpen. This needs to be a closure like this
 		// or otherwise changes to the `ctx` and `conn` parameters
 		// within this Open() method does not get applied at the
 		// time defer is fired
-		defer func() { p.hooks.postOpen(c, ctx, myconn, err) }()
+		defer func() {
+			if p.postError(&err, hooks.postOpen(withDuration(c, start), ctx, myconn, err)) {
+				conn.Close()
+				ret = nil
+			}
+		}()
 
-		if ctx, err = p.hooks.preOpen(c, name); err != nil {
+		if ctx, err = hooks.preOpen(c, name); err != nil {
 			return nil, err
 		}
 	}
@@ -112,13 +456,12 @@ func (p *Proxy) Open(name string) (driver.Conn, error) {
 		return nil, err
 	}
 
-	myconn = &Conn{
-		Conn:  conn,
-		Proxy: p,
-	}
+	myconn = newConn(conn, p)
+	myconn.hooks = ch
 
-	if p.hooks != nil {
-		if err = p.hooks.open(c, ctx, myconn); err != nil {
+	if hooks != nil {
+		c = myconn.withMetadata(c)
+		if err = hooks.open(c, ctx, myconn); err != nil {
 			conn.Close()
 			return nil, err
 		}
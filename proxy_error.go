@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// ProxyError describes an operation that failed.
+// The proxies created by Proxy.WithProxyError return it instead of the original errors.
+type ProxyError struct {
+	// Op is the operation that failed.
+	Op Operation
+
+	// Query is the query of the operation. It is empty for the operations without queries, e.g. Begin.
+	Query string
+
+	// Args is the arguments of the query.
+	// It is nil unless ProxyErrorOptions.IncludeArgs is true.
+	Args []driver.NamedValue
+
+	// ConnID is the ID of the connection that executed the operation. See Conn.ID.
+	ConnID int64
+
+	// Duration is the duration of the operation.
+	Duration time.Duration
+
+	// Err is the original error.
+	Err error
+}
+
+func (e *ProxyError) Error() string {
+	if e.Query == "" {
+		return fmt.Sprintf("proxy: %s failed on conn %d after %s: %v", e.Op, e.ConnID, e.Duration, e.Err)
+	}
+	return fmt.Sprintf("proxy: %s %q failed on conn %d after %s: %v", e.Op, e.Query, e.ConnID, e.Duration, e.Err)
+}
+
+// Unwrap returns the original error, so errors.Is and errors.As reach it.
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+// ProxyErrorOptions holds the options of Proxy.WithProxyError.
+type ProxyErrorOptions struct {
+	// IncludeArgs makes ProxyError include the arguments of the query.
+	// The arguments are not included by default, because they may contain sensitive data.
+	IncludeArgs bool
+
+	// ValueFormatter formats the values of the arguments.
+	// If it is set, the values are replaced with the formatted strings,
+	// e.g. RedactedValueFormatter hides them.
+	ValueFormatter ValueFormatter
+
+	// RedactArgs formats the values of the arguments by RedactedValueFormatter.
+	// The names and the ordinals of the arguments are kept.
+	// It overrides ValueFormatter.
+	RedactArgs bool
+
+	// MaxArgLength is the maximum length of the formatted arguments in bytes.
+	// The longer arguments are elided with an ellipsis and their lengths.
+	// If it is zero, the arguments are not elided.
+	MaxArgLength int
+}
+
+// WithProxyError returns a new Proxy that wraps the errors of the Ping, Prepare, Exec, Query, Begin, Commit and Rollback
+// operations in ProxyError.
+// driver.ErrSkip and driver.ErrBadConn are returned as is, because database/sql compares them directly.
+// p is not modified.
+func (p *Proxy) WithProxyError(opt ProxyErrorOptions) *Proxy {
+	np := p.clone()
+	np.proxyError = &opt
+	return np
+}
+
+// wrapError wraps *err in ProxyError if p is created by WithProxyError.
+func (p *Proxy) wrapError(err *error, op Operation, conn *Conn, query string, args []driver.NamedValue, start time.Time) {
+	opt := p.proxyError
+	if opt == nil || *err == nil || *err == driver.ErrSkip || *err == driver.ErrBadConn {
+		return
+	}
+	perr := &ProxyError{
+		Op:       op,
+		Query:    query,
+		Duration: time.Since(start),
+		Err:      *err,
+	}
+	if conn != nil {
+		perr.ConnID = conn.id
+	}
+	if opt.IncludeArgs {
+		vf := opt.ValueFormatter
+		if opt.RedactArgs {
+			vf = RedactedValueFormatter
+		}
+		perr.Args = formatNamedValues(args, vf, opt.MaxArgLength)
+	}
+	*err = perr
+}
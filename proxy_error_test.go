@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestWithProxyError(t *testing.T) {
+	errDriver := errors.New("driver error")
+	newTestConn := func(opt ProxyErrorOptions, err error) *Conn {
+		p := NewProxyContext(fdriver).WithInterceptors(Interceptors{
+			Exec: func(next ExecFunc) ExecFunc {
+				return func(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Result, error) {
+					return nil, err
+				}
+			},
+		}).WithProxyError(opt)
+		return newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+	}
+	args := []driver.NamedValue{{Name: "id", Ordinal: 1, Value: int64(1)}}
+	query := "INSERT INTO t1 (id) VALUES(?)"
+
+	t.Run("default", func(t *testing.T) {
+		conn := newTestConn(ProxyErrorOptions{}, errDriver)
+		_, err := conn.ExecContext(context.Background(), query, args)
+		perr, ok := err.(*ProxyError)
+		if !ok || perr.Err != errDriver {
+			t.Fatalf("want ProxyError of %v, got %v", errDriver, err)
+		}
+		if perr.Op != OpExec || perr.Query != query || perr.ConnID != conn.ID() || perr.Args != nil {
+			t.Errorf("unexpected error: %#v", perr)
+		}
+		re := regexp.MustCompile(`^proxy: Exec "INSERT INTO t1 \(id\) VALUES\(\?\)" failed on conn \d+ after \S+: driver error$`)
+		if !re.MatchString(err.Error()) {
+			t.Errorf("unexpected message: %s", err.Error())
+		}
+	})
+
+	t.Run("redacted args", func(t *testing.T) {
+		conn := newTestConn(ProxyErrorOptions{IncludeArgs: true, RedactArgs: true}, errDriver)
+		_, err := conn.ExecContext(context.Background(), query, args)
+		perr, ok := err.(*ProxyError)
+		if !ok {
+			t.Fatalf("want ProxyError, got %T", err)
+		}
+		if want := []driver.NamedValue{{Name: "id", Ordinal: 1, Value: "<redacted>"}}; !reflect.DeepEqual(perr.Args, want) {
+			t.Errorf("want %v, got %v", want, perr.Args)
+		}
+		if args[0].Value != int64(1) {
+			t.Error("the original arguments must not be modified")
+		}
+	})
+
+	t.Run("formatted args", func(t *testing.T) {
+		conn := newTestConn(ProxyErrorOptions{IncludeArgs: true, ValueFormatter: SQLLiteralValueFormatter, MaxArgLength: 4}, errDriver)
+		longArgs := []driver.NamedValue{{Ordinal: 1, Value: "it's long"}}
+		_, err := conn.ExecContext(context.Background(), query, longArgs)
+		perr, ok := err.(*ProxyError)
+		if !ok {
+			t.Fatalf("want ProxyError, got %T", err)
+		}
+		if want := []driver.NamedValue{{Ordinal: 1, Value: "'it'... (12 bytes)"}}; !reflect.DeepEqual(perr.Args, want) {
+			t.Errorf("want %v, got %v", want, perr.Args)
+		}
+	})
+
+	t.Run("bad conn", func(t *testing.T) {
+		conn := newTestConn(ProxyErrorOptions{}, driver.ErrBadConn)
+		if _, err := conn.ExecContext(context.Background(), query, args); err != driver.ErrBadConn {
+			t.Errorf("want %v, got %v", driver.ErrBadConn, err)
+		}
+	})
+}
@@ -528,3 +528,32 @@ func TestFakeDBCtx(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterProxyFor_DriverContext(t *testing.T) {
+	// fakedbctx rejects the empty data source name,
+	// so the driver must be resolved by the one passed to sql.Open.
+	var pings int
+	if err := RegisterProxyFor("fakedbctx", "fakedbctx-proxy-for", &HooksContext{
+		PostPing: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			pings++
+			return nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("fakedbctx-proxy-for", `{"Name":"proxy-for-ctx","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.PingContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if pings != 1 {
+		t.Errorf("want 1 PostPing call, got %d", pings)
+	}
+
+	if err := RegisterProxyFor("unknown-driver", "unknown-driver-proxy-for"); err == nil {
+		t.Error("want error, got nil")
+	}
+}
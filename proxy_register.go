@@ -2,6 +2,9 @@ package proxy
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -9,16 +12,55 @@ import (
 // and registers the proxies as sql driver.
 // Use `proxy.WithHooks(ctx, hooks)` to hook query execution.
 // The proxies' names have suffix ":proxy".
+// The underlying drivers are resolved on first use, see NewProxyByName.
 func RegisterProxy() {
 	for _, driver := range sql.Drivers() {
 		if strings.HasSuffix(driver, ":trace") || strings.HasSuffix(driver, ":proxy") {
 			continue
 		}
-		db, err := sql.Open(driver, "")
-		if err != nil {
-			continue
-		}
-		defer db.Close()
-		sql.Register(driver+":proxy", NewProxyContext(db.Driver()))
+		sql.Register(driver+":proxy", NewProxyByName(driver))
+	}
+}
+
+// RegisterProxyFor creates a proxy of the sql driver registered as driverName,
+// and registers the proxy as the sql driver named newName.
+// Unlike RegisterProxy, it wraps exactly one driver, and the proxy calls hs.
+// The underlying driver is resolved on first use, see NewProxyByName.
+// It returns an error if driverName is not registered.
+// Like sql.Register, it panics if newName is already registered.
+func RegisterProxyFor(driverName, newName string, hs ...*HooksContext) error {
+	if !isRegistered(driverName) {
+		return unknownDriverError(driverName)
+	}
+	sql.Register(newName, NewProxyByName(driverName, hs...))
+	return nil
+}
+
+// isRegistered reports whether the sql driver is registered as name.
+func isRegistered(name string) bool {
+	drivers := sql.Drivers()
+	i := sort.SearchStrings(drivers, name)
+	return i < len(drivers) && drivers[i] == name
+}
+
+func unknownDriverError(name string) error {
+	return fmt.Errorf("proxy: unknown driver %q (forgotten import?)", name)
+}
+
+// lookupDriver returns the sql driver registered as name.
+// dsn is passed to the driver as is, so the driver doesn't see a fake data source name.
+//
+// database/sql doesn't export the registered drivers,
+// but sql.Open returns them without connecting to the database.
+// If the driver implements driver.DriverContext, sql.Open calls its OpenConnector with dsn,
+// and the connector is closed soon, because database/sql doesn't export it either.
+// So OpenConnector of the driver is called twice with dsn on the first use of a lazy driver:
+// once by lookupDriver, and once more by the proxy.
+func lookupDriver(name, dsn string) (driver.Driver, error) {
+	db, err := sql.Open(name, dsn)
+	if err != nil {
+		return nil, err
 	}
+	defer db.Close()
+	return db.Driver(), nil
 }
@@ -42,3 +42,26 @@ func ExampleRegisterProxy() {
 		log.Fatal(err)
 	}
 }
+
+func ExampleRegisterProxyFor() {
+	err := proxy.RegisterProxyFor("fakedb", "fakedb-proxy-for", &proxy.HooksContext{
+		Ping: func(c context.Context, ctx interface{}, conn *proxy.Conn) error {
+			fmt.Println("Ping")
+			return nil
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := sql.Open("fakedb-proxy-for", `{"name":"proxy-for"}`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.PingContext(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// Ping
+}
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestFakeDB(t *testing.T) {
@@ -541,3 +543,207 @@ func TestFakeDB(t *testing.T) {
 		})
 	}
 }
+
+func TestProxyHooks(t *testing.T) {
+	stats := &HooksContext{Name: "stats"}
+	tracer := &HooksContext{}
+	p := NewProxyContext(fdriver, stats, tracer)
+
+	infos := p.Hooks()
+	if len(infos) != 2 {
+		t.Fatalf("want 2 hook sets, got %d", len(infos))
+	}
+	if infos[0].Name != "stats" || infos[0].Kind != "HooksContext" || infos[0].Value != stats {
+		t.Errorf("unexpected hook set info: %#v", infos[0])
+	}
+	if infos[1].Name != "" || infos[1].Kind != "HooksContext" || infos[1].Value != tracer {
+		t.Errorf("unexpected hook set info: %#v", infos[1])
+	}
+
+	info, ok := p.LookupHooks("stats")
+	if !ok {
+		t.Fatal("want to find the stats hooks, but not")
+	}
+	if info.Value != stats {
+		t.Errorf("want %p, got %p", stats, info.Value)
+	}
+	if _, ok := p.LookupHooks("unknown"); ok {
+		t.Error("want not to find unknown hooks, but found")
+	}
+
+	if infos := NewProxyContext(fdriver).Hooks(); len(infos) != 0 {
+		t.Errorf("want no hook sets, got %#v", infos)
+	}
+}
+
+func TestProxyWith(t *testing.T) {
+	base := &HooksContext{Name: "base"}
+	audit := &HooksContext{Name: "audit"}
+	p := NewProxyContext(fdriver, base)
+	derived := p.With(audit)
+
+	if derived.Driver != p.Driver {
+		t.Errorf("want the driver shared, but not")
+	}
+	if infos := p.Hooks(); len(infos) != 1 || infos[0].Value != base {
+		t.Errorf("the original proxy should not be modified: %#v", infos)
+	}
+	infos := derived.Hooks()
+	if len(infos) != 2 {
+		t.Fatalf("want 2 hook sets, got %d", len(infos))
+	}
+	if infos[0].Value != base || infos[1].Value != audit {
+		t.Errorf("unexpected hook sets: %#v", infos)
+	}
+
+	// nil hooks are ignored.
+	if infos := NewProxyContext(fdriver).With(nil, audit).Hooks(); len(infos) != 1 || infos[0].Value != audit {
+		t.Errorf("unexpected hook sets: %#v", infos)
+	}
+}
+
+func TestProxyWith_KeepsOptions(t *testing.T) {
+	gen := func() int64 { return 42 }
+	p := NewProxyContext(fdriver, &HooksContext{Name: "base"}).
+		WithOperations(OpExec).
+		WithErrorPolicy(ErrorPolicyWrap).
+		WithProxyError(ProxyErrorOptions{IncludeArgs: true}).
+		WithHookTiming(HookTimingOptions{Budget: time.Second}).
+		WithInterceptors(Interceptors{}).
+		WithDSNHooks(map[string]*HooksContext{"replica": {Name: "replica"}})
+	p.connIDGen = gen
+
+	derived := []*Proxy{
+		p.With(&HooksContext{Name: "audit"}),
+		p.WithOperations(OpExec),
+		p.WithErrorPolicy(ErrorPolicyWrap),
+		p.WithProxyError(*p.proxyError),
+		p.WithHookTiming(*p.hookTiming),
+		p.WithInterceptors(),
+		p.WithDSNHooks(nil),
+	}
+	for i, np := range derived {
+		if np.disabledOps != p.disabledOps || np.errorPolicy != p.errorPolicy ||
+			np.proxyError == nil || !np.proxyError.IncludeArgs ||
+			np.hookTiming == nil || np.hookTiming.Budget != time.Second ||
+			len(np.dsnRoutes) != 1 || np.newConnID() != 42 {
+			t.Errorf("%d: the options are not carried over: %#v", i, np)
+		}
+	}
+}
+
+func TestProxyHookPriority(t *testing.T) {
+	metrics := &HooksContext{Name: "metrics", Priority: 10}
+	tracer := &HooksContext{Name: "tracer"}
+	audit := &HooksContext{Name: "audit"}
+	rewriter := &HooksContext{Name: "rewriter", Priority: -10}
+
+	names := func(infos []HookSetInfo) []string {
+		ret := make([]string, 0, len(infos))
+		for _, info := range infos {
+			ret = append(ret, info.Name)
+		}
+		return ret
+	}
+
+	p := NewProxyContext(fdriver, rewriter, tracer, metrics)
+	if got, want := names(p.Hooks()), []string{"metrics", "tracer", "rewriter"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// the hook sets added by With are also sorted.
+	derived := p.With(audit, When(func(c context.Context) bool { return true }, &HooksContext{Name: "first", Priority: 100}))
+	if got, want := names(derived.Hooks()), []string{"first", "metrics", "tracer", "audit", "rewriter"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestProxyWithOperations(t *testing.T) {
+	var got []string
+	p := NewProxyContext(fdriver, &HooksContext{
+		PrePing: func(c context.Context, conn *Conn) (interface{}, error) {
+			got = append(got, "PrePing")
+			return nil, nil
+		},
+		PreExec: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+			got = append(got, "PreExec")
+			return nil, nil
+		},
+		PreClose: func(c context.Context, conn *Conn) (interface{}, error) {
+			got = append(got, "PreClose")
+			return nil, nil
+		},
+	}).WithOperations(OpExec)
+	conn := newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+
+	if err := conn.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.ExecContext(context.Background(), "INSERT INTO t1 (id) VALUES(?)", []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"PreExec"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	// the disabled operations are still counted.
+	if n := p.Stats().Operations[OpPing].Count; n != 1 {
+		t.Errorf("want 1 ping, got %d", n)
+	}
+}
+
+func TestProxySetHooks(t *testing.T) {
+	var got []string
+	newHooks := func(name string) *HooksContext {
+		return &HooksContext{
+			Name: name,
+			PrePing: func(c context.Context, conn *Conn) (interface{}, error) {
+				got = append(got, name)
+				return nil, nil
+			},
+		}
+	}
+	p := NewProxyContext(fdriver, newHooks("old"))
+	conn := newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+
+	if err := conn.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	p.SetHooks(newHooks("new1"), nil, newHooks("new2"))
+	if err := conn.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	p.SetHooks()
+	if err := conn.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"old", "new1", "new2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if n := len(p.Hooks()); n != 0 {
+		t.Errorf("want no hook sets, got %d", n)
+	}
+}
+
+func TestProxySetHooks_Race(t *testing.T) {
+	p := NewProxyContext(fdriver)
+	conn := newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			p.SetHooks(&HooksContext{})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if err := conn.Ping(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
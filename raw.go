@@ -0,0 +1,79 @@
+//go:build go1.17
+// +build go1.17
+
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// rawConnector borrows connections from an existing *sql.DB via sql.Conn.Raw.
+type rawConnector struct {
+	db *sql.DB
+}
+
+var _ borrower = (*rawConnector)(nil)
+
+// Connect always fails, because the connections of rawConnector must be returned to db.
+// Connector uses borrow instead.
+func (c *rawConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return nil, ErrBorrowedConnection
+}
+
+func (c *rawConnector) Driver() driver.Driver {
+	return c.db.Driver()
+}
+
+func (c *rawConnector) borrow(ctx context.Context) (driver.Conn, func() error, error) {
+	sqlConn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	connCh := make(chan driver.Conn, 1)
+	releaseCh := make(chan struct{})
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- sqlConn.Raw(func(driverConn interface{}) error {
+			connCh <- driverConn.(driver.Conn)
+			<-releaseCh
+			return nil
+		})
+	}()
+
+	select {
+	case conn := <-connCh:
+		release := func() error {
+			close(releaseCh)
+			err := <-doneCh
+			if cerr := sqlConn.Close(); err == nil {
+				err = cerr
+			}
+			return err
+		}
+		return conn, release, nil
+	case err := <-doneCh:
+		sqlConn.Close()
+		return nil, nil, err
+	}
+}
+
+// InstrumentDB returns a new *sql.DB that runs the operations on db with the hooks.
+// It is useful to bolt tracing onto an already-open *sql.DB owned by a third-party library,
+// because the underlying driver can't be registered again.
+//
+// The returned DB borrows a connection from db for each operation via sql.Conn.Raw,
+// and returns it when the operation finishes, so it doesn't keep idle connections.
+// Closing the returned DB doesn't close db.
+// It requires Go 1.17 or later.
+func InstrumentDB(db *sql.DB, hs ...*HooksContext) *sql.DB {
+	p := NewProxyContext(db.Driver(), hs...)
+	instrumented := sql.OpenDB(&Connector{
+		Proxy:     p,
+		Connector: &rawConnector{db: db},
+	})
+	instrumented.SetMaxIdleConns(0)
+	return instrumented
+}
@@ -0,0 +1,58 @@
+//go:build go1.17
+// +build go1.17
+
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInstrumentDB(t *testing.T) {
+	origin, err := sql.Open("fakedb", `{"Name":"instrument-db","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer origin.Close()
+
+	var execs int32
+	db := InstrumentDB(origin, &HooksContext{
+		PostExec: func(_ context.Context, _ interface{}, _ *Stmt, _ []driver.NamedValue, _ driver.Result, _ error) error {
+			atomic.AddInt32(&execs, 1)
+			return nil
+		},
+	})
+	for i := 0; i < 3; i++ {
+		if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := atomic.LoadInt32(&execs); got != 3 {
+		t.Errorf("want 3 executions, got %d", got)
+	}
+
+	// the connection is returned to the original pool.
+	if stats := origin.Stats(); stats.InUse != 0 || stats.OpenConnections != 1 {
+		t.Errorf("unexpected stats of the original pool: %#v", stats)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := origin.Ping(); err != nil {
+		t.Errorf("the original pool should not be closed: %v", err)
+	}
+	if stats := origin.Stats(); stats.OpenConnections != 1 {
+		t.Errorf("the borrowed connection should not be closed: %#v", stats)
+	}
+}
+
+func TestRawConnector_Connect(t *testing.T) {
+	c := &rawConnector{}
+	if _, err := c.Connect(context.Background()); err != ErrBorrowedConnection {
+		t.Errorf("want %v, got %v", ErrBorrowedConnection, err)
+	}
+}
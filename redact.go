@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// RedactedValue is a value masked by TracerOptions.RedactArgs.
+// It is formatted as "<redacted TYPE>" by the value formatters,
+// so the logs still record the types of the arguments.
+type RedactedValue struct {
+	// Type is the type of the original value, e.g. "int64" and "string".
+	Type string
+}
+
+// RedactValue returns the RedactedValue of v.
+func RedactValue(v driver.Value) RedactedValue {
+	if v == nil {
+		return RedactedValue{Type: "nil"}
+	}
+	return RedactedValue{Type: fmt.Sprintf("%T", v)}
+}
+
+// String returns "<redacted TYPE>".
+func (v RedactedValue) String() string {
+	return "<redacted " + v.Type + ">"
+}
+
+// GoString returns "<redacted TYPE>". It is used by GoSyntaxValueFormatter.
+func (v RedactedValue) GoString() string {
+	return v.String()
+}
+
+// RedactAllArgs masks all the arguments. It is intended to be used as TracerOptions.RedactArgs.
+func RedactAllArgs(name string, v driver.Value) driver.Value {
+	return RedactValue(v)
+}
+
+// redactArgs returns a copy of args, whose values are replaced by redact.
+func redactArgs(args []driver.NamedValue, redact func(name string, v driver.Value) driver.Value) []driver.NamedValue {
+	if len(args) == 0 {
+		return args
+	}
+	ret := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		ret[i] = arg
+		ret[i].Value = redact(arg.Name, arg.Value)
+	}
+	return ret
+}
@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRedactValue(t *testing.T) {
+	tests := []struct {
+		in   driver.Value
+		want string
+	}{
+		{nil, "<redacted nil>"},
+		{int64(1), "<redacted int64>"},
+		{"secret", "<redacted string>"},
+		{[]byte("secret"), "<redacted []uint8>"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		GoSyntaxValueFormatter.FormatValue(&buf, RedactValue(tt.in))
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%#v: want %q, got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestTracerOptions_RedactArgs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-redact", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		RedactArgs: func(name string, v driver.Value) driver.Value {
+			if s, ok := v.(string); ok && s == "secret" {
+				return RedactValue(v)
+			}
+			return v
+		},
+	})))
+	db, err := sql.Open("fakedb-trace-redact", `{"name":"trace-redact"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id, name) VALUES(?, ?)", 1, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	want := "args = [1, <redacted string>]"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want %q in the log, got:\n%s", want, buf.String())
+	}
+	if strings.Contains(buf.String(), `"secret"`) {
+		t.Errorf("the secret is logged:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// Result adds hook points into "database/sql/driver".Result.
+// It is returned by the executions of the proxies that have hooks.
+type Result struct {
+	// Result is the original result.
+	Result driver.Result
+
+	Proxy *Proxy
+
+	// Stmt is the statement that returned the result.
+	Stmt *Stmt
+
+	ctx   context.Context // the context of the execution
+	hooks hooks
+}
+
+func newResult(c context.Context, hooks hooks, stmt *Stmt, result driver.Result) *Result {
+	return &Result{
+		Result: result,
+		Proxy:  stmt.Proxy,
+		Stmt:   stmt,
+		ctx:    c,
+		hooks:  hooks,
+	}
+}
+
+// query returns the query that returned the result.
+func (r *Result) query() string {
+	if r == nil {
+		return ""
+	}
+	return r.Stmt.query()
+}
+
+// conn returns the connection that returned the result.
+func (r *Result) conn() *Conn {
+	if r == nil {
+		return nil
+	}
+	return r.Stmt.conn()
+}
+
+// LastInsertId returns the database's auto-generated ID.
+// It will trigger PostLastInsertId hooks.
+func (r *Result) LastInsertId() (int64, error) {
+	id, err := r.Result.LastInsertId()
+	if r.hooks != nil {
+		r.Proxy.postError(&err, r.hooks.postLastInsertId(r.ctx, r, id, err))
+	}
+	return id, err
+}
+
+// RowsAffected returns the number of rows affected by the query.
+// It will trigger PostRowsAffected hooks.
+func (r *Result) RowsAffected() (int64, error) {
+	n, err := r.Result.RowsAffected()
+	if r.hooks != nil {
+		r.Proxy.postError(&err, r.hooks.postRowsAffected(r.ctx, r, n, err))
+	}
+	return n, err
+}
@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+)
+
+var _ driver.Result = &Result{}
+
+func TestResultHooks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-result-hooks", NewProxyContext(fdriver, &HooksContext{
+		PostLastInsertId: func(c context.Context, result *Result, id int64, err error) error {
+			fmt.Fprintln(buf, "[PostLastInsertId]", result.Stmt.QueryString, id, err != nil)
+			return nil
+		},
+		PostRowsAffected: func(c context.Context, result *Result, n int64, err error) error {
+			fmt.Fprintln(buf, "[PostRowsAffected]", result.Stmt.QueryString, n, err)
+			return nil
+		},
+	}))
+	db, err := sql.Open("fakedb-result-hooks", `{"Name":"result-hooks","ConnType":"fakeConnCtx","RowsAffected":2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n != 2 {
+		t.Errorf("want (2, nil), got (%d, %v)", n, err)
+	}
+	if _, err := result.LastInsertId(); err == nil {
+		t.Error("want error, got nil")
+	}
+
+	want := "[PostRowsAffected] DELETE FROM t1 2 <nil>\n[PostLastInsertId] DELETE FROM t1 0 true\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
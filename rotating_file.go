@@ -0,0 +1,176 @@
+package proxy
+
+import (
+	"os"
+	"strconv"
+	"sync"
+)
+
+// defaultMaxFileSize is the default of RotatingFileOptions.MaxSize.
+const defaultMaxFileSize = 100 << 20
+
+// RotatingFileOptions holds the options of OpenRotatingFile.
+type RotatingFileOptions struct {
+	// MaxSize is the maximum size of the file in bytes.
+	// The file is rotated before it grows larger than MaxSize.
+	// If it is zero, 100 MiB is used.
+	MaxSize int64
+
+	// MaxBackups is the maximum number of the rotated files to keep.
+	// The rotated files are named name.1, name.2, ..., and name.1 is the newest.
+	// If it is zero, all the rotated files are kept.
+	MaxBackups int
+
+	// Perm is the permission of the files.
+	// If it is zero, 0644 is used.
+	Perm os.FileMode
+}
+
+// RotatingFile is an Outputter that writes the logs to a file,
+// and rotates the file when it reaches the maximum size.
+// It is also an io.Writer, so it can be used as the output of log.Logger,
+// e.g. to add timestamps to the logs.
+// It is safe for concurrent use.
+type RotatingFile struct {
+	name string
+	opt  RotatingFileOptions
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotatingFile opens the file name for appending the logs, creating it if necessary.
+func OpenRotatingFile(name string, opt RotatingFileOptions) (*RotatingFile, error) {
+	if opt.MaxSize <= 0 {
+		opt.MaxSize = defaultMaxFileSize
+	}
+	if opt.Perm == 0 {
+		opt.Perm = 0644
+	}
+	f := &RotatingFile{
+		name: name,
+		opt:  opt,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Output writes s to the file, appending a newline if s doesn't end with it.
+// calldepth is ignored.
+func (f *RotatingFile) Output(calldepth int, s string) error {
+	if len(s) == 0 || s[len(s)-1] != '\n' {
+		s += "\n"
+	}
+	_, err := f.Write([]byte(s))
+	return err
+}
+
+// Write writes p to the file.
+// The file is rotated before writing if p doesn't fit in it,
+// but p is never split across the files.
+// If the rotation fails, p is written to the current file and the error of the rotation is returned.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	var rotateErr error
+	if f.size > 0 && f.size+int64(len(p)) > f.opt.MaxSize {
+		rotateErr = f.rotate()
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Rotate rotates the file immediately, e.g. on SIGHUP.
+// If it fails, the file keeps being written.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	file, size, err := f.openFile()
+	if err != nil {
+		return err
+	}
+	f.file = file
+	f.size = size
+	return nil
+}
+
+func (f *RotatingFile) openFile() (*os.File, int64, error) {
+	file, err := os.OpenFile(f.name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, f.opt.Perm)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+// rotate renames name.N to name.N+1 and name to name.1, and then opens new file.
+// The current file is replaced only after the new file is opened,
+// so it keeps being written if any step fails.
+// f.mu must be held.
+func (f *RotatingFile) rotate() error {
+	n := f.opt.MaxBackups
+	if n == 0 {
+		// shift all the existing backups.
+		for n = 1; ; n++ {
+			if _, err := os.Stat(f.backupName(n)); err != nil {
+				break
+			}
+		}
+	} else if err := os.Remove(f.backupName(n)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i := n - 1; i > 0; i-- {
+		if err := os.Rename(f.backupName(i), f.backupName(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(f.name, f.backupName(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	file, size, err := f.openFile()
+	if err != nil {
+		return err
+	}
+	old := f.file
+	f.file = file
+	f.size = size
+	return old.Close()
+}
+
+func (f *RotatingFile) backupName(n int) string {
+	return f.name + "." + strconv.Itoa(n)
+}
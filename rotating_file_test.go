@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-sql-proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "trace.log")
+	f, err := OpenRotatingFile(name, RotatingFileOptions{
+		MaxSize:    10,
+		MaxBackups: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, s := range []string{"line1", "line2", "line3", "line4"} {
+		if err := f.Output(2, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{
+		name:        "line4\n",
+		name + ".1": "line3\n",
+		name + ".2": "line2\n",
+	}
+	for name, content := range want {
+		got, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("%s: want %q, got %q", name, content, got)
+		}
+	}
+	if _, err := os.Stat(name + ".3"); !os.IsNotExist(err) {
+		t.Errorf("want %s.3 to be removed, got %v", name, err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Output(2, "line5"); err != os.ErrClosed {
+		t.Errorf("want %v, got %v", os.ErrClosed, err)
+	}
+}
+
+func TestRotatingFile_Append(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-sql-proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "trace.log")
+	if err := ioutil.WriteFile(name, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := OpenRotatingFile(name, RotatingFileOptions{MaxSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// "old\nnew1\n" exceeds MaxSize, so the old file is rotated.
+	if err := f.Output(2, "new1\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		name:        "",
+		name + ".1": "new1\n",
+		name + ".2": "old\n",
+	}
+	for name, content := range want {
+		got, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("%s: want %q, got %q", name, content, got)
+		}
+	}
+}
+
+func TestRotatingFile_RotateError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-sql-proxy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "trace.log")
+	f, err := OpenRotatingFile(name, RotatingFileOptions{
+		MaxSize:    10,
+		MaxBackups: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// the oldest backup can't be removed, so the rotation fails.
+	if err := os.MkdirAll(filepath.Join(name+".1", "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Output(2, "line1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Rotate(); err == nil {
+		t.Error("want error, got nil")
+	}
+	if err := f.Output(2, "line2"); err == nil {
+		t.Error("want error, got nil")
+	}
+
+	// the lines are written to the current file.
+	got, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "line1\nline2\n"; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	// the rotation succeeds once the backup is removable.
+	if err := os.RemoveAll(name + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Output(2, "line3"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		name:        "line3\n",
+		name + ".1": "line1\nline2\n",
+	}
+	for name, content := range want {
+		got, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("%s: want %q, got %q", name, content, got)
+		}
+	}
+}
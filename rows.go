@@ -0,0 +1,255 @@
+package proxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"time"
+)
+
+// Rows adds hook points into "database/sql/driver".Rows.
+// It is returned by the queries of the proxies that have hooks.
+type Rows struct {
+	// Rows is the original rows.
+	Rows driver.Rows
+
+	Proxy *Proxy
+
+	// Stmt is the statement that returned the rows.
+	Stmt *Stmt
+
+	ctx       context.Context // the context of the query
+	hooks     hooks
+	start     time.Time           // the start time of the query
+	iterStart time.Time           // the start time of the iteration
+	count     int64               // the number of rows read
+	finished  bool                // PostRows hooks have been called
+	err       error               // the error of Next other than io.EOF
+	cols      []Column            // the cache of columnInfo
+	args      []driver.NamedValue // the arguments of the query
+}
+
+// Column describes a column of the result set.
+type Column struct {
+	// Name is the name of the column.
+	Name string
+
+	// DatabaseTypeName is the database system type name, e.g. "VARCHAR".
+	// It is empty if the driver does not expose it.
+	DatabaseTypeName string
+
+	// ScanType is the type suitable for scanning into.
+	// It is nil if the driver does not expose it.
+	ScanType reflect.Type
+
+	// Nullable reports whether the column may be null.
+	// It is meaningful only if HasNullable is true.
+	Nullable    bool
+	HasNullable bool
+}
+
+func newRows(c context.Context, hooks hooks, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, start time.Time) *Rows {
+	return &Rows{
+		Rows:  rows,
+		Proxy: stmt.Proxy,
+		Stmt:  stmt,
+		ctx:   c,
+		hooks: hooks,
+		start: start,
+		args:  args,
+
+		iterStart: time.Now(),
+	}
+}
+
+// Elapsed returns the duration since the query started.
+// In the RowsClose hooks, it is the total time from the query to the close.
+func (rows *Rows) Elapsed() time.Duration {
+	return time.Since(rows.start)
+}
+
+// columnInfo returns the columns of the result set.
+// The result is cached, because it is shared by all hook sets.
+func (rows *Rows) columnInfo() []Column {
+	if rows.cols != nil {
+		return rows.cols
+	}
+	names := rows.Rows.Columns()
+	cols := make([]Column, len(names))
+	dbType, _ := rows.Rows.(driver.RowsColumnTypeDatabaseTypeName)
+	scanType, _ := rows.Rows.(driver.RowsColumnTypeScanType)
+	nullable, _ := rows.Rows.(driver.RowsColumnTypeNullable)
+	for i, name := range names {
+		cols[i].Name = name
+		if dbType != nil {
+			cols[i].DatabaseTypeName = dbType.ColumnTypeDatabaseTypeName(i)
+		}
+		if scanType != nil {
+			cols[i].ScanType = scanType.ColumnTypeScanType(i)
+		}
+		if nullable != nil {
+			cols[i].Nullable, cols[i].HasNullable = nullable.ColumnTypeNullable(i)
+		}
+	}
+	rows.cols = cols
+	return cols
+}
+
+// query returns the query that returned the rows.
+func (rows *Rows) query() string {
+	if rows == nil {
+		return ""
+	}
+	return rows.Stmt.query()
+}
+
+// conn returns the connection that returned the rows.
+func (rows *Rows) conn() *Conn {
+	if rows == nil {
+		return nil
+	}
+	return rows.Stmt.conn()
+}
+
+// Err returns the error that Next returned, other than io.EOF.
+// It returns nil if the rows are read without errors so far.
+func (rows *Rows) Err() error {
+	return rows.err
+}
+
+// RowCount returns the number of rows read by Next so far.
+func (rows *Rows) RowCount() int64 {
+	return rows.count
+}
+
+// finish calls the PostRows hooks once.
+func (rows *Rows) finish() {
+	if rows.finished {
+		return
+	}
+	rows.finished = true
+	if rows.hooks != nil {
+		rows.hooks.postRows(rows.ctx, rows, rows.count, time.Since(rows.iterStart))
+	}
+}
+
+// Columns returns the names of the columns.
+// It just calls the original Columns method.
+func (rows *Rows) Columns() []string {
+	return rows.Rows.Columns()
+}
+
+// Close closes the rows.
+// It will trigger PreRowsClose, RowsClose, PostRowsClose hooks.
+func (rows *Rows) Close() (err error) {
+	var ctx interface{}
+	start := time.Now()
+	defer rows.Proxy.stats.observe(OpRowsClose, start, &err)
+	rows.finish()
+
+	hooks := rows.hooks
+	if rows.Proxy.disabledOps.Contains(OpRowsClose) {
+		hooks = nil
+	}
+	if hooks != nil {
+		defer func() { rows.Proxy.postError(&err, hooks.postRowsClose(withDuration(rows.ctx, start), ctx, rows, err)) }()
+		if ctx, err = hooks.preRowsClose(rows.ctx, rows); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Rows.Close(); err != nil {
+		return err
+	}
+
+	if hooks != nil {
+		err = hooks.rowsClose(rows.ctx, ctx, rows)
+	}
+	return err
+}
+
+// Next populates the next row.
+// It will trigger PostRows hooks when the rows are exhausted.
+func (rows *Rows) Next(dest []driver.Value) error {
+	err := rows.Rows.Next(dest)
+	rows.advance(err)
+	return err
+}
+
+// advance counts the row read, or calls the PostRows hooks if the rows are exhausted or reading them fails.
+// err is the error of reading the next row.
+func (rows *Rows) advance(err error) {
+	if err == nil {
+		rows.count++
+		return
+	}
+	if err != io.EOF {
+		rows.err = err
+	}
+	rows.finish()
+}
+
+// HasNextResultSet reports whether there is another result set.
+// It returns false if the original rows do not satisfy "database/sql/driver".RowsNextResultSet.
+func (rows *Rows) HasNextResultSet() bool {
+	if r, ok := rows.Rows.(driver.RowsNextResultSet); ok {
+		return r.HasNextResultSet()
+	}
+	return false
+}
+
+// NextResultSet advances to the next result set.
+// It returns io.EOF if the original rows do not satisfy "database/sql/driver".RowsNextResultSet.
+func (rows *Rows) NextResultSet() error {
+	if r, ok := rows.Rows.(driver.RowsNextResultSet); ok {
+		return r.NextResultSet()
+	}
+	return io.EOF
+}
+
+// ColumnTypeScanType returns the type suitable for scanning into.
+// It returns the type of interface{} if the original rows do not support it,
+// which is the same as the default of database/sql.
+func (rows *Rows) ColumnTypeScanType(index int) reflect.Type {
+	if r, ok := rows.Rows.(driver.RowsColumnTypeScanType); ok {
+		return r.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+// ColumnTypeDatabaseTypeName returns the database system type name.
+// It returns an empty string if the original rows do not support it.
+func (rows *Rows) ColumnTypeDatabaseTypeName(index int) string {
+	if r, ok := rows.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return r.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+// ColumnTypeLength returns the length of the column type.
+// ok is false if the original rows do not support it.
+func (rows *Rows) ColumnTypeLength(index int) (length int64, ok bool) {
+	if r, ok := rows.Rows.(driver.RowsColumnTypeLength); ok {
+		return r.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+// ColumnTypeNullable reports whether the column may be null.
+// ok is false if the original rows do not support it.
+func (rows *Rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if r, ok := rows.Rows.(driver.RowsColumnTypeNullable); ok {
+		return r.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+// ColumnTypePrecisionScale returns the precision and scale for decimal types.
+// ok is false if the original rows do not support it.
+func (rows *Rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if r, ok := rows.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return r.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,12 @@
+//go:build !go1.27
+// +build !go1.27
+
+package proxy
+
+import "database/sql/driver"
+
+// driverRows returns rows as driver.Rows.
+// driver.RowsColumnScanner is available from Go 1.27, so it is always rows itself.
+func (rows *Rows) driverRows() driver.Rows {
+	return rows
+}
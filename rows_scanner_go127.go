@@ -0,0 +1,35 @@
+//go:build go1.27
+// +build go1.27
+
+package proxy
+
+import "database/sql/driver"
+
+// rowsColumnScanner is Rows that implements driver.RowsColumnScanner.
+// It is returned instead of Rows if the original rows implement it, so database/sql doesn't see it otherwise.
+type rowsColumnScanner struct {
+	*Rows
+}
+
+// driverRows returns rows as driver.Rows.
+// It implements driver.RowsColumnScanner if the original rows implement it.
+func (rows *Rows) driverRows() driver.Rows {
+	if _, ok := rows.Rows.(driver.RowsColumnScanner); ok {
+		return rowsColumnScanner{rows}
+	}
+	return rows
+}
+
+// NextRow advances to the next row.
+// database/sql calls it instead of Next, so it also triggers PostRows hooks when the rows are exhausted.
+func (rows rowsColumnScanner) NextRow() error {
+	err := rows.Rows.Rows.(driver.RowsColumnScanner).NextRow()
+	rows.advance(err)
+	return err
+}
+
+// ScanColumn copies the column at index in the current row into dest.
+// It just calls the original ScanColumn method.
+func (rows rowsColumnScanner) ScanColumn(scanCtx driver.ScanContext, index int, dest interface{}) error {
+	return rows.Rows.Rows.(driver.RowsColumnScanner).ScanColumn(scanCtx, index, dest)
+}
@@ -0,0 +1,72 @@
+//go:build go1.27
+// +build go1.27
+
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+// columnScannerRows is fakeRows that implements driver.RowsColumnScanner.
+type columnScannerRows struct {
+	driver.Rows
+	nextRows int
+}
+
+func (rows *columnScannerRows) NextRow() error {
+	rows.nextRows++
+	return rows.Rows.Next(make([]driver.Value, len(rows.Columns())))
+}
+
+func (rows *columnScannerRows) ScanColumn(_ driver.ScanContext, index int, dest interface{}) error {
+	*dest.(*int64) = 42
+	return nil
+}
+
+func TestRowsColumnScanner(t *testing.T) {
+	var scanner *columnScannerRows
+	var counts []int64
+	p := NewProxyContext(fdriver, &HooksContext{
+		PostRows: func(c context.Context, rows *Rows, count int64, d time.Duration) error {
+			counts = append(counts, count)
+			return nil
+		},
+	}).WithInterceptors(Interceptors{
+		Query: func(next QueryFunc) QueryFunc {
+			return func(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Rows, error) {
+				rows, err := next(c, stmt, args)
+				if err != nil {
+					return nil, err
+				}
+				scanner = &columnScannerRows{Rows: rows}
+				return scanner, nil
+			}
+		},
+	})
+	sql.Register("fakedb-rows-column-scanner", p)
+	db, err := sql.Open("fakedb-rows-column-scanner", `{"Name":"rows-column-scanner","ConnType":"fakeConnCtx","NumRows":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var id int64
+	if err := db.QueryRow("SELECT id FROM t1").Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	// the values are scanned by the original rows.
+	if id != 42 {
+		t.Errorf("want 42, got %d", id)
+	}
+	if scanner == nil || scanner.nextRows == 0 {
+		t.Errorf("want NextRow called, got %#v", scanner)
+	}
+	if len(counts) != 1 || counts[0] != 1 {
+		t.Errorf("want PostRows called with 1 row, got %v", counts)
+	}
+}
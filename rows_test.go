@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var _ driver.Rows = &Rows{}
+var _ driver.RowsNextResultSet = &Rows{}
+var _ driver.RowsColumnTypeScanType = &Rows{}
+var _ driver.RowsColumnTypeDatabaseTypeName = &Rows{}
+var _ driver.RowsColumnTypeLength = &Rows{}
+var _ driver.RowsColumnTypeNullable = &Rows{}
+var _ driver.RowsColumnTypePrecisionScale = &Rows{}
+
+func TestRowsCloseHooks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-rows-close-hooks", NewProxyContext(fdriver, &HooksContext{
+		PreRowsClose: func(c context.Context, rows *Rows) (interface{}, error) {
+			connID, _ := ConnIDFromContext(c)
+			fmt.Fprintf(buf, "[PreRowsClose] %s %t\n", rows.Stmt.QueryString, connID != 0)
+			return nil, nil
+		},
+		RowsClose: func(c context.Context, ctx interface{}, rows *Rows) error {
+			fmt.Fprintln(buf, "[RowsClose]", rows.Elapsed() > 0)
+			return nil
+		},
+		PostRowsClose: func(c context.Context, ctx interface{}, rows *Rows, err error) error {
+			fmt.Fprintln(buf, "[PostRowsClose]", err)
+			return nil
+		},
+	}))
+	db, err := sql.Open("fakedb-rows-close-hooks", `{"Name":"rows-close-hooks","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id FROM t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(types) != 1 || types[0].Name() != "id" {
+		t.Errorf("unexpected column types: %v", types)
+	}
+	for rows.Next() {
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "[PreRowsClose] SELECT id FROM t1 true\n[RowsClose] true\n[PostRowsClose] <nil>\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestPostRowsHooks(t *testing.T) {
+	var counts []int64
+	sql.Register("fakedb-post-rows-hooks", NewProxyContext(fdriver, &HooksContext{
+		PostRows: func(c context.Context, rows *Rows, count int64, d time.Duration) error {
+			counts = append(counts, count)
+			return nil
+		},
+	}))
+	db, err := sql.Open("fakedb-post-rows-hooks", `{"Name":"post-rows-hooks","ConnType":"fakeConnCtx","NumRows":3}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// exhausted
+	rows, err := db.Query("SELECT id FROM t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// closed before exhausted
+	rows, err = db.Query("SELECT id FROM t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Next()
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int64{3, 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("want %v, got %v", want, counts)
+	}
+}
+
+func TestColumnsHooks(t *testing.T) {
+	var got []Column
+	errColumns := errors.New("the column names are not snake_case")
+	reject := false
+	sql.Register("fakedb-columns-hooks", NewProxyContext(fdriver, &HooksContext{
+		Columns: func(c context.Context, rows *Rows, columns []Column) error {
+			got = append(got, columns...)
+			if reject {
+				return errColumns
+			}
+			return nil
+		},
+	}))
+	db, err := sql.Open("fakedb-columns-hooks", `{"Name":"columns-hooks","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id FROM t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	want := []Column{{Name: "id"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	reject = true
+	if _, err := db.Query("SELECT id FROM t1"); err != errColumns {
+		t.Errorf("want %v, got %v", errColumns, err)
+	}
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"strings"
+)
+
+// SavepointKind is a kind of the savepoint statements.
+type SavepointKind int
+
+const (
+	// SavepointCreate is the statement "SAVEPOINT name".
+	SavepointCreate SavepointKind = iota + 1
+
+	// SavepointRelease is the statement "RELEASE SAVEPOINT name".
+	SavepointRelease
+
+	// SavepointRollback is the statement "ROLLBACK TO SAVEPOINT name".
+	SavepointRollback
+)
+
+func (k SavepointKind) String() string {
+	switch k {
+	case SavepointCreate:
+		return "Savepoint"
+	case SavepointRelease:
+		return "ReleaseSavepoint"
+	case SavepointRollback:
+		return "RollbackToSavepoint"
+	}
+	return "Unknown"
+}
+
+// Savepoint is a savepoint statement recognized by the proxy.
+type Savepoint struct {
+	// Kind is the kind of the statement.
+	Kind SavepointKind
+
+	// Name is the name of the savepoint, without quotes.
+	Name string
+}
+
+// ParseSavepoint recognizes the savepoint statements executed via Exec.
+// It supports the following forms, which are case-insensitive:
+//
+//	SAVEPOINT name
+//	RELEASE [SAVEPOINT] name
+//	ROLLBACK [WORK | TRANSACTION] TO [SAVEPOINT] name
+//
+// It returns nil if query is not a savepoint statement.
+func ParseSavepoint(query string) *Savepoint {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	// fast path for the other statements.
+	switch query[0] {
+	case 'S', 's', 'R', 'r':
+	default:
+		return nil
+	}
+
+	fields := strings.Fields(strings.TrimSuffix(query, ";"))
+	if len(fields) < 2 {
+		return nil
+	}
+
+	var kind SavepointKind
+	switch {
+	case strings.EqualFold(fields[0], "SAVEPOINT"):
+		kind = SavepointCreate
+		fields = fields[1:]
+	case strings.EqualFold(fields[0], "RELEASE"):
+		kind = SavepointRelease
+		fields = fields[1:]
+		if len(fields) > 1 && strings.EqualFold(fields[0], "SAVEPOINT") {
+			fields = fields[1:]
+		}
+	case strings.EqualFold(fields[0], "ROLLBACK"):
+		kind = SavepointRollback
+		fields = fields[1:]
+		if len(fields) > 0 && (strings.EqualFold(fields[0], "WORK") || strings.EqualFold(fields[0], "TRANSACTION")) {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 || !strings.EqualFold(fields[0], "TO") {
+			return nil
+		}
+		fields = fields[1:]
+		if len(fields) > 1 && strings.EqualFold(fields[0], "SAVEPOINT") {
+			fields = fields[1:]
+		}
+	default:
+		return nil
+	}
+	if len(fields) != 1 {
+		return nil
+	}
+	return &Savepoint{
+		Kind: kind,
+		Name: unquoteIdentifier(fields[0]),
+	}
+}
+
+// unquoteIdentifier removes the quotes of the SQL identifier.
+func unquoteIdentifier(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	switch q := s[0]; q {
+	case '"', '`':
+		if s[len(s)-1] == q {
+			return strings.Replace(s[1:len(s)-1], string([]byte{q, q}), string([]byte{q}), -1)
+		}
+	case '[':
+		if s[len(s)-1] == ']' {
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
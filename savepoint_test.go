@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestParseSavepoint(t *testing.T) {
+	tests := []struct {
+		query string
+		want  *Savepoint
+	}{
+		{"SAVEPOINT sp1", &Savepoint{Kind: SavepointCreate, Name: "sp1"}},
+		{"  savepoint `sp``1`;", &Savepoint{Kind: SavepointCreate, Name: "sp`1"}},
+		{"RELEASE SAVEPOINT sp1", &Savepoint{Kind: SavepointRelease, Name: "sp1"}},
+		{"release \"sp1\"", &Savepoint{Kind: SavepointRelease, Name: "sp1"}},
+		{"ROLLBACK TO SAVEPOINT sp1", &Savepoint{Kind: SavepointRollback, Name: "sp1"}},
+		{"rollback work to sp1", &Savepoint{Kind: SavepointRollback, Name: "sp1"}},
+		{"ROLLBACK TRANSACTION TO SAVEPOINT [sp1]", &Savepoint{Kind: SavepointRollback, Name: "sp1"}},
+		{"ROLLBACK", nil},
+		{"SAVEPOINT", nil},
+		{"SELECT 1", nil},
+		{"RELEASE SAVEPOINT sp1 sp2", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := ParseSavepoint(tt.query)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSavepoint(%q): want %v, got %v", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestSavepointHooks(t *testing.T) {
+	var got []string
+	sql.Register("fakedb-savepoint-hooks", NewProxyContext(fdriver, &HooksContext{
+		PreSavepoint: func(c context.Context, stmt *Stmt, sp *Savepoint) (interface{}, error) {
+			got = append(got, fmt.Sprintf("PreSavepoint %s %s", sp.Kind, sp.Name))
+			return nil, nil
+		},
+		Savepoint: func(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) error {
+			got = append(got, "Savepoint")
+			return nil
+		},
+		PostSavepoint: func(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error {
+			got = append(got, fmt.Sprintf("PostSavepoint %v", err))
+			return nil
+		},
+		PreExec: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+			got = append(got, fmt.Sprintf("PreExec %s", stmt.QueryString))
+			return nil, nil
+		},
+		PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			got = append(got, "PostExec")
+			return nil
+		},
+	}))
+	db, err := sql.Open("fakedb-savepoint-hooks", `{"Name":"savepoint-hooks","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("SAVEPOINT sp1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("ROLLBACK TO SAVEPOINT sp1"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"PreSavepoint Savepoint sp1", "PreExec SAVEPOINT sp1", "Savepoint", "PostExec", "PostSavepoint <nil>",
+		"PreExec INSERT INTO t1 (id) VALUES(?)", "PostExec",
+		"PreSavepoint RollbackToSavepoint sp1", "PreExec ROLLBACK TO SAVEPOINT sp1", "Savepoint", "PostExec", "PostSavepoint <nil>",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestSavepoint_Trace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-savepoint", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+	})))
+	db, err := sql.Open("fakedb-trace-savepoint", `{"Name":"trace-savepoint","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("RELEASE SAVEPOINT sp1"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := regexp.MustCompile(`(?m)^ReleaseSavepoint 0x[0-9a-f]+: sp1; conn_id = \d+ `)
+	if !want.MatchString(buf.String()) {
+		t.Errorf("unexpected log:\n%s", buf.String())
+	}
+	if regexp.MustCompile(`(?m)^Exec `).MatchString(buf.String()) {
+		t.Errorf("the savepoint statement should not be logged as Exec:\n%s", buf.String())
+	}
+}
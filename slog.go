@@ -0,0 +1,114 @@
+//go:build go1.21
+// +build go1.21
+
+package proxy
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SlogOptions holds the options of NewSlogHooks.
+type SlogOptions struct {
+	// Level is the level of the records of the operations.
+	// If it is nil, slog.LevelDebug is used.
+	Level slog.Leveler
+
+	// ErrorLevel is the level of the records of the failed operations.
+	// If it is nil, slog.LevelError is used.
+	ErrorLevel slog.Leveler
+
+	// SlowQuery is a threshold duration of slow operations.
+	// The records of the operations that take SlowQuery or longer are logged at SlowLevel.
+	// If it is zero, no operations are slow.
+	SlowQuery time.Duration
+
+	// SlowLevel is the level of the records of the slow operations.
+	// If it is nil, slog.LevelWarn is used.
+	SlowLevel slog.Leveler
+
+	// ValueFormatter formats the arguments of queries.
+	// If it is nil, GoSyntaxValueFormatter is used.
+	ValueFormatter ValueFormatter
+
+	// OmitArgs omits the arguments of queries from the records.
+	OmitArgs bool
+}
+
+// NewSlogHooks creates new HooksContext which logs the operations to logger as structured records.
+// The records have the attributes "query", "args", "duration", "conn_id", "tx_id", "error" and "labels",
+// and are omitted if they are empty.
+// If logger is nil, slog.Default() is used.
+func NewSlogHooks(logger *slog.Logger, opt SlogOptions) *HooksContext {
+	level := leveler(opt.Level, slog.LevelDebug)
+	errorLevel := leveler(opt.ErrorLevel, slog.LevelError)
+	slowLevel := leveler(opt.SlowLevel, slog.LevelWarn)
+	vf := opt.ValueFormatter
+	if vf == nil {
+		vf = GoSyntaxValueFormatter
+	}
+
+	return NewEventHooks(func(c context.Context, e *Event) {
+		l := logger
+		if l == nil {
+			l = slog.Default()
+		}
+		lv := level.Level()
+		if e.Error != "" {
+			lv = errorLevel.Level()
+		} else if opt.SlowQuery > 0 && e.Duration >= opt.SlowQuery {
+			lv = slowLevel.Level()
+		}
+		if !l.Enabled(c, lv) {
+			return
+		}
+
+		attrs := make([]slog.Attr, 0, 7)
+		if e.Query != "" {
+			attrs = append(attrs, slog.String("query", e.Query))
+		}
+		if len(e.Args) > 0 && !opt.OmitArgs {
+			var buf strings.Builder
+			for i, arg := range e.Args {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				vf.FormatValue(&buf, arg.Value)
+			}
+			attrs = append(attrs, slog.String("args", buf.String()))
+		}
+		attrs = append(attrs, slog.Duration("duration", e.Duration))
+		if e.ConnID != 0 {
+			attrs = append(attrs, slog.Int64("conn_id", e.ConnID))
+		}
+		if e.TxID != 0 {
+			attrs = append(attrs, slog.Int64("tx_id", e.TxID))
+		}
+		if e.Error != "" {
+			attrs = append(attrs, slog.String("error", e.Error))
+		}
+		if len(e.Labels) > 0 {
+			keys := make([]string, 0, len(e.Labels))
+			for k := range e.Labels {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			labels := make([]any, 0, len(keys))
+			for _, k := range keys {
+				labels = append(labels, slog.String(k, e.Labels[k]))
+			}
+			attrs = append(attrs, slog.Group("labels", labels...))
+		}
+		l.LogAttrs(c, lv, e.Operation.String(), attrs...)
+	})
+}
+
+func leveler(l slog.Leveler, def slog.Level) slog.Leveler {
+	if l == nil {
+		return def
+	}
+	return l
+}
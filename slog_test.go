@@ -0,0 +1,81 @@
+//go:build go1.21
+// +build go1.21
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestNewSlogHooks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	sql.Register("fakedb-slog-hooks", NewProxyContext(fdriver, NewSlogHooks(logger, SlogOptions{})))
+	db, err := sql.Open("fakedb-slog-hooks", `{"Name":"slog-hooks","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := WithLabels(context.Background(), map[string]string{"job": "test"})
+	if _, err := db.ExecContext(ctx, "CREATE TABLE t1 (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	type record struct {
+		Level  string
+		Msg    string
+		Query  string
+		Args   string
+		ConnID int64 `json:"conn_id"`
+		Labels map[string]string
+	}
+	var records []record
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var r record
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if len(records) == 0 {
+		t.Fatal("no records")
+	}
+	last := records[len(records)-1]
+	if last.Level != "DEBUG" || last.Msg != "Exec" || last.Query != "INSERT INTO t1 (id) VALUES(?)" || last.Args != "1" {
+		t.Errorf("unexpected record: %+v", last)
+	}
+	if last.ConnID == 0 {
+		t.Error("want conn_id, got none")
+	}
+	if last.Labels["job"] != "test" {
+		t.Errorf("want label job=test, got %v", last.Labels)
+	}
+}
+
+func TestNewSlogHooks_Level(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	sql.Register("fakedb-slog-hooks-level", NewProxyContext(fdriver, NewSlogHooks(logger, SlogOptions{})))
+	db, err := sql.Open("fakedb-slog-hooks-level", `{"Name":"slog-hooks-level","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("want no debug records, got:\n%s", buf.String())
+	}
+}
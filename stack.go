@@ -0,0 +1,163 @@
+package proxy
+
+import (
+	"database/sql/driver"
+	"reflect"
+)
+
+// driverUnwrapper is implemented by the drivers that wrap another driver, e.g. Proxy.
+type driverUnwrapper interface {
+	Unwrap() driver.Driver
+}
+
+// Unwrap returns the underlying driver of p.
+// It makes the chain of stacked proxies walkable.
+func (p *Proxy) Unwrap() driver.Driver {
+	return p.Driver
+}
+
+// UnwrapDriver returns the innermost driver of the stacked drivers.
+// The drivers that have an `Unwrap() driver.Driver` method are unwrapped.
+func UnwrapDriver(d driver.Driver) driver.Driver {
+	for {
+		u, ok := d.(driverUnwrapper)
+		if !ok {
+			return d
+		}
+		inner := u.Unwrap()
+		if inner == nil {
+			return d
+		}
+		d = inner
+	}
+}
+
+// nestedProxies returns the proxies stacked under d, including d itself.
+func nestedProxies(d driver.Driver) []*Proxy {
+	var proxies []*Proxy
+	for d != nil {
+		if p, ok := d.(*Proxy); ok {
+			proxies = append(proxies, p)
+		}
+		u, ok := d.(driverUnwrapper)
+		if !ok {
+			break
+		}
+		d = u.Unwrap()
+	}
+	return proxies
+}
+
+// dedupHooks removes the hook sets which are already installed in the proxies stacked under d,
+// so that they are not called twice for an operation.
+func dedupHooks(d driver.Driver, hs []hooks) []hooks {
+	proxies := nestedProxies(d)
+	if len(proxies) == 0 {
+		return hs
+	}
+	var installed []interface{}
+	for _, p := range proxies {
+		for _, info := range p.Hooks() {
+			installed = append(installed, info.Value)
+		}
+	}
+
+	ret := hs[:0]
+LOOP:
+	for _, hk := range hs {
+		for _, info := range describeHooks(hk) {
+			for _, v := range installed {
+				if sameHookSet(info.Value, v) {
+					continue LOOP
+				}
+			}
+		}
+		ret = append(ret, hk)
+	}
+	return ret
+}
+
+func sameHookSet(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb || !ta.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// nestedTracerFilters returns the filters of the tracers installed in the proxies stacked under d.
+func nestedTracerFilters(d driver.Driver) []Filter {
+	var filters []Filter
+	for _, p := range nestedProxies(d) {
+		for _, info := range p.Hooks() {
+			if h, ok := info.Value.(*HooksContext); ok && h.tracerFilter != nil {
+				filters = append(filters, h.tracerFilter)
+			}
+		}
+	}
+	return filters
+}
+
+// multiFilter skips a package if any of the filters skips it.
+type multiFilter []Filter
+
+func (fs multiFilter) DoOutput(packageName string) bool {
+	for _, f := range fs {
+		if !f.DoOutput(packageName) {
+			return false
+		}
+	}
+	return true
+}
+
+func (fs multiFilter) DoOutputFile(file string) bool {
+	for _, f := range fs {
+		if ff, ok := f.(FileFilter); ok && !ff.DoOutputFile(file) {
+			return false
+		}
+	}
+	return true
+}
+
+// MultiFilter combines the filters, e.g. DefaultPackageFilter and a FilePathFilter,
+// so the frames skipped by any of them are skipped.
+func MultiFilter(fs ...Filter) Filter {
+	var merged multiFilter
+	for _, f := range fs {
+		if f != nil {
+			merged = append(merged, f)
+		}
+	}
+	return merged
+}
+
+// mergeFilters merges the ignore lists of the filters.
+func mergeFilters(fs ...Filter) Filter {
+	var merged multiFilter
+	for _, f := range fs {
+		if f == nil {
+			f = DefaultPackageFilter
+		}
+		merged = append(merged, f)
+	}
+	if len(merged) == 1 {
+		return merged[0]
+	}
+	return merged
+}
+
+// isNil reports whether v is nil, or a nil pointer wrapped in an interface.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Func, reflect.Chan, reflect.Interface, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package proxy_test
+
+import (
+	"bytes"
+	"database/sql"
+	"log"
+	"strings"
+	"testing"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+)
+
+// loggerOutputter calls the logger in the same depth as the default outputter of the tracer.
+type loggerOutputter struct {
+	l *log.Logger
+}
+
+func (o loggerOutputter) Output(calldepth int, s string) error {
+	return o.l.Output(calldepth, s)
+}
+
+func TestStackedTraceProxies_Caller(t *testing.T) {
+	origin, err := sql.Open("fakedb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer origin.Close()
+
+	buf := &bytes.Buffer{}
+	o := loggerOutputter{log.New(buf, "", log.Lshortfile)}
+	inner := proxy.NewTraceProxy(origin.Driver(), o)
+	sql.Register("fakedb:stacked-trace", proxy.NewTraceProxy(inner, o))
+	db, err := sql.Open("fakedb:stacked-trace", `{"name":"stacked-trace"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// both tracers report the caller in this file.
+	var execs int
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !strings.Contains(line, "Exec ") {
+			continue
+		}
+		execs++
+		if !strings.HasPrefix(line, "stack_external_test.go:") {
+			t.Errorf("unexpected caller: %s", line)
+		}
+	}
+	if execs != 2 {
+		t.Errorf("want 2 lines of Exec, got %d:\n%s", execs, buf.String())
+	}
+}
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io/ioutil"
+	"log"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStackedProxies(t *testing.T) {
+	var count1, count2 int32
+	h1 := &HooksContext{
+		Name: "h1",
+		PostExec: func(_ context.Context, _ interface{}, _ *Stmt, _ []driver.NamedValue, _ driver.Result, _ error) error {
+			atomic.AddInt32(&count1, 1)
+			return nil
+		},
+	}
+	h2 := &HooksContext{
+		Name: "h2",
+		PostExec: func(_ context.Context, _ interface{}, _ *Stmt, _ []driver.NamedValue, _ driver.Result, _ error) error {
+			atomic.AddInt32(&count2, 1)
+			return nil
+		},
+	}
+	inner := NewProxyContext(fdriver, h1)
+	outer := NewProxyContext(inner, h1, h2)
+
+	if got := UnwrapDriver(outer); got != fdriver {
+		t.Errorf("want %v, got %v", fdriver, got)
+	}
+	if infos := outer.Hooks(); len(infos) != 1 || infos[0].Name != "h2" {
+		t.Errorf("the hooks installed in the inner proxy should be deduplicated: %#v", infos)
+	}
+
+	sql.Register("fakedb-stacked-proxies", outer)
+	db, err := sql.Open("fakedb-stacked-proxies", `{"Name":"stacked-proxies","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&count1); got != 1 {
+		t.Errorf("h1: want 1 call, got %d", got)
+	}
+	if got := atomic.LoadInt32(&count2); got != 1 {
+		t.Errorf("h2: want 1 call, got %d", got)
+	}
+}
+
+func TestStackedTraceProxies(t *testing.T) {
+	o := log.New(ioutil.Discard, "", 0)
+	inner := NewTraceProxyWithFilter(fdriver, o, PackageFilter{"example.com/orm": struct{}{}})
+	outer := NewTraceProxy(inner, o)
+
+	infos := outer.Hooks()
+	if len(infos) != 1 {
+		t.Fatalf("want 1 hook set, got %#v", infos)
+	}
+	f := infos[0].Value.(*HooksContext).tracerFilter
+	for _, pkg := range []string{"example.com/orm", "database/sql", "github.com/shogo82148/go-sql-proxy"} {
+		if f.DoOutput(pkg) {
+			t.Errorf("%s should be ignored by the merged filter", pkg)
+		}
+	}
+	if !f.DoOutput("example.com/app") {
+		t.Error("example.com/app should not be ignored by the merged filter")
+	}
+
+}
+
+func TestStackedTraceProxies_Wrapped(t *testing.T) {
+	o := log.New(ioutil.Discard, "", 0)
+	always := func(c context.Context) bool { return true }
+	inner := NewProxyContext(fdriver, When(always, NewTraceHooks(TracerOptions{
+		Outputter: o,
+		Filter:    PackageFilter{"example.com/orm": struct{}{}},
+	})))
+	outer := NewTraceProxy(inner, o)
+
+	f := outer.Hooks()[0].Value.(*HooksContext).tracerFilter
+	if f.DoOutput("example.com/orm") {
+		t.Error("example.com/orm should be ignored by the merged filter")
+	}
+	if !f.DoOutput("example.com/app") {
+		t.Error("example.com/app should not be ignored by the merged filter")
+	}
+}
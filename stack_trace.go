@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxStackDepth is the maximum number of frames of the stack traces in the logs.
+const maxStackDepth = 32
+
+// packageName returns the package name of the function name, e.g. "database/sql" for "database/sql.(*DB).Exec".
+func packageName(name string) string {
+	// http://stackoverflow.com/questions/25262754/how-to-get-name-of-current-package-in-go
+	dotIdx := 0
+	for j := len(name) - 1; j >= 0; j-- {
+		if name[j] == '.' {
+			dotIdx = j
+		} else if name[j] == '/' {
+			break
+		}
+	}
+	return name[:dotIdx]
+}
+
+// stackTrace returns the stack trace of the caller, skipping the frames that f filters out.
+// skip is the number of frames to skip, in the same manner as runtime.Callers.
+func stackTrace(skip int, f Filter) string {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(skip+1, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		name := frame.Function
+		if name != "" && !strings.HasPrefix(name, "runtime.") && doOutputFrame(f, name, frame.File) {
+			b.WriteString(name)
+			b.WriteString("\n\t")
+			b.WriteString(frame.File)
+			b.WriteString(":")
+			b.WriteString(strconv.Itoa(frame.Line))
+			b.WriteString("\n")
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
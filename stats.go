@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"database/sql/driver"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the statistics of a Proxy.
+type Stats struct {
+	// Operations is the statistics per operation kind.
+	// Operations that have never been called are omitted.
+	Operations map[Operation]OperationStats
+
+	// HookSets is the statistics of the callbacks per hook set.
+	// It is reported only by the proxies created by Proxy.WithHookTiming.
+	HookSets []HookSetStats
+}
+
+// OperationStats is the statistics of an operation kind.
+type OperationStats struct {
+	// Count is the number of calls.
+	Count int64
+
+	// Errors is the number of calls that returned errors.
+	Errors int64
+
+	// TotalDuration is the total duration of the calls.
+	TotalDuration time.Duration
+
+	// MaxDuration is the maximum duration of the calls.
+	MaxDuration time.Duration
+
+	// Prepared is the number of the calls executed by prepared statements.
+	// It is counted only for Exec and Query.
+	Prepared int64
+
+	// Reused is the number of the calls that reused prepared statements,
+	// i.e. the calls executed by prepared statements that had been executed before.
+	// Prepared - Reused is the number of the prepared statements executed at least once.
+	Reused int64
+}
+
+// MeanDuration returns the mean duration of the calls.
+func (s OperationStats) MeanDuration() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalDuration / time.Duration(s.Count)
+}
+
+// operationCounters is the counters of an operation kind.
+// All fields are updated atomically.
+type operationCounters struct {
+	count    int64
+	errors   int64
+	total    int64
+	maxNanos int64
+	prepared int64
+	reused   int64
+}
+
+// proxyStats is the built-in lightweight counters of Proxy.
+type proxyStats struct {
+	ops [numOperations]operationCounters
+
+	// the counters published by Proxy.PublishExpvar.
+	slowNanos int64 // the threshold of slow queries
+	slow      int64 // the number of slow queries
+	openConns int64 // the number of open connections
+}
+
+// observe records a call of op which started at start.
+// It is intended to be deferred, so it takes a pointer to the error.
+// The calls that fail with driver.ErrSkip are not recorded,
+// because database/sql retries them in another way, which is recorded instead.
+func (s *proxyStats) observe(op Operation, start time.Time, err *error) {
+	if *err == driver.ErrSkip {
+		return
+	}
+	d := int64(time.Since(start))
+	c := &s.ops[op]
+	atomic.AddInt64(&c.count, 1)
+	if *err != nil {
+		atomic.AddInt64(&c.errors, 1)
+	}
+	atomic.AddInt64(&c.total, d)
+	switch op {
+	case OpExec, OpQuery:
+		if slow := atomic.LoadInt64(&s.slowNanos); slow > 0 && d >= slow {
+			atomic.AddInt64(&s.slow, 1)
+		}
+	}
+	for {
+		max := atomic.LoadInt64(&c.maxNanos)
+		if d <= max || atomic.CompareAndSwapInt64(&c.maxNanos, max, d) {
+			break
+		}
+	}
+}
+
+// observeStmt is the same as observe, but it also records that op is executed by the prepared statement stmt.
+func (s *proxyStats) observeStmt(op Operation, stmt *Stmt, start time.Time, err *error) {
+	s.observe(op, start, err)
+	if *err == driver.ErrSkip {
+		return
+	}
+	c := &s.ops[op]
+	atomic.AddInt64(&c.prepared, 1)
+	if stmt.uses > 1 {
+		atomic.AddInt64(&c.reused, 1)
+	}
+}
+
+// countOpen records that conn is open.
+// conn remembers s, so that closing it decrements the open connections of s and no others.
+func (s *proxyStats) countOpen(conn *Conn) {
+	conn.openStats = s
+	atomic.AddInt64(&s.openConns, 1)
+}
+
+// countClose records that conn is closed.
+// It does nothing if the open of conn is not recorded, e.g. conn is not opened by a Proxy.
+func (conn *Conn) countClose() {
+	if s := conn.openStats; s != nil {
+		conn.openStats = nil
+		atomic.AddInt64(&s.openConns, -1)
+	}
+}
+
+func (s *proxyStats) snapshot() Stats {
+	ops := make(map[Operation]OperationStats)
+	for i := range s.ops {
+		c := &s.ops[i]
+		count := atomic.LoadInt64(&c.count)
+		if count == 0 {
+			continue
+		}
+		ops[Operation(i)] = OperationStats{
+			Count:         count,
+			Errors:        atomic.LoadInt64(&c.errors),
+			TotalDuration: time.Duration(atomic.LoadInt64(&c.total)),
+			MaxDuration:   time.Duration(atomic.LoadInt64(&c.maxNanos)),
+			Prepared:      atomic.LoadInt64(&c.prepared),
+			Reused:        atomic.LoadInt64(&c.reused),
+		}
+	}
+	return Stats{
+		Operations: ops,
+	}
+}
+
+// Stats returns a snapshot of the statistics of p.
+// The statistics are collected regardless of the hooks,
+// and they are useful for health check endpoints.
+func (p *Proxy) Stats() Stats {
+	stats := p.stats.snapshot()
+	stats.HookSets = p.hookSetStats()
+	return stats
+}
@@ -0,0 +1,380 @@
+package proxy
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultStatsSampleSize is the default of StatsOptions.SampleSize.
+const defaultStatsSampleSize = 1024
+
+// defaultStatsMaxFingerprints is the default of StatsOptions.MaxFingerprints.
+const defaultStatsMaxFingerprints = 1000
+
+// defaultStatsPercentiles is the default of StatsOptions.Percentiles.
+var defaultStatsPercentiles = []float64{50, 90, 99}
+
+// StatsOptions holds the options of NewStatsHooks.
+type StatsOptions struct {
+	// SampleSize is the number of the latest durations kept per operation kind
+	// to calculate the percentiles.
+	// If it is zero, 1024 is used.
+	SampleSize int
+
+	// Percentiles is the percentiles of the durations reported by the snapshots, e.g. 50 and 99.9.
+	// If it is nil, 50, 90 and 99 are used.
+	Percentiles []float64
+
+	// Fingerprints enables the statistics of Exec and Query per query fingerprint,
+	// which is normalized by Fingerprint, like pg_stat_statements of PostgreSQL.
+	Fingerprints bool
+
+	// MaxFingerprints is the maximum number of the fingerprints to aggregate.
+	// If a new fingerprint comes when the limit is reached, the least called one is discarded.
+	// If it is zero, 1000 is used.
+	MaxFingerprints int
+
+	// Labels is the keys of the labels associated by WithLabels, e.g. "tenant",
+	// to aggregate the statistics per label value.
+	Labels []string
+
+	// MaxLabelValues is the maximum number of the distinct values per label key.
+	// The other values are aggregated as OverflowLabelValue.
+	// If it is zero, 100 is used.
+	MaxLabelValues int
+}
+
+// StatsSnapshot is a snapshot of the statistics aggregated by StatsAggregator.
+type StatsSnapshot struct {
+	// Operations is the statistics per operation kind.
+	// Operations that have never been called are omitted.
+	Operations map[Operation]OperationSnapshot
+
+	// Queries is the statistics per query fingerprint sorted by TotalDuration in descending order.
+	// It is reported only if StatsOptions.Fingerprints is enabled.
+	Queries []QuerySnapshot
+
+	// Labels is the statistics per label value sorted by the key and the value.
+	// It is reported only for the keys of StatsOptions.Labels.
+	Labels []LabelSnapshot
+}
+
+// TopByTotalDuration returns at most n queries that took the longest time in total.
+func (s StatsSnapshot) TopByTotalDuration(n int) []QuerySnapshot {
+	return s.top(n, func(a, b *QuerySnapshot) bool {
+		return a.TotalDuration > b.TotalDuration
+	})
+}
+
+// TopByCalls returns at most n most called queries.
+func (s StatsSnapshot) TopByCalls(n int) []QuerySnapshot {
+	return s.top(n, func(a, b *QuerySnapshot) bool {
+		return a.Count > b.Count
+	})
+}
+
+// TopByErrorRate returns at most n queries with the highest error rates.
+// The queries that have never failed are omitted.
+func (s StatsSnapshot) TopByErrorRate(n int) []QuerySnapshot {
+	ret := s.top(n, func(a, b *QuerySnapshot) bool {
+		return a.ErrorRate() > b.ErrorRate()
+	})
+	for i, q := range ret {
+		if q.Errors == 0 {
+			return ret[:i]
+		}
+	}
+	return ret
+}
+
+func (s StatsSnapshot) top(n int, less func(a, b *QuerySnapshot) bool) []QuerySnapshot {
+	ret := append([]QuerySnapshot(nil), s.Queries...)
+	sort.SliceStable(ret, func(i, j int) bool {
+		return less(&ret[i], &ret[j])
+	})
+	if n >= 0 && len(ret) > n {
+		ret = ret[:n]
+	}
+	return ret
+}
+
+// OperationSnapshot is the statistics of an operation kind aggregated by StatsAggregator.
+type OperationSnapshot struct {
+	OperationStats
+
+	// Percentiles is the percentiles of the durations of the latest calls,
+	// keyed by StatsOptions.Percentiles.
+	Percentiles map[float64]time.Duration
+}
+
+// QuerySnapshot is the statistics of a query fingerprint aggregated by StatsAggregator.
+type QuerySnapshot struct {
+	// Fingerprint is the normalized query.
+	Fingerprint string
+
+	OperationSnapshot
+}
+
+// LabelSnapshot is the statistics of a label value aggregated by StatsAggregator.
+type LabelSnapshot struct {
+	// Key is the key of the label.
+	Key string
+
+	// Value is the value of the label.
+	Value string
+
+	// Operations is the statistics per operation kind of the calls with the label.
+	Operations map[Operation]OperationSnapshot
+}
+
+// ErrorRate returns the ratio of the failed calls.
+func (s QuerySnapshot) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Count)
+}
+
+// StatsAggregator aggregates the statistics of the operations in memory.
+// It is created by NewStatsHooks.
+type StatsAggregator struct {
+	sampleSize  int
+	percentiles []float64
+
+	// maxFingerprints is the limit of queries. It is zero if the fingerprints are disabled.
+	maxFingerprints int
+
+	// labels picks the labels to aggregate. It is nil if no labels are aggregated.
+	labels *LabelLimiter
+
+	mu      sync.Mutex
+	ops     map[Operation]*statsBucket
+	queries map[string]*statsBucket
+	byLabel map[statsLabel]map[Operation]*statsBucket
+}
+
+// statsLabel is a label value aggregated by StatsAggregator.
+type statsLabel struct {
+	key, value string
+}
+
+// statsBucket is the statistics of an operation kind or a query fingerprint.
+type statsBucket struct {
+	stats OperationStats
+
+	// samples is a ring buffer of the latest durations.
+	samples []time.Duration
+	next    int
+}
+
+// NewStatsHooks creates new HooksContext which aggregates the counts, the error counts
+// and the percentiles of the durations per operation kind,
+// and StatsAggregator which reports them, e.g. on the health check and debug endpoints of applications.
+// If StatsOptions.Fingerprints is enabled, Exec and Query are also aggregated per query fingerprint.
+// Unlike Proxy.Stats, the statistics are aggregated only while the hooks are installed.
+func NewStatsHooks(opt StatsOptions) (*HooksContext, *StatsAggregator) {
+	sampleSize := opt.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = defaultStatsSampleSize
+	}
+	percentiles := opt.Percentiles
+	if percentiles == nil {
+		percentiles = defaultStatsPercentiles
+	}
+	s := &StatsAggregator{
+		sampleSize:  sampleSize,
+		percentiles: append([]float64(nil), percentiles...),
+		ops:         make(map[Operation]*statsBucket),
+		queries:     make(map[string]*statsBucket),
+		byLabel:     make(map[statsLabel]map[Operation]*statsBucket),
+	}
+	if len(opt.Labels) > 0 {
+		s.labels = NewLabelLimiter(opt.Labels, opt.MaxLabelValues)
+	}
+	if opt.Fingerprints {
+		s.maxFingerprints = opt.MaxFingerprints
+		if s.maxFingerprints <= 0 {
+			s.maxFingerprints = defaultStatsMaxFingerprints
+		}
+	}
+	hooks := NewEventHooks(func(_ context.Context, e *Event) {
+		s.observe(e)
+	})
+	return hooks, s
+}
+
+func (s *StatsAggregator) observe(e *Event) {
+	var values []string
+	if s.labels != nil {
+		values = s.labels.Values(e.Labels)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	observeOperation(s.ops, e, s.sampleSize)
+	for i, v := range values {
+		if v == "" {
+			continue
+		}
+		l := statsLabel{key: s.labels.keys[i], value: v}
+		ops, ok := s.byLabel[l]
+		if !ok {
+			ops = make(map[Operation]*statsBucket)
+			s.byLabel[l] = ops
+		}
+		observeOperation(ops, e, s.sampleSize)
+	}
+
+	if s.maxFingerprints > 0 && (e.Operation == OpExec || e.Operation == OpQuery) {
+		s.observeQuery(e)
+	}
+}
+
+// observeOperation records e into the statistics of its operation kind in ops.
+func observeOperation(ops map[Operation]*statsBucket, e *Event, sampleSize int) {
+	b, ok := ops[e.Operation]
+	if !ok {
+		b = &statsBucket{}
+		ops[e.Operation] = b
+	}
+	b.observe(e, sampleSize)
+}
+
+// observeQuery records e into the statistics of its fingerprint.
+// s.mu must be held.
+func (s *StatsAggregator) observeQuery(e *Event) {
+	fp := Fingerprint(e.Query)
+	b, ok := s.queries[fp]
+	if !ok {
+		if len(s.queries) >= s.maxFingerprints {
+			s.evictQuery()
+		}
+		b = &statsBucket{}
+		s.queries[fp] = b
+	}
+	b.observe(e, s.sampleSize)
+}
+
+// evictQuery discards the least called fingerprint.
+// s.mu must be held.
+func (s *StatsAggregator) evictQuery() {
+	var victim string
+	var min int64 = math.MaxInt64
+	for fp, b := range s.queries {
+		if b.stats.Count < min || b.stats.Count == min && fp < victim {
+			victim, min = fp, b.stats.Count
+		}
+	}
+	delete(s.queries, victim)
+}
+
+func (b *statsBucket) observe(e *Event, sampleSize int) {
+	b.stats.Count++
+	if e.Error != "" {
+		b.stats.Errors++
+	}
+	b.stats.TotalDuration += e.Duration
+	if e.Duration > b.stats.MaxDuration {
+		b.stats.MaxDuration = e.Duration
+	}
+	if e.Uses > 0 {
+		b.stats.Prepared++
+		if e.Uses > 1 {
+			b.stats.Reused++
+		}
+	}
+	if len(b.samples) < sampleSize {
+		b.samples = append(b.samples, e.Duration)
+	} else {
+		b.samples[b.next] = e.Duration
+		b.next = (b.next + 1) % sampleSize
+	}
+}
+
+func (b *statsBucket) snapshot(percentiles []float64) OperationSnapshot {
+	samples := append([]time.Duration(nil), b.samples...)
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	ret := OperationSnapshot{
+		OperationStats: b.stats,
+		Percentiles:    make(map[float64]time.Duration, len(percentiles)),
+	}
+	for _, p := range percentiles {
+		ret.Percentiles[p] = percentile(samples, p)
+	}
+	return ret
+}
+
+// percentile returns the p-th percentile of sorted by the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// Snapshot returns a snapshot of the statistics aggregated so far.
+func (s *StatsAggregator) Snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var labels []LabelSnapshot
+	for l, ops := range s.byLabel {
+		labels = append(labels, LabelSnapshot{
+			Key:        l.key,
+			Value:      l.value,
+			Operations: s.snapshotOperations(ops),
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Key != labels[j].Key {
+			return labels[i].Key < labels[j].Key
+		}
+		return labels[i].Value < labels[j].Value
+	})
+	var queries []QuerySnapshot
+	for fp, b := range s.queries {
+		queries = append(queries, QuerySnapshot{
+			Fingerprint:       fp,
+			OperationSnapshot: b.snapshot(s.percentiles),
+		})
+	}
+	sort.Slice(queries, func(i, j int) bool {
+		if queries[i].TotalDuration != queries[j].TotalDuration {
+			return queries[i].TotalDuration > queries[j].TotalDuration
+		}
+		return queries[i].Fingerprint < queries[j].Fingerprint
+	})
+	return StatsSnapshot{
+		Operations: s.snapshotOperations(s.ops),
+		Queries:    queries,
+		Labels:     labels,
+	}
+}
+
+// snapshotOperations returns the snapshot of ops.
+// s.mu must be held.
+func (s *StatsAggregator) snapshotOperations(ops map[Operation]*statsBucket) map[Operation]OperationSnapshot {
+	ret := make(map[Operation]OperationSnapshot, len(ops))
+	for op, b := range ops {
+		ret[op] = b.snapshot(s.percentiles)
+	}
+	return ret
+}
+
+// Reset discards the statistics aggregated so far.
+func (s *StatsAggregator) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops = make(map[Operation]*statsBucket)
+	s.queries = make(map[string]*statsBucket)
+	s.byLabel = make(map[statsLabel]map[Operation]*statsBucket)
+}
@@ -0,0 +1,214 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewStatsHooks(t *testing.T) {
+	hooks, stats := NewStatsHooks(StatsOptions{})
+	sql.Register("fakedb-stats-hooks", NewProxyContext(fdriver, hooks))
+	db, err := sql.Open("fakedb-stats-hooks", `{"Name":"stats-hooks","ConnType":"fakeConnCtx","FailQuery":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?)", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.QueryContext(ctx, "SELECT id FROM t1"); err == nil {
+		t.Fatal("want error, got nil")
+	}
+
+	snapshot := stats.Snapshot()
+	tests := []struct {
+		op     Operation
+		count  int64
+		errors int64
+	}{
+		{OpOpen, 1, 0},
+		{OpExec, 3, 0},
+		{OpQuery, 1, 1},
+	}
+	for _, tt := range tests {
+		s := snapshot.Operations[tt.op]
+		if s.Count != tt.count || s.Errors != tt.errors {
+			t.Errorf("%v: want count = %d, errors = %d, got %#v", tt.op, tt.count, tt.errors, s)
+		}
+		if len(s.Percentiles) != 3 {
+			t.Errorf("%v: want 3 percentiles, got %v", tt.op, s.Percentiles)
+		}
+		if s.Percentiles[99] > s.MaxDuration {
+			t.Errorf("%v: inconsistent durations: %#v", tt.op, s)
+		}
+	}
+	if _, ok := snapshot.Operations[OpBegin]; ok {
+		t.Error("operations that have never been called should be omitted")
+	}
+
+	stats.Reset()
+	if n := len(stats.Snapshot().Operations); n != 0 {
+		t.Errorf("want no statistics after reset, got %d", n)
+	}
+}
+
+func TestStatsAggregator_Percentiles(t *testing.T) {
+	_, stats := NewStatsHooks(StatsOptions{
+		SampleSize:  100,
+		Percentiles: []float64{0, 50, 99, 100},
+	})
+	// the first 100 calls are evicted from the samples.
+	for i := 1; i <= 200; i++ {
+		stats.observe(&Event{Operation: OpExec, Duration: time.Duration(i) * time.Millisecond})
+	}
+
+	s := stats.Snapshot().Operations[OpExec]
+	if s.Count != 200 || s.MaxDuration != 200*time.Millisecond {
+		t.Errorf("unexpected stats: %#v", s.OperationStats)
+	}
+	want := map[float64]time.Duration{
+		0:   101 * time.Millisecond,
+		50:  150 * time.Millisecond,
+		99:  199 * time.Millisecond,
+		100: 200 * time.Millisecond,
+	}
+	for p, d := range want {
+		if got := s.Percentiles[p]; got != d {
+			t.Errorf("p%v: want %s, got %s", p, d, got)
+		}
+	}
+}
+
+func TestStatsAggregator_Fingerprints(t *testing.T) {
+	_, stats := NewStatsHooks(StatsOptions{Fingerprints: true})
+	observe := func(query string, d time.Duration, failed bool) {
+		e := &Event{Operation: OpExec, Query: query, Duration: d}
+		if failed {
+			e.Error = "failed"
+		}
+		stats.observe(e)
+	}
+	for i := 0; i < 5; i++ {
+		observe("SELECT * FROM t1 WHERE id = 1", time.Millisecond, false)
+	}
+	observe("SELECT * FROM t1 WHERE id = 2", time.Millisecond, true)
+	observe("UPDATE t1 SET name = 'a'", 100*time.Millisecond, false)
+	observe("DELETE FROM t1", 10*time.Millisecond, true)
+	observe("DELETE FROM t1", 10*time.Millisecond, false)
+	stats.observe(&Event{Operation: OpBegin})
+
+	snapshot := stats.Snapshot()
+	if len(snapshot.Queries) != 3 {
+		t.Fatalf("want 3 fingerprints, got %v", snapshot.Queries)
+	}
+	fingerprints := func(queries []QuerySnapshot) []string {
+		var ret []string
+		for _, q := range queries {
+			ret = append(ret, q.Fingerprint)
+		}
+		return ret
+	}
+	tests := []struct {
+		name string
+		got  []QuerySnapshot
+		want []string
+	}{
+		{"by total duration", snapshot.TopByTotalDuration(2), []string{"update t1 set name = ?", "delete from t1"}},
+		{"by calls", snapshot.TopByCalls(1), []string{"select * from t1 where id = ?"}},
+		{"by error rate", snapshot.TopByErrorRate(10), []string{"delete from t1", "select * from t1 where id = ?"}},
+	}
+	for _, tt := range tests {
+		got := fingerprints(tt.got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: want %q, got %q", tt.name, tt.want, got)
+		}
+	}
+	if q := snapshot.TopByCalls(1)[0]; q.Count != 6 || q.Errors != 1 {
+		t.Errorf("unexpected stats: %#v", q.OperationStats)
+	}
+}
+
+func TestStatsAggregator_MaxFingerprints(t *testing.T) {
+	_, stats := NewStatsHooks(StatsOptions{Fingerprints: true, MaxFingerprints: 2})
+	for _, query := range []string{"SELECT 1", "SELECT * FROM t1", "SELECT * FROM t1", "SELECT * FROM t2"} {
+		stats.observe(&Event{Operation: OpQuery, Query: query})
+	}
+	got := map[string]int64{}
+	for _, q := range stats.Snapshot().Queries {
+		got[q.Fingerprint] = q.Count
+	}
+	want := map[string]int64{"select * from t1": 2, "select * from t2": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestStatsAggregator_Labels(t *testing.T) {
+	_, stats := NewStatsHooks(StatsOptions{Labels: []string{"tenant"}, MaxLabelValues: 2})
+	for _, tenant := range []string{"acme", "acme", "globex", "initech", ""} {
+		e := &Event{Operation: OpExec}
+		if tenant != "" {
+			e.Labels = map[string]string{"tenant": tenant, "user": "alice"}
+		}
+		stats.observe(e)
+	}
+	got := map[string]int64{}
+	for _, l := range stats.Snapshot().Labels {
+		if l.Key != "tenant" {
+			t.Errorf("unexpected key: %q", l.Key)
+		}
+		got[l.Value] = l.Operations[OpExec].Count
+	}
+	want := map[string]int64{"acme": 2, "globex": 1, OverflowLabelValue: 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if n := stats.Snapshot().Operations[OpExec].Count; n != 5 {
+		t.Errorf("want 5 calls, got %d", n)
+	}
+}
+
+func TestNewStatsHooks_Prepared(t *testing.T) {
+	hooks, stats := NewStatsHooks(StatsOptions{})
+	sql.Register("fakedb-stats-hooks-prepared", NewProxyContext(fdriver, hooks))
+	db, err := sql.Open("fakedb-stats-hooks-prepared", `{"Name":"stats-hooks-prepared","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	stmt, err := db.Prepare("SELECT id FROM t1 WHERE id = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	for i := 0; i < 2; i++ {
+		rows, err := stmt.Query(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+	}
+	rows, err := db.Query("SELECT id FROM t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	snapshot := stats.Snapshot()
+	if s := snapshot.Operations[OpPrepare]; s.Count != 1 {
+		t.Errorf("want 1 prepare, got %#v", s)
+	}
+	if s := snapshot.Operations[OpQuery]; s.Count != 3 || s.Prepared != 2 || s.Reused != 1 {
+		t.Errorf("want count = 3, prepared = 2, reused = 1, got %#v", s)
+	}
+}
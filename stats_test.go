@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProxyStats(t *testing.T) {
+	p := NewProxyContext(fdriver)
+	sql.Register("fakedb-proxy-stats", p)
+	db, err := sql.Open("fakedb-proxy-stats", `{"Name":"proxy-stats","ConnType":"fakeConnCtx","FailQuery":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?)", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.QueryContext(ctx, "SELECT id FROM t1"); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := p.Stats()
+	tests := []struct {
+		op     Operation
+		count  int64
+		errors int64
+	}{
+		{OpOpen, 1, 0},
+		{OpExec, 2, 0},
+		{OpQuery, 1, 1},
+		{OpBegin, 1, 0},
+		{OpCommit, 1, 0},
+	}
+	for _, tt := range tests {
+		s := stats.Operations[tt.op]
+		if s.Count != tt.count || s.Errors != tt.errors {
+			t.Errorf("%v: want count = %d, errors = %d, got %#v", tt.op, tt.count, tt.errors, s)
+		}
+		if s.MaxDuration > s.TotalDuration || s.MeanDuration() > s.MaxDuration {
+			t.Errorf("%v: inconsistent durations: %#v", tt.op, s)
+		}
+	}
+	if _, ok := stats.Operations[OpRollback]; ok {
+		t.Error("operations that have never been called should be omitted")
+	}
+}
+
+func TestProxyStats_observe(t *testing.T) {
+	var s proxyStats
+	var err error
+	s.observe(OpPing, time.Now(), &err)
+	err = errors.New("failed")
+	s.observe(OpPing, time.Now(), &err)
+	err = driver.ErrSkip
+	s.observe(OpPing, time.Now(), &err)
+
+	got := s.snapshot().Operations[OpPing]
+	if got.Count != 2 || got.Errors != 1 {
+		t.Errorf("unexpected stats: %#v", got)
+	}
+}
+
+func TestProxyStats_openConns(t *testing.T) {
+	p := NewProxyContext(fdriver)
+	dsn := `{"Name":"proxy-stats-open-conns","ConnType":"fakeConnCtx"}`
+
+	conn, err := p.Open(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt64(&p.stats.openConns); got != 1 {
+		t.Errorf("want 1 open connection, got %d", got)
+	}
+	conn.Close()
+	if got := atomic.LoadInt64(&p.stats.openConns); got != 0 {
+		t.Errorf("want 0 open connections, got %d", got)
+	}
+
+	connector, err := p.OpenConnector(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err = connector.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt64(&p.stats.openConns); got != 1 {
+		t.Errorf("want 1 open connection, got %d", got)
+	}
+	conn.Close()
+	if got := atomic.LoadInt64(&p.stats.openConns); got != 0 {
+		t.Errorf("want 0 open connections, got %d", got)
+	}
+
+	// the connections which are not opened by p are not counted, so closing them must not decrement the counter.
+	driverConn, err := fdriver.Open(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	myconn := &Conn{Conn: driverConn, Proxy: p}
+	myconn.Close()
+	if got := atomic.LoadInt64(&p.stats.openConns); got != 0 {
+		t.Errorf("want 0 open connections, got %d", got)
+	}
+}
+
+func TestProxyStats_prepared(t *testing.T) {
+	p := NewProxyContext(fdriver)
+	sql.Register("fakedb-proxy-stats-prepared", p)
+	db, err := sql.Open("fakedb-proxy-stats-prepared", `{"Name":"proxy-stats-prepared","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	stmt, err := db.Prepare("INSERT INTO t1 (id) VALUES(?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	for i := 0; i < 3; i++ {
+		if _, err := stmt.Exec(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := p.Stats()
+	if s := stats.Operations[OpPrepare]; s.Count != 1 {
+		t.Errorf("want 1 prepare, got %#v", s)
+	}
+	if s := stats.Operations[OpExec]; s.Count != 4 || s.Prepared != 3 || s.Reused != 2 {
+		t.Errorf("want count = 4, prepared = 3, reused = 2, got %#v", s)
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/shogo82148/go-sql-proxy/statsdproxy
+
+go 1.25.0
+
+require github.com/shogo82148/go-sql-proxy v0.8.0 // placeholder: needs an unreleased version of the root module, see README
@@ -0,0 +1,94 @@
+// Package statsdproxy emits the metrics of go-sql-proxy over StatsD, with the tags of DogStatsD.
+package statsdproxy
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+)
+
+// Options holds the options of NewHooks.
+type Options struct {
+	// Prefix is the prefix of the names of the metrics, e.g. "myapp.sql.".
+	Prefix string
+
+	// SampleRate is the rate of the operations to emit the metrics, between 0 and 1.
+	// If it is zero, all the operations are emitted.
+	SampleRate float64
+
+	// DogStatsD enables the tags of DogStatsD.
+	// The metrics have the "status" tag, which is "ok" or "error", and Tags.
+	DogStatsD bool
+
+	// Tags is the tags of all the metrics in the form of "key:value", e.g. "db:main".
+	// It is used only if DogStatsD is true.
+	Tags []string
+}
+
+// NewHooks creates new HooksContext which emits the metrics of the operations to w,
+// which is usually a UDP connection to the StatsD server, e.g. net.Dial("udp", "127.0.0.1:8125").
+// w must be safe for concurrent use.
+//
+// The metrics are named after the operations, e.g. for Exec:
+//
+//   - Prefix + "exec.duration": the timing of the operations in milliseconds
+//   - Prefix + "exec.count": the counter of the operations
+//   - Prefix + "exec.errors": the counter of the failed operations
+//
+// The errors of w are ignored, because StatsD is a fire-and-forget protocol.
+func NewHooks(w io.Writer, opt Options) *proxy.HooksContext {
+	rate := opt.SampleRate
+	if rate <= 0 || rate > 1 {
+		rate = 1
+	}
+	var suffix string
+	if rate < 1 {
+		suffix = "|@" + strconv.FormatFloat(rate, 'f', -1, 64)
+	}
+	tags := strings.Join(opt.Tags, ",")
+
+	return proxy.NewEventHooks(func(_ context.Context, e *proxy.Event) {
+		if rate < 1 && rand.Float64() >= rate {
+			return
+		}
+		name := opt.Prefix + strings.ToLower(e.Operation.String())
+		var tagSuffix string
+		if opt.DogStatsD {
+			status := "status:ok"
+			if e.Error != "" {
+				status = "status:error"
+			}
+			if tags != "" {
+				tagSuffix = "|#" + tags + "," + status
+			} else {
+				tagSuffix = "|#" + status
+			}
+		}
+
+		var b strings.Builder
+		b.WriteString(name)
+		b.WriteString(".duration:")
+		b.WriteString(strconv.FormatFloat(float64(e.Duration)/float64(time.Millisecond), 'f', -1, 64))
+		b.WriteString("|ms")
+		b.WriteString(suffix)
+		b.WriteString(tagSuffix)
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(".count:1|c")
+		b.WriteString(suffix)
+		b.WriteString(tagSuffix)
+		if e.Error != "" {
+			b.WriteString("\n")
+			b.WriteString(name)
+			b.WriteString(".errors:1|c")
+			b.WriteString(suffix)
+			b.WriteString(tagSuffix)
+		}
+		io.WriteString(w, b.String())
+	})
+}
@@ -0,0 +1,74 @@
+package statsdproxy
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+)
+
+type packetRecorder struct {
+	mu      sync.Mutex
+	packets []string
+}
+
+func (r *packetRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.packets = append(r.packets, string(p))
+	return len(p), nil
+}
+
+func exec(t *testing.T, h *proxy.HooksContext, err error) {
+	t.Helper()
+	c := context.Background()
+	stmt := &proxy.Stmt{QueryString: "INSERT INTO t1 (id) VALUES(1)"}
+	ctx, e := h.PreExec(c, stmt, nil)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e := h.PostExec(c, ctx, stmt, nil, nil, err); e != nil {
+		t.Fatal(e)
+	}
+}
+
+func TestNewHooks(t *testing.T) {
+	r := &packetRecorder{}
+	h := NewHooks(r, Options{Prefix: "myapp.sql."})
+	exec(t, h, nil)
+	exec(t, h, errors.New("exec failed"))
+
+	if len(r.packets) != 2 {
+		t.Fatalf("want 2 packets, got %q", r.packets)
+	}
+	ok := regexp.MustCompile(`^myapp\.sql\.exec\.duration:[0-9.]+\|ms\nmyapp\.sql\.exec\.count:1\|c$`)
+	if !ok.MatchString(r.packets[0]) {
+		t.Errorf("unexpected packet: %q", r.packets[0])
+	}
+	failed := regexp.MustCompile(`^myapp\.sql\.exec\.duration:[0-9.]+\|ms\nmyapp\.sql\.exec\.count:1\|c\nmyapp\.sql\.exec\.errors:1\|c$`)
+	if !failed.MatchString(r.packets[1]) {
+		t.Errorf("unexpected packet: %q", r.packets[1])
+	}
+}
+
+func TestNewHooks_DogStatsD(t *testing.T) {
+	r := &packetRecorder{}
+	h := NewHooks(r, Options{
+		DogStatsD:  true,
+		Tags:       []string{"db:main"},
+		SampleRate: 0.999999,
+	})
+	for len(r.packets) == 0 {
+		exec(t, h, errors.New("exec failed"))
+	}
+
+	re := regexp.MustCompile(`^exec\.duration:[0-9.]+\|ms\|@0\.999999\|#db:main,status:error\n` +
+		`exec\.count:1\|c\|@0\.999999\|#db:main,status:error\n` +
+		`exec\.errors:1\|c\|@0\.999999\|#db:main,status:error$`)
+	if !re.MatchString(r.packets[0]) {
+		t.Errorf("unexpected packet: %q", r.packets[0])
+	}
+}
@@ -3,6 +3,8 @@ package proxy
 import (
 	"context"
 	"database/sql/driver"
+	"fmt"
+	"time"
 )
 
 // Stmt adds hook points into "database/sql/driver".Stmt.
@@ -14,12 +16,104 @@ type Stmt struct {
 	QueryString string
 	Proxy       *Proxy
 	Conn        *Conn
+
+	uses      int64     // the number of executions
+	firstExec time.Time // the time of the first execution
+	lastExec  time.Time // the time of the last execution
+	prepared  bool      // the statement is created by Conn.PrepareContext
+	savepoint *Savepoint
+}
+
+// query returns the query of the statement, or an empty string if stmt is nil.
+func (stmt *Stmt) query() string {
+	if stmt == nil {
+		return ""
+	}
+	return stmt.QueryString
+}
+
+// conn returns the connection of the statement, or nil if stmt is nil.
+func (stmt *Stmt) conn() *Conn {
+	if stmt == nil {
+		return nil
+	}
+	return stmt.Conn
+}
+
+// Savepoint returns the savepoint statement that the statement executes.
+// It is available in the Exec hooks, and returns nil if the statement is not a savepoint statement.
+func (stmt *Stmt) Savepoint() *Savepoint {
+	return stmt.savepoint
+}
+
+// use records an execution of the statement.
+func (stmt *Stmt) use() {
+	now := time.Now()
+	stmt.uses++
+	if stmt.firstExec.IsZero() {
+		stmt.firstExec = now
+	}
+	stmt.lastExec = now
+}
+
+// preparedUses returns the number of executions of the statement if it is a prepared statement, or zero otherwise.
+func (stmt *Stmt) preparedUses() int64 {
+	if !stmt.prepared {
+		return 0
+	}
+	return stmt.uses
+}
+
+// Uses returns the number of executions of the statement, including the running one.
+// It is always 1 for the statements of Conn.ExecContext and Conn.QueryContext.
+func (stmt *Stmt) Uses() int64 {
+	return stmt.uses
+}
+
+// FirstExecTime returns the time when the statement was executed first.
+// It returns the zero time if the statement has never been executed.
+func (stmt *Stmt) FirstExecTime() time.Time {
+	return stmt.firstExec
+}
+
+// LastExecTime returns the time when the statement was executed last.
+// It returns the zero time if the statement has never been executed.
+func (stmt *Stmt) LastExecTime() time.Time {
+	return stmt.lastExec
+}
+
+// Prepared reports whether the statement is a prepared statement created by Conn.Prepare.
+// It is false in the Exec and Query hooks called by Conn.ExecContext and Conn.QueryContext,
+// which execute the query directly without preparing it.
+func (stmt *Stmt) Prepared() bool {
+	return stmt.prepared
 }
 
 // Close closes the statement.
-// It just calls the original Close method.
-func (stmt *Stmt) Close() error {
-	return stmt.Stmt.Close()
+// It will trigger PreStmtClose, StmtClose, PostStmtClose hooks.
+func (stmt *Stmt) Close() (err error) {
+	c := context.Background()
+	var ctx interface{}
+	start := time.Now()
+	defer stmt.Proxy.stats.observe(OpStmtClose, start, &err)
+
+	hooks := stmt.Proxy.hooksFor(OpStmtClose, stmt.Conn.connHooks())
+	if hooks != nil {
+		c = stmt.Conn.withMetadata(c)
+		defer func() { stmt.Proxy.postError(&err, hooks.postStmtClose(withDuration(c, start), ctx, stmt, err)) }()
+		if ctx, err = hooks.preStmtClose(c, stmt); err != nil {
+			return err
+		}
+	}
+
+	if err = stmt.Stmt.Close(); err != nil {
+		return err
+	}
+
+	if hooks != nil {
+		err = hooks.stmtClose(c, ctx, stmt)
+	}
+	return err
 }
 
 // NumInput returns the number of placeholder parameters.
@@ -30,39 +124,47 @@ func (stmt *Stmt) NumInput() int {
 
 // Exec executes a query that doesn't return rows.
 // It will trigger PreExec, Exec, PostExec hooks.
-// NOT SUPPORTED: use ExecContext instead
+// It is the same as ExecContext with the background context.
 func (stmt *Stmt) Exec(args []driver.Value) (driver.Result, error) {
-	panic("not supported")
+	return stmt.ExecContext(context.Background(), valuesToNamedValues(args))
 }
 
 // ExecContext executes a query that doesn't return rows.
 // It will trigger PreExec, Exec, PostExec hooks.
-func (stmt *Stmt) ExecContext(c context.Context, args []driver.NamedValue) (driver.Result, error) {
+func (stmt *Stmt) ExecContext(c context.Context, args []driver.NamedValue) (ret driver.Result, err error) {
+	stmt.use()
 	var ctx interface{}
-	var err error
 	var result driver.Result
-	hooks := stmt.Proxy.getHooks(c)
+	var spctx interface{}
+	start := time.Now()
+	defer stmt.Proxy.stats.observeStmt(OpExec, stmt, start, &err)
+	defer stmt.Proxy.wrapError(&err, OpExec, stmt.Conn, stmt.QueryString, args, start)
+	hooks := stmt.Proxy.getHooks(c, OpExec, stmt.Conn.connHooks())
 	if hooks != nil {
-		defer func() { hooks.postExec(c, ctx, stmt, args, result, err) }()
+		c = stmt.Conn.withMetadata(c)
+		if stmt.savepoint = ParseSavepoint(stmt.QueryString); stmt.savepoint != nil {
+			sp := stmt.savepoint
+			defer func() {
+				if stmt.Proxy.postError(&err, hooks.postSavepoint(withDuration(c, start), spctx, stmt, sp, err)) {
+					ret = nil
+				}
+			}()
+			if spctx, err = hooks.preSavepoint(c, stmt, sp); err != nil {
+				return nil, err
+			}
+		}
+		defer func() {
+			if stmt.Proxy.postError(&err, hooks.postExec(withDuration(c, start), ctx, stmt, args, result, err)) {
+				ret = nil
+			}
+		}()
+		defer func() { notifyCanceled(c, hooks, OpExec, start, err) }()
 		if ctx, err = hooks.preExec(c, stmt, args); err != nil {
 			return nil, err
 		}
 	}
 
-	if execerContext, ok := stmt.Stmt.(driver.StmtExecContext); ok {
-		result, err = execerContext.ExecContext(c, args)
-	} else {
-		select {
-		default:
-		case <-c.Done():
-			return nil, c.Err()
-		}
-		dargs, err0 := namedValuesToValues(args)
-		if err0 != nil {
-			return nil, err0
-		}
-		result, err = stmt.Stmt.Exec(dargs)
-	}
+	result, err = stmt.Proxy.interceptors.exec(execStmt)(c, stmt, args)
 	if err != nil {
 		return result, err
 	}
@@ -71,6 +173,14 @@ func (stmt *Stmt) ExecContext(c context.Context, args []driver.NamedValue) (driv
 		if err = hooks.exec(c, ctx, stmt, args, result); err != nil {
 			return result, err
 		}
+		if stmt.savepoint != nil {
+			if err = hooks.savepoint(c, spctx, stmt, stmt.savepoint); err != nil {
+				return result, err
+			}
+		}
+		if result != nil {
+			return newResult(c, hooks, stmt, result), nil
+		}
 	}
 
 	return result, nil
@@ -78,47 +188,51 @@ func (stmt *Stmt) ExecContext(c context.Context, args []driver.NamedValue) (driv
 
 // Query executes a query that may return rows.
 // It wil trigger PreQuery, Query, PostQuery hooks.
-// NOT SUPPORTED: use QueryContext instead
+// It is the same as QueryContext with the background context.
 func (stmt *Stmt) Query(args []driver.Value) (driver.Rows, error) {
-	panic("not supported")
+	return stmt.QueryContext(context.Background(), valuesToNamedValues(args))
 }
 
 // QueryContext executes a query that may return rows.
 // It wil trigger PreQuery, Query, PostQuery hooks.
-func (stmt *Stmt) QueryContext(c context.Context, args []driver.NamedValue) (driver.Rows, error) {
+func (stmt *Stmt) QueryContext(c context.Context, args []driver.NamedValue) (ret driver.Rows, err error) {
+	stmt.use()
 	var ctx interface{}
-	var err error
 	var rows driver.Rows
-	hooks := stmt.Proxy.getHooks(c)
+	start := time.Now()
+	defer stmt.Proxy.stats.observeStmt(OpQuery, stmt, start, &err)
+	defer stmt.Proxy.wrapError(&err, OpQuery, stmt.Conn, stmt.QueryString, args, start)
+	hooks := stmt.Proxy.getHooks(c, OpQuery, stmt.Conn.connHooks())
 	if hooks != nil {
-		defer func() { hooks.postQuery(c, ctx, stmt, args, rows, err) }()
+		c = stmt.Conn.withMetadata(c)
+		defer func() {
+			if stmt.Proxy.postError(&err, hooks.postQuery(withDuration(c, start), ctx, stmt, args, rows, err)) {
+				rows.Close()
+				ret = nil
+			}
+		}()
+		defer func() { notifyCanceled(c, hooks, OpQuery, start, err) }()
 		if ctx, err = hooks.preQuery(c, stmt, args); err != nil {
 			return nil, err
 		}
 	}
 
-	if queryCtx, ok := stmt.Stmt.(driver.StmtQueryContext); ok {
-		rows, err = queryCtx.QueryContext(c, args)
-	} else {
-		select {
-		default:
-		case <-c.Done():
-			return nil, c.Err()
-		}
-		dargs, err0 := namedValuesToValues(args)
-		if err0 != nil {
-			return nil, err0
-		}
-		rows, err = stmt.Stmt.Query(dargs)
-	}
+	rows, err = stmt.Proxy.interceptors.query(queryStmt)(c, stmt, args)
 	if err != nil {
 		return nil, err
 	}
 
 	if hooks != nil {
 		if err = hooks.query(c, ctx, stmt, args, rows); err != nil {
+			rows.Close()
 			return nil, err
 		}
+		myrows := newRows(c, hooks, stmt, args, rows, start)
+		if err = hooks.columns(c, myrows); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		return myrows.driverRows(), nil
 	}
 
 	return rows, nil
@@ -127,11 +241,16 @@ func (stmt *Stmt) QueryContext(c context.Context, args []driver.NamedValue) (dri
 // ColumnConverter returns a ValueConverter for the provided column index.
 // If the original statement does not satisfy ColumnConverter,
 // it returns driver.DefaultParameterConverter.
+// The ColumnConverter hooks can override the converter.
 func (stmt *Stmt) ColumnConverter(idx int) driver.ValueConverter {
-	if conv, ok := stmt.Stmt.(driver.ColumnConverter); ok {
-		return conv.ColumnConverter(idx)
+	var conv driver.ValueConverter = driver.DefaultParameterConverter
+	if cc, ok := stmt.Stmt.(driver.ColumnConverter); ok {
+		conv = cc.ColumnConverter(idx)
 	}
-	return driver.DefaultParameterConverter
+	if hooks := stmt.Proxy.baseHooks(stmt.Conn.connHooks()); hooks != nil {
+		conv = hooks.columnConverter(stmt, idx, conv)
+	}
+	return conv
 }
 
 // CheckNamedValue for implementing NamedValueChecker
@@ -146,6 +265,37 @@ func (stmt *Stmt) CheckNamedValue(nv *driver.NamedValue) (err error) {
 	if nvc, ok := stmt.Conn.Conn.(namedValueChecker); ok {
 		return nvc.CheckNamedValue(nv)
 	}
-	// fallback to default
+	// fallback to the column converter.
+	// database/sql doesn't call ColumnConverter, because `proxy.Stmt` implements `CheckNamedValue`,
+	// so we call it instead of database/sql, in the same conditions as database/sql does.
+	if _, ok := stmt.Stmt.(driver.ColumnConverter); ok && useColumnConverter(stmt.Stmt.NumInput(), nv.Ordinal-1) {
+		return checkNamedValueWithConverter(nv, stmt.ColumnConverter(nv.Ordinal-1))
+	}
 	return defaultCheckNamedValue(nv)
 }
+
+// checkNamedValueWithConverter converts nv by conv in the same way as database/sql does for ColumnConverter.
+func checkNamedValueWithConverter(nv *driver.NamedValue, conv driver.ValueConverter) (err error) {
+	if conv == driver.DefaultParameterConverter {
+		return defaultCheckNamedValue(nv)
+	}
+	arg := nv.Value
+	if vr, ok := arg.(driver.Valuer); ok {
+		sv, err := vr.Value()
+		if err != nil {
+			return err
+		}
+		if !driver.IsValue(sv) {
+			return fmt.Errorf("non-subset type %T returned from Value", sv)
+		}
+		arg = sv
+	}
+	nv.Value, err = conv.ConvertValue(arg)
+	if err != nil {
+		return err
+	}
+	if !driver.IsValue(nv.Value) {
+		return fmt.Errorf("driver ColumnConverter error converted %T to unsupported type %T", arg, nv.Value)
+	}
+	return nil
+}
@@ -1,8 +1,240 @@
 package proxy
 
-import "database/sql/driver"
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
 
 var _ driver.Stmt = &Stmt{}
 var _ driver.StmtExecContext = &Stmt{}
 var _ driver.StmtQueryContext = &Stmt{}
 var _ namedValueChecker = &Stmt{}
+
+func TestStmtCloseHooks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-stmt-close-hooks", NewProxyContext(fdriver, &HooksContext{
+		PreStmtClose: func(c context.Context, stmt *Stmt) (interface{}, error) {
+			connID, _ := ConnIDFromContext(c)
+			fmt.Fprintf(buf, "[PreStmtClose] %s %t\n", stmt.QueryString, connID != 0)
+			return nil, nil
+		},
+		StmtClose: func(c context.Context, ctx interface{}, stmt *Stmt) error {
+			fmt.Fprintln(buf, "[StmtClose]")
+			return nil
+		},
+		PostStmtClose: func(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+			fmt.Fprintln(buf, "[PostStmtClose]", err)
+			return nil
+		},
+	}))
+	db, err := sql.Open("fakedb-stmt-close-hooks", `{"Name":"stmt-close-hooks","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stmt.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "[PreStmtClose] SELECT 1 true\n[StmtClose]\n[PostStmtClose] <nil>\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestStmtPrepared(t *testing.T) {
+	var got []string
+	sql.Register("fakedb-stmt-prepared", NewProxyContext(fdriver, &HooksContext{
+		PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			got = append(got, fmt.Sprintf("Exec %t", stmt.Prepared()))
+			return nil
+		},
+		PostQuery: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+			got = append(got, fmt.Sprintf("Query %t", stmt.Prepared()))
+			return nil
+		},
+	}))
+	db, err := sql.Open("fakedb-stmt-prepared", `{"Name":"stmt-prepared","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("SELECT id FROM t1 WHERE id = ?", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	stmt, err := db.Prepare("INSERT INTO t1 (id) VALUES(?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err := stmt.Exec(2); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Exec false", "Query false", "Exec true"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestStmtUses(t *testing.T) {
+	type record struct {
+		uses      int64
+		sameTimes bool
+	}
+	var got []record
+	sql.Register("fakedb-stmt-uses", NewProxyContext(fdriver, &HooksContext{
+		PreExec: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+			got = append(got, record{
+				uses:      stmt.Uses(),
+				sameTimes: stmt.FirstExecTime().Equal(stmt.LastExecTime()),
+			})
+			return nil, nil
+		},
+	}))
+	db, err := sql.Open("fakedb-stmt-uses", `{"Name":"stmt-uses","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	stmt, err := db.Prepare("INSERT INTO t1 (id) VALUES(?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	for i := 0; i < 2; i++ {
+		time.Sleep(time.Millisecond)
+		if _, err := stmt.Exec(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []record{
+		{uses: 1, sameTimes: true},
+		{uses: 1, sameTimes: true},
+		{uses: 2, sameTimes: false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+type stringConverter struct {
+	converted []driver.Value
+}
+
+func (c *stringConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	c.converted = append(c.converted, v)
+	return driver.String.ConvertValue(v)
+}
+
+func TestColumnConverterHooks(t *testing.T) {
+	var indexes []int
+	conv := &stringConverter{}
+	sql.Register("fakedb-column-converter-hooks", NewProxyContext(fdriver, &HooksContext{
+		ColumnConverter: func(stmt *Stmt, idx int, original driver.ValueConverter) driver.ValueConverter {
+			indexes = append(indexes, idx)
+			return conv
+		},
+		PreExec: func(c context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
+			if v, ok := args[0].Value.(string); !ok || v != "1" {
+				t.Errorf("want the argument converted by the hook, got %#v", args[0].Value)
+			}
+			return nil, nil
+		},
+	}))
+	db, err := sql.Open("fakedb-column-converter-hooks", `{"Name":"column-converter-hooks","ConnType":"fakeConnCtx","NumInput":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("INSERT INTO t1 (id) VALUES(?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err := stmt.Exec(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{0}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("want %v, got %v", want, indexes)
+	}
+	if want := []driver.Value{1}; !reflect.DeepEqual(conv.converted, want) {
+		t.Errorf("want %v, got %v", want, conv.converted)
+	}
+}
+
+type closeRecordingRows struct {
+	driver.Rows
+	closed bool
+}
+
+func (rows *closeRecordingRows) Close() error {
+	rows.closed = true
+	return rows.Rows.Close()
+}
+
+func TestStmtQueryHookError(t *testing.T) {
+	var rows *closeRecordingRows
+	errHook := errors.New("hook error")
+	p := NewProxyContext(fdriver, &HooksContext{
+		Query: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows) error {
+			return errHook
+		},
+	}).WithInterceptors(Interceptors{
+		Query: func(next QueryFunc) QueryFunc {
+			return func(c context.Context, stmt *Stmt, args []driver.NamedValue) (driver.Rows, error) {
+				r, err := next(c, stmt, args)
+				if err != nil {
+					return nil, err
+				}
+				rows = &closeRecordingRows{Rows: r}
+				return rows, nil
+			}
+		},
+	})
+	sql.Register("fakedb-stmt-query-hook-error", p)
+	db, err := sql.Open("fakedb-stmt-query-hook-error", `{"Name":"stmt-query-hook-error","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("SELECT id FROM t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+	if _, err := stmt.Query(); err != errHook {
+		t.Fatalf("want %v, got %v", errHook, err)
+	}
+	// the rows returned by the driver are closed.
+	if rows == nil || !rows.closed {
+		t.Errorf("want the rows closed, got %#v", rows)
+	}
+}
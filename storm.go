@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// OpenStormOptions holds the options of NewOpenStormHooks.
+type OpenStormOptions struct {
+	// Window is the length of the time window in which the opens are counted.
+	// If it is zero, one second is used.
+	Window time.Duration
+
+	// Threshold is the number of opens in a window regarded as a storm.
+	// If it is zero or negative, storms are never detected, and opens are never delayed.
+	Threshold int
+
+	// MaxBackoff is the maximum delay applied to the opens during a storm.
+	// The delay is jittered, and grows with the number of the opens exceeding Threshold.
+	// If it is zero, opens are never delayed.
+	MaxBackoff time.Duration
+
+	// OnStorm is called once per window when a storm is detected.
+	OnStorm func(c context.Context, s *OpenStorm)
+}
+
+// OpenStorm describes a burst of new connections, e.g. after a failover.
+type OpenStorm struct {
+	// Start is the start of the window.
+	Start time.Time
+
+	// Window is the length of the window.
+	Window time.Duration
+
+	// Opens is the number of opens in the window so far.
+	Opens int
+}
+
+// openStormDetector counts the opens in fixed time windows.
+type openStormDetector struct {
+	opt OpenStormOptions
+
+	mu       sync.Mutex
+	start    time.Time
+	opens    int
+	reported bool
+	rand     *rand.Rand
+}
+
+// NewOpenStormHooks creates new HooksContext which detects bursts of Open calls,
+// and optionally delays new connections with jittered backoff.
+// It protects databases from being overwhelmed right when they restart.
+func NewOpenStormHooks(opt OpenStormOptions) *HooksContext {
+	if opt.Window <= 0 {
+		opt.Window = time.Second
+	}
+	d := &openStormDetector{
+		opt:  opt,
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	return &HooksContext{
+		PreOpen: func(c context.Context, _ string) (interface{}, error) {
+			return nil, d.preOpen(c)
+		},
+	}
+}
+
+func (d *openStormDetector) preOpen(c context.Context) error {
+	storm, backoff := d.count(time.Now())
+	if storm != nil && d.opt.OnStorm != nil {
+		d.opt.OnStorm(c, storm)
+	}
+	if backoff <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-c.Done():
+		return c.Err()
+	}
+}
+
+// count counts an open at now.
+// It returns the storm to report and the delay to apply.
+func (d *openStormDetector) count(now time.Time) (*OpenStorm, time.Duration) {
+	if d.opt.Threshold <= 0 {
+		// the detection is disabled.
+		return nil, 0
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.start) >= d.opt.Window {
+		d.start = now
+		d.opens = 0
+		d.reported = false
+	}
+	d.opens++
+	excess := d.opens - d.opt.Threshold
+	if excess <= 0 {
+		return nil, 0
+	}
+
+	var storm *OpenStorm
+	if !d.reported {
+		d.reported = true
+		storm = &OpenStorm{
+			Start:  d.start,
+			Window: d.opt.Window,
+			Opens:  d.opens,
+		}
+	}
+
+	var backoff time.Duration
+	if d.opt.MaxBackoff > 0 {
+		limit := d.opt.MaxBackoff
+		if excess < d.opt.Threshold {
+			limit = limit * time.Duration(excess) / time.Duration(d.opt.Threshold)
+		}
+		if limit > 0 {
+			backoff = time.Duration(d.rand.Int63n(int64(limit)))
+		}
+	}
+	return storm, backoff
+}
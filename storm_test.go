@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOpenStormHooks(t *testing.T) {
+	var mu sync.Mutex
+	var storms []OpenStorm
+	sql.Register("fakedb-open-storm", NewProxyContext(fdriver, NewOpenStormHooks(OpenStormOptions{
+		Window:     time.Hour,
+		Threshold:  2,
+		MaxBackoff: time.Millisecond,
+		OnStorm: func(c context.Context, s *OpenStorm) {
+			mu.Lock()
+			defer mu.Unlock()
+			storms = append(storms, *s)
+		},
+	})))
+	db, err := sql.Open("fakedb-open-storm", `{"Name":"open-storm","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxIdleConns(0)
+
+	for i := 0; i < 4; i++ {
+		if err := db.Ping(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(storms) != 1 {
+		t.Fatalf("want a storm reported once, got %#v", storms)
+	}
+	if storms[0].Opens != 3 || storms[0].Window != time.Hour {
+		t.Errorf("unexpected storm: %#v", storms[0])
+	}
+}
+
+func TestOpenStormDetector_count(t *testing.T) {
+	d := &openStormDetector{
+		opt: OpenStormOptions{
+			Window:     time.Second,
+			Threshold:  1,
+			MaxBackoff: time.Second,
+		},
+		rand: rand.New(rand.NewSource(1)),
+	}
+	now := time.Now()
+	if storm, backoff := d.count(now); storm != nil || backoff != 0 {
+		t.Errorf("want no storm, got %#v, %v", storm, backoff)
+	}
+	if storm, backoff := d.count(now); storm == nil || backoff < 0 || backoff >= time.Second {
+		t.Errorf("want a storm, got %#v, %v", storm, backoff)
+	}
+	if storm, _ := d.count(now); storm != nil {
+		t.Errorf("a storm should be reported once per window, got %#v", storm)
+	}
+
+	// a new window starts.
+	if storm, backoff := d.count(now.Add(time.Second)); storm != nil || backoff != 0 {
+		t.Errorf("want no storm, got %#v, %v", storm, backoff)
+	}
+}
+
+func TestOpenStormDetector_count_disabled(t *testing.T) {
+	d := &openStormDetector{
+		opt: OpenStormOptions{
+			Window:     time.Second,
+			MaxBackoff: time.Second,
+		},
+		rand: rand.New(rand.NewSource(1)),
+	}
+	now := time.Now()
+	// the zero threshold disables the detection.
+	for i := 0; i < 10; i++ {
+		if storm, backoff := d.count(now); storm != nil || backoff != 0 {
+			t.Errorf("want no storm, got %#v, %v", storm, backoff)
+		}
+	}
+}
+
+func TestOpenStormHooks_canceled(t *testing.T) {
+	d := &openStormDetector{
+		opt: OpenStormOptions{
+			Window:     time.Hour,
+			Threshold:  1,
+			MaxBackoff: time.Hour,
+		},
+		rand: rand.New(rand.NewSource(1)),
+	}
+	if err := d.preOpen(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// the second open exceeds the threshold, so it is delayed until the context is canceled.
+	if err := d.preOpen(ctx); err != context.Canceled {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,665 @@
+package proxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// HookTimingOptions holds the options of Proxy.WithHookTiming.
+type HookTimingOptions struct {
+	// Budget is the duration that a callback of a hook set is allowed to spend.
+	// If it is zero, the budget is not checked.
+	Budget time.Duration
+
+	// OnBudgetExceeded is called when a callback spends more than Budget.
+	OnBudgetExceeded func(c context.Context, e *HookBudgetExceeded)
+
+	// Timeout is the duration that the proxy waits for a callback of a hook set.
+	// If a callback doesn't return in time, the timeout is recorded,
+	// and the proxy continues the operation as if the callback returned nil.
+	// If it is a Pre hook, the other callbacks of the hook set for the operation are skipped,
+	// because they can't get the context returned by the Pre hook.
+	// The callbacks that time out keep running in the background,
+	// and the original connection is closed after they return.
+	// If it is zero, the proxy waits for the callbacks forever.
+	//
+	// Note that the callbacks are called in other goroutines when Timeout is set.
+	// The panics of the callbacks are recovered, and returned as *HookPanicError.
+	// A callback that timed out runs concurrently with the following operations,
+	// which may use the same Conn, Stmt, Rows and Tx, so it must not touch them after the timeout.
+	// Copy the values it needs before doing anything slow.
+	Timeout time.Duration
+
+	// OnTimeout is called when a callback doesn't return within Timeout.
+	OnTimeout func(c context.Context, e *HookBudgetExceeded)
+}
+
+// HookBudgetExceeded describes a callback that spent more than the budget.
+type HookBudgetExceeded struct {
+	// HookSet is the hook set of the callback.
+	HookSet HookSetInfo
+
+	// Operation is the operation that triggered the callback.
+	Operation Operation
+
+	// Duration is the duration of the callback.
+	Duration time.Duration
+}
+
+// HookPanicError is the error returned when a callback panics.
+// It is returned only if HookTimingOptions.Timeout is set,
+// because the callbacks are called in other goroutines.
+type HookPanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+func (e *HookPanicError) Error() string {
+	return fmt.Sprintf("proxy: hook panicked: %v", e.Value)
+}
+
+// Unwrap returns Value if it is an error.
+func (e *HookPanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// HookSetStats is the statistics of the callbacks of a hook set.
+type HookSetStats struct {
+	// HookSet is the hook set.
+	HookSet HookSetInfo
+
+	// Operations is the statistics of the callbacks per operation kind.
+	// The Pre, main and Post callbacks of an operation are counted separately.
+	Operations map[Operation]OperationStats
+
+	// Timeouts is the number of the callbacks that didn't return within HookTimingOptions.Timeout.
+	Timeouts int64
+}
+
+// WithHookTiming returns a new Proxy that measures how long each hook set spends in its callbacks.
+// The measurements are reported by Proxy.Stats, so the overhead of instrumentation can be proved.
+// The slow callbacks can also be bounded by HookTimingOptions.Timeout.
+// The hook sets added later by With are also measured.
+// If p already measures the hook sets, opt replaces its options, and the measurements start over.
+// p is not modified.
+func (p *Proxy) WithHookTiming(opt HookTimingOptions) *Proxy {
+	np := p.clone()
+	np.hookTiming = &opt
+	np.hooks = np.timeHooks(np.hooks)
+	return np
+}
+
+// timeHooks wraps each hook set in h to measure it.
+func (p *Proxy) timeHooks(h hooks) hooks {
+	switch h := h.(type) {
+	case nil:
+		return nil
+	case *timingHooks:
+		if h.opt == p.hookTiming {
+			return h
+		}
+		return &timingHooks{
+			hooks: h.hooks,
+			opt:   p.hookTiming,
+		}
+	case multipleHooks:
+		hooksSlice := make([]hooks, 0, len(h))
+		for _, hk := range h {
+			hooksSlice = append(hooksSlice, p.timeHooks(hk))
+		}
+		return multipleHooks(hooksSlice)
+	}
+	return &timingHooks{
+		hooks: h,
+		opt:   p.hookTiming,
+	}
+}
+
+func (p *Proxy) hookSetStats() []HookSetStats {
+	var hookTimings []*timingHooks
+	switch h := p.loadHooks().(type) {
+	case *timingHooks:
+		hookTimings = append(hookTimings, h)
+	case multipleHooks:
+		for _, hk := range h {
+			if th, ok := hk.(*timingHooks); ok {
+				hookTimings = append(hookTimings, th)
+			}
+		}
+	}
+	if len(hookTimings) == 0 {
+		return nil
+	}
+	ret := make([]HookSetStats, 0, len(hookTimings))
+	for _, th := range hookTimings {
+		var info HookSetInfo
+		if infos := describeHooks(th.hooks); len(infos) > 0 {
+			info = infos[0]
+		}
+		ret = append(ret, HookSetStats{
+			HookSet:    info,
+			Operations: th.stats.snapshot().Operations,
+			Timeouts:   atomic.LoadInt64(&th.timeouts),
+		})
+	}
+	return ret
+}
+
+// timingHooks measures the callbacks of a hook set.
+type timingHooks struct {
+	hooks    hooks
+	opt      *HookTimingOptions
+	stats    proxyStats
+	timeouts int64
+}
+
+func (h *timingHooks) describe() []HookSetInfo {
+	return describeHooks(h.hooks)
+}
+
+func (h *timingHooks) priority() int {
+	return hooksPriority(h.hooks)
+}
+
+func (h *timingHooks) observe(c context.Context, op Operation, start time.Time, err *error) {
+	h.stats.observe(op, start, err)
+	if h.opt.Budget <= 0 || h.opt.OnBudgetExceeded == nil {
+		return
+	}
+	if d := time.Since(start); d > h.opt.Budget {
+		var info HookSetInfo
+		if infos := describeHooks(h.hooks); len(infos) > 0 {
+			info = infos[0]
+		}
+		h.opt.OnBudgetExceeded(c, &HookBudgetExceeded{
+			HookSet:   info,
+			Operation: op,
+			Duration:  d,
+		})
+	}
+}
+
+// hookTimedOut is the context passed from a Pre hook that timed out
+// to the following callbacks of the same hook set, so they are skipped.
+type hookTimedOut struct{}
+
+// call calls f, and waits for it within the timeout.
+// ctx is the context returned by the Pre hook, if any.
+// f keeps running in the background after the timeout, and conn is retired until it returns.
+func (h *timingHooks) call(c context.Context, op Operation, conn *Conn, ctx interface{}, f func() error) error {
+	if _, ok := ctx.(hookTimedOut); ok {
+		return nil
+	}
+	if h.opt.Timeout <= 0 {
+		return f()
+	}
+	done := make(chan error, 1)
+	release := conn.acquireCallback()
+	go func() {
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				err = &HookPanicError{Value: r, Stack: debug.Stack()}
+			}
+			release()
+			done <- err
+		}()
+		err = f()
+	}()
+	timer := time.NewTimer(h.opt.Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		h.timeout(c, op)
+		return nil
+	}
+}
+
+// callPre is the same as call, but for the Pre hooks.
+// On timeout, it returns hookTimedOut, so the following callbacks of the hook set are skipped.
+func (h *timingHooks) callPre(c context.Context, op Operation, conn *Conn, f func() (interface{}, error)) (interface{}, error) {
+	if h.opt.Timeout <= 0 {
+		return f()
+	}
+	type result struct {
+		ctx interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	release := conn.acquireCallback()
+	go func() {
+		var r result
+		defer func() {
+			if v := recover(); v != nil {
+				r.err = &HookPanicError{Value: v, Stack: debug.Stack()}
+			}
+			release()
+			done <- r
+		}()
+		r.ctx, r.err = f()
+	}()
+	timer := time.NewTimer(h.opt.Timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.ctx, r.err
+	case <-timer.C:
+		h.timeout(c, op)
+		return hookTimedOut{}, nil
+	}
+}
+
+func (h *timingHooks) timeout(c context.Context, op Operation) {
+	atomic.AddInt64(&h.timeouts, 1)
+	if h.opt.OnTimeout == nil {
+		return
+	}
+	var info HookSetInfo
+	if infos := describeHooks(h.hooks); len(infos) > 0 {
+		info = infos[0]
+	}
+	h.opt.OnTimeout(c, &HookBudgetExceeded{
+		HookSet:   info,
+		Operation: op,
+		Duration:  h.opt.Timeout,
+	})
+}
+
+func (h *timingHooks) prePing(c context.Context, conn *Conn) (ctx interface{}, err error) {
+	defer h.observe(c, OpPing, time.Now(), &err)
+	return h.callPre(c, OpPing, conn, func() (interface{}, error) {
+		return h.hooks.prePing(c, conn)
+	})
+}
+
+func (h *timingHooks) ping(c context.Context, ctx interface{}, conn *Conn) (err error) {
+	defer h.observe(c, OpPing, time.Now(), &err)
+	return h.call(c, OpPing, conn, ctx, func() error {
+		return h.hooks.ping(c, ctx, conn)
+	})
+}
+
+func (h *timingHooks) postPing(c context.Context, ctx interface{}, conn *Conn, opErr error) (err error) {
+	defer h.observe(c, OpPing, time.Now(), &err)
+	return h.call(c, OpPing, conn, ctx, func() error {
+		return h.hooks.postPing(c, ctx, conn, opErr)
+	})
+}
+
+func (h *timingHooks) preOpen(c context.Context, name string) (ctx interface{}, err error) {
+	defer h.observe(c, OpOpen, time.Now(), &err)
+	return h.callPre(c, OpOpen, nil, func() (interface{}, error) {
+		return h.hooks.preOpen(c, name)
+	})
+}
+
+func (h *timingHooks) open(c context.Context, ctx interface{}, conn *Conn) (err error) {
+	defer h.observe(c, OpOpen, time.Now(), &err)
+	return h.call(c, OpOpen, conn, ctx, func() error {
+		return h.hooks.open(c, ctx, conn)
+	})
+}
+
+func (h *timingHooks) postOpen(c context.Context, ctx interface{}, conn *Conn, opErr error) (err error) {
+	defer h.observe(c, OpOpen, time.Now(), &err)
+	return h.call(c, OpOpen, conn, ctx, func() error {
+		return h.hooks.postOpen(c, ctx, conn, opErr)
+	})
+}
+
+func (h *timingHooks) prePrepare(c context.Context, stmt *Stmt) (ctx interface{}, err error) {
+	defer h.observe(c, OpPrepare, time.Now(), &err)
+	return h.callPre(c, OpPrepare, stmt.conn(), func() (interface{}, error) {
+		return h.hooks.prePrepare(c, stmt)
+	})
+}
+
+func (h *timingHooks) prepare(c context.Context, ctx interface{}, stmt *Stmt) (err error) {
+	defer h.observe(c, OpPrepare, time.Now(), &err)
+	return h.call(c, OpPrepare, stmt.conn(), ctx, func() error {
+		return h.hooks.prepare(c, ctx, stmt)
+	})
+}
+
+func (h *timingHooks) postPrepare(c context.Context, ctx interface{}, stmt *Stmt, opErr error) (err error) {
+	defer h.observe(c, OpPrepare, time.Now(), &err)
+	return h.call(c, OpPrepare, stmt.conn(), ctx, func() error {
+		return h.hooks.postPrepare(c, ctx, stmt, opErr)
+	})
+}
+
+func (h *timingHooks) preExec(c context.Context, stmt *Stmt, args []driver.NamedValue) (ctx interface{}, err error) {
+	defer h.observe(c, OpExec, time.Now(), &err)
+	return h.callPre(c, OpExec, stmt.conn(), func() (interface{}, error) {
+		return h.hooks.preExec(c, stmt, args)
+	})
+}
+
+func (h *timingHooks) exec(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result) (err error) {
+	defer h.observe(c, OpExec, time.Now(), &err)
+	return h.call(c, OpExec, stmt.conn(), ctx, func() error {
+		return h.hooks.exec(c, ctx, stmt, args, result)
+	})
+}
+
+func (h *timingHooks) postExec(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, opErr error) (err error) {
+	defer h.observe(c, OpExec, time.Now(), &err)
+	return h.call(c, OpExec, stmt.conn(), ctx, func() error {
+		return h.hooks.postExec(c, ctx, stmt, args, result, opErr)
+	})
+}
+
+func (h *timingHooks) preQuery(c context.Context, stmt *Stmt, args []driver.NamedValue) (ctx interface{}, err error) {
+	defer h.observe(c, OpQuery, time.Now(), &err)
+	return h.callPre(c, OpQuery, stmt.conn(), func() (interface{}, error) {
+		return h.hooks.preQuery(c, stmt, args)
+	})
+}
+
+func (h *timingHooks) query(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows) (err error) {
+	defer h.observe(c, OpQuery, time.Now(), &err)
+	return h.call(c, OpQuery, stmt.conn(), ctx, func() error {
+		return h.hooks.query(c, ctx, stmt, args, rows)
+	})
+}
+
+func (h *timingHooks) postQuery(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, opErr error) (err error) {
+	defer h.observe(c, OpQuery, time.Now(), &err)
+	return h.call(c, OpQuery, stmt.conn(), ctx, func() error {
+		return h.hooks.postQuery(c, ctx, stmt, args, rows, opErr)
+	})
+}
+
+func (h *timingHooks) preBegin(c context.Context, conn *Conn) (ctx interface{}, err error) {
+	defer h.observe(c, OpBegin, time.Now(), &err)
+	return h.callPre(c, OpBegin, conn, func() (interface{}, error) {
+		return h.hooks.preBegin(c, conn)
+	})
+}
+
+func (h *timingHooks) begin(c context.Context, ctx interface{}, conn *Conn) (err error) {
+	defer h.observe(c, OpBegin, time.Now(), &err)
+	return h.call(c, OpBegin, conn, ctx, func() error {
+		return h.hooks.begin(c, ctx, conn)
+	})
+}
+
+func (h *timingHooks) postBegin(c context.Context, ctx interface{}, conn *Conn, opErr error) (err error) {
+	defer h.observe(c, OpBegin, time.Now(), &err)
+	return h.call(c, OpBegin, conn, ctx, func() error {
+		return h.hooks.postBegin(c, ctx, conn, opErr)
+	})
+}
+
+func (h *timingHooks) preCommit(c context.Context, tx *Tx) (ctx interface{}, err error) {
+	defer h.observe(c, OpCommit, time.Now(), &err)
+	return h.callPre(c, OpCommit, tx.Conn, func() (interface{}, error) {
+		return h.hooks.preCommit(c, tx)
+	})
+}
+
+func (h *timingHooks) commit(c context.Context, ctx interface{}, tx *Tx) (err error) {
+	defer h.observe(c, OpCommit, time.Now(), &err)
+	return h.call(c, OpCommit, tx.Conn, ctx, func() error {
+		return h.hooks.commit(c, ctx, tx)
+	})
+}
+
+func (h *timingHooks) postCommit(c context.Context, ctx interface{}, tx *Tx, opErr error) (err error) {
+	defer h.observe(c, OpCommit, time.Now(), &err)
+	return h.call(c, OpCommit, tx.Conn, ctx, func() error {
+		return h.hooks.postCommit(c, ctx, tx, opErr)
+	})
+}
+
+func (h *timingHooks) preRollback(c context.Context, tx *Tx) (ctx interface{}, err error) {
+	defer h.observe(c, OpRollback, time.Now(), &err)
+	return h.callPre(c, OpRollback, tx.Conn, func() (interface{}, error) {
+		return h.hooks.preRollback(c, tx)
+	})
+}
+
+func (h *timingHooks) rollback(c context.Context, ctx interface{}, tx *Tx) (err error) {
+	defer h.observe(c, OpRollback, time.Now(), &err)
+	return h.call(c, OpRollback, tx.Conn, ctx, func() error {
+		return h.hooks.rollback(c, ctx, tx)
+	})
+}
+
+func (h *timingHooks) postRollback(c context.Context, ctx interface{}, tx *Tx, opErr error) (err error) {
+	defer h.observe(c, OpRollback, time.Now(), &err)
+	return h.call(c, OpRollback, tx.Conn, ctx, func() error {
+		return h.hooks.postRollback(c, ctx, tx, opErr)
+	})
+}
+
+func (h *timingHooks) preClose(c context.Context, conn *Conn) (ctx interface{}, err error) {
+	defer h.observe(c, OpClose, time.Now(), &err)
+	return h.callPre(c, OpClose, conn, func() (interface{}, error) {
+		return h.hooks.preClose(c, conn)
+	})
+}
+
+func (h *timingHooks) close(c context.Context, ctx interface{}, conn *Conn) (err error) {
+	defer h.observe(c, OpClose, time.Now(), &err)
+	return h.call(c, OpClose, conn, ctx, func() error {
+		return h.hooks.close(c, ctx, conn)
+	})
+}
+
+func (h *timingHooks) postClose(c context.Context, ctx interface{}, conn *Conn, opErr error) (err error) {
+	defer h.observe(c, OpClose, time.Now(), &err)
+	return h.call(c, OpClose, conn, ctx, func() error {
+		return h.hooks.postClose(c, ctx, conn, opErr)
+	})
+}
+
+func (h *timingHooks) preResetSession(c context.Context, conn *Conn) (ctx interface{}, err error) {
+	defer h.observe(c, OpResetSession, time.Now(), &err)
+	return h.callPre(c, OpResetSession, conn, func() (interface{}, error) {
+		return h.hooks.preResetSession(c, conn)
+	})
+}
+
+func (h *timingHooks) resetSession(c context.Context, ctx interface{}, conn *Conn) (err error) {
+	defer h.observe(c, OpResetSession, time.Now(), &err)
+	return h.call(c, OpResetSession, conn, ctx, func() error {
+		return h.hooks.resetSession(c, ctx, conn)
+	})
+}
+
+func (h *timingHooks) postResetSession(c context.Context, ctx interface{}, conn *Conn, opErr error) (err error) {
+	defer h.observe(c, OpResetSession, time.Now(), &err)
+	return h.call(c, OpResetSession, conn, ctx, func() error {
+		return h.hooks.postResetSession(c, ctx, conn, opErr)
+	})
+}
+
+func (h *timingHooks) preIsValid(conn *Conn) (ctx interface{}, err error) {
+	defer h.observe(context.Background(), OpIsValid, time.Now(), &err)
+	return h.callPre(context.Background(), OpIsValid, conn, func() (interface{}, error) {
+		return h.hooks.preIsValid(conn)
+	})
+}
+
+func (h *timingHooks) isValid(ctx interface{}, conn *Conn) (err error) {
+	defer h.observe(context.Background(), OpIsValid, time.Now(), &err)
+	return h.call(context.Background(), OpIsValid, conn, ctx, func() error {
+		return h.hooks.isValid(ctx, conn)
+	})
+}
+
+func (h *timingHooks) postIsValid(ctx interface{}, conn *Conn, valid bool) (err error) {
+	defer h.observe(context.Background(), OpIsValid, time.Now(), &err)
+	return h.call(context.Background(), OpIsValid, conn, ctx, func() error {
+		return h.hooks.postIsValid(ctx, conn, valid)
+	})
+}
+
+func (h *timingHooks) preStmtClose(c context.Context, stmt *Stmt) (ctx interface{}, err error) {
+	defer h.observe(c, OpStmtClose, time.Now(), &err)
+	return h.callPre(c, OpStmtClose, stmt.conn(), func() (interface{}, error) {
+		return h.hooks.preStmtClose(c, stmt)
+	})
+}
+
+func (h *timingHooks) stmtClose(c context.Context, ctx interface{}, stmt *Stmt) (err error) {
+	defer h.observe(c, OpStmtClose, time.Now(), &err)
+	return h.call(c, OpStmtClose, stmt.conn(), ctx, func() error {
+		return h.hooks.stmtClose(c, ctx, stmt)
+	})
+}
+
+func (h *timingHooks) postStmtClose(c context.Context, ctx interface{}, stmt *Stmt, opErr error) (err error) {
+	defer h.observe(c, OpStmtClose, time.Now(), &err)
+	return h.call(c, OpStmtClose, stmt.conn(), ctx, func() error {
+		return h.hooks.postStmtClose(c, ctx, stmt, opErr)
+	})
+}
+
+func (h *timingHooks) preRowsClose(c context.Context, rows *Rows) (ctx interface{}, err error) {
+	defer h.observe(c, OpRowsClose, time.Now(), &err)
+	return h.callPre(c, OpRowsClose, rows.conn(), func() (interface{}, error) {
+		return h.hooks.preRowsClose(c, rows)
+	})
+}
+
+func (h *timingHooks) rowsClose(c context.Context, ctx interface{}, rows *Rows) (err error) {
+	defer h.observe(c, OpRowsClose, time.Now(), &err)
+	return h.call(c, OpRowsClose, rows.conn(), ctx, func() error {
+		return h.hooks.rowsClose(c, ctx, rows)
+	})
+}
+
+func (h *timingHooks) postRowsClose(c context.Context, ctx interface{}, rows *Rows, opErr error) (err error) {
+	defer h.observe(c, OpRowsClose, time.Now(), &err)
+	return h.call(c, OpRowsClose, rows.conn(), ctx, func() error {
+		return h.hooks.postRowsClose(c, ctx, rows, opErr)
+	})
+}
+
+func (h *timingHooks) postRows(c context.Context, rows *Rows, count int64, d time.Duration) (err error) {
+	defer h.observe(c, OpQuery, time.Now(), &err)
+	return h.call(c, OpQuery, rows.conn(), nil, func() error {
+		return h.hooks.postRows(c, rows, count, d)
+	})
+}
+
+func (h *timingHooks) postLastInsertId(c context.Context, result *Result, id int64, opErr error) (err error) {
+	defer h.observe(c, OpExec, time.Now(), &err)
+	return h.call(c, OpExec, result.conn(), nil, func() error {
+		return h.hooks.postLastInsertId(c, result, id, opErr)
+	})
+}
+
+func (h *timingHooks) postRowsAffected(c context.Context, result *Result, n int64, opErr error) (err error) {
+	defer h.observe(c, OpExec, time.Now(), &err)
+	return h.call(c, OpExec, result.conn(), nil, func() error {
+		return h.hooks.postRowsAffected(c, result, n, opErr)
+	})
+}
+
+func (h *timingHooks) preConnect(c context.Context, connector *Connector) (ctx interface{}, err error) {
+	defer h.observe(c, OpOpen, time.Now(), &err)
+	return h.callPre(c, OpOpen, nil, func() (interface{}, error) {
+		return h.hooks.preConnect(c, connector)
+	})
+}
+
+func (h *timingHooks) connect(c context.Context, ctx interface{}, connector *Connector, conn *Conn) (err error) {
+	defer h.observe(c, OpOpen, time.Now(), &err)
+	return h.call(c, OpOpen, conn, ctx, func() error {
+		return h.hooks.connect(c, ctx, connector, conn)
+	})
+}
+
+func (h *timingHooks) postConnect(c context.Context, ctx interface{}, connector *Connector, conn *Conn, opErr error) (err error) {
+	defer h.observe(c, OpOpen, time.Now(), &err)
+	return h.call(c, OpOpen, conn, ctx, func() error {
+		return h.hooks.postConnect(c, ctx, connector, conn, opErr)
+	})
+}
+
+func (h *timingHooks) preConnectorClose(c context.Context, connector *Connector) (ctx interface{}, err error) {
+	defer h.observe(c, OpConnectorClose, time.Now(), &err)
+	return h.callPre(c, OpConnectorClose, nil, func() (interface{}, error) {
+		return h.hooks.preConnectorClose(c, connector)
+	})
+}
+
+func (h *timingHooks) postConnectorClose(c context.Context, ctx interface{}, connector *Connector, opErr error) (err error) {
+	defer h.observe(c, OpConnectorClose, time.Now(), &err)
+	return h.call(c, OpConnectorClose, nil, ctx, func() error {
+		return h.hooks.postConnectorClose(c, ctx, connector, opErr)
+	})
+}
+
+func (h *timingHooks) onCanceled(c context.Context, op Operation, d time.Duration, opErr error) (err error) {
+	defer h.observe(c, op, time.Now(), &err)
+	return h.call(c, op, nil, nil, func() error {
+		return h.hooks.onCanceled(c, op, d, opErr)
+	})
+}
+
+func (h *timingHooks) columns(c context.Context, rows *Rows) (err error) {
+	defer h.observe(c, OpQuery, time.Now(), &err)
+	return h.call(c, OpQuery, rows.conn(), nil, func() error {
+		return h.hooks.columns(c, rows)
+	})
+}
+
+func (h *timingHooks) preSavepoint(c context.Context, stmt *Stmt, sp *Savepoint) (ctx interface{}, err error) {
+	defer h.observe(c, OpExec, time.Now(), &err)
+	return h.callPre(c, OpExec, stmt.conn(), func() (interface{}, error) {
+		return h.hooks.preSavepoint(c, stmt, sp)
+	})
+}
+
+func (h *timingHooks) savepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint) (err error) {
+	defer h.observe(c, OpExec, time.Now(), &err)
+	return h.call(c, OpExec, stmt.conn(), ctx, func() error {
+		return h.hooks.savepoint(c, ctx, stmt, sp)
+	})
+}
+
+func (h *timingHooks) postSavepoint(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, opErr error) (err error) {
+	defer h.observe(c, OpExec, time.Now(), &err)
+	return h.call(c, OpExec, stmt.conn(), ctx, func() error {
+		return h.hooks.postSavepoint(c, ctx, stmt, sp, opErr)
+	})
+}
+
+func (h *timingHooks) columnConverter(stmt *Stmt, idx int, conv driver.ValueConverter) driver.ValueConverter {
+	var err error
+	defer h.observe(context.Background(), OpExec, time.Now(), &err)
+	return h.hooks.columnConverter(stmt, idx, conv)
+}
+
+func (h *timingHooks) oncePerConn(c context.Context, conn *Conn) (err error) {
+	defer h.observe(c, OpInitConn, time.Now(), &err)
+	return h.call(c, OpInitConn, conn, nil, func() error {
+		return h.hooks.oncePerConn(c, conn)
+	})
+}
+
+func (h *timingHooks) rewriteDSN(c context.Context, name string) (_ string, err error) {
+	defer h.observe(c, OpOpen, time.Now(), &err)
+	newName, err := h.callPre(c, OpOpen, nil, func() (interface{}, error) {
+		return h.hooks.rewriteDSN(c, name)
+	})
+	if newName, ok := newName.(string); ok {
+		return newName, err
+	}
+	// the callback timed out.
+	return name, err
+}
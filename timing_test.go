@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProxyWithHookTiming(t *testing.T) {
+	var mu sync.Mutex
+	var exceeded []HookBudgetExceeded
+	slow := &HooksContext{
+		Name: "slow",
+		PreExec: func(_ context.Context, _ *Stmt, _ []driver.NamedValue) (interface{}, error) {
+			time.Sleep(10 * time.Millisecond)
+			return nil, nil
+		},
+	}
+	failing := &HooksContext{
+		Name: "failing",
+		Query: func(_ context.Context, _ interface{}, _ *Stmt, _ []driver.NamedValue, _ driver.Rows) error {
+			return errors.New("failed")
+		},
+	}
+	p := NewProxyContext(fdriver, slow).WithHookTiming(HookTimingOptions{
+		Budget: 5 * time.Millisecond,
+		OnBudgetExceeded: func(c context.Context, e *HookBudgetExceeded) {
+			mu.Lock()
+			defer mu.Unlock()
+			exceeded = append(exceeded, *e)
+		},
+	}).With(failing)
+	sql.Register("fakedb-hook-timing", p)
+	db, err := sql.Open("fakedb-hook-timing", `{"Name":"hook-timing","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Query("SELECT id FROM t1"); err == nil {
+		t.Fatal("want error, got nil")
+	}
+
+	if infos := p.Hooks(); len(infos) != 2 || infos[0].Name != "slow" || infos[1].Name != "failing" {
+		t.Errorf("the hook sets should be described as before: %#v", infos)
+	}
+
+	stats := p.Stats().HookSets
+	if len(stats) != 2 {
+		t.Fatalf("want 2 hook sets, got %#v", stats)
+	}
+	if stats[0].HookSet.Name != "slow" {
+		t.Errorf("unexpected hook set: %#v", stats[0].HookSet)
+	}
+	if s := stats[0].Operations[OpExec]; s.Count != 3 || s.MaxDuration < 10*time.Millisecond {
+		t.Errorf("unexpected stats of Exec: %#v", s)
+	}
+	if stats[1].HookSet.Name != "failing" {
+		t.Errorf("unexpected hook set: %#v", stats[1].HookSet)
+	}
+	if s := stats[1].Operations[OpQuery]; s.Count != 3 || s.Errors != 1 {
+		t.Errorf("unexpected stats of Query: %#v", s)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(exceeded) != 1 {
+		t.Fatalf("want 1 warning, got %#v", exceeded)
+	}
+	if e := exceeded[0]; e.HookSet.Name != "slow" || e.Operation != OpExec || e.Duration < 10*time.Millisecond {
+		t.Errorf("unexpected warning: %#v", e)
+	}
+}
+
+func TestProxyStats_noHookTiming(t *testing.T) {
+	p := NewProxyContext(fdriver, &HooksContext{})
+	if stats := p.Stats(); stats.HookSets != nil {
+		t.Errorf("want nil, got %#v", stats.HookSets)
+	}
+}
+
+func TestProxyWithHookTiming_Timeout(t *testing.T) {
+	var mu sync.Mutex
+	var timeouts []HookBudgetExceeded
+	release := make(chan struct{})
+	defer close(release)
+	var got []interface{}
+	p := NewProxyContext(fdriver, &HooksContext{
+		Name: "stuck",
+		PreExec: func(_ context.Context, _ *Stmt, _ []driver.NamedValue) (interface{}, error) {
+			<-release
+			return "never passed", nil
+		},
+		PostExec: func(_ context.Context, ctx interface{}, _ *Stmt, _ []driver.NamedValue, _ driver.Result, _ error) error {
+			got = append(got, ctx)
+			return nil
+		},
+	}).WithHookTiming(HookTimingOptions{
+		Timeout: 10 * time.Millisecond,
+		OnTimeout: func(c context.Context, e *HookBudgetExceeded) {
+			mu.Lock()
+			defer mu.Unlock()
+			timeouts = append(timeouts, *e)
+		},
+	})
+	sql.Register("fakedb-hook-timeout", p)
+	db, err := sql.Open("fakedb-hook-timeout", `{"Name":"hook-timeout","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the operation continues without waiting for the Pre hook.
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// the hook set that timed out is skipped.
+	if len(got) != 0 {
+		t.Errorf("want no PostExec calls, got %v", got)
+	}
+	// the connection is kept in the pool while the Pre hook is running.
+	if n := db.Stats().OpenConnections; n != 1 {
+		t.Errorf("want 1 open connection, got %d", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(timeouts) != 1 {
+		t.Fatalf("want 1 timeout, got %#v", timeouts)
+	}
+	if e := timeouts[0]; e.HookSet.Name != "stuck" || e.Operation != OpExec || e.Duration != 10*time.Millisecond {
+		t.Errorf("unexpected timeout: %#v", e)
+	}
+	if stats := p.Stats().HookSets; len(stats) != 1 || stats[0].Timeouts != 1 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+}
+
+func TestProxyWithHookTiming_Panic(t *testing.T) {
+	p := NewProxyContext(fdriver, &HooksContext{
+		PreExec: func(_ context.Context, _ *Stmt, _ []driver.NamedValue) (interface{}, error) {
+			panic("boom")
+		},
+	}).WithHookTiming(HookTimingOptions{
+		Timeout: time.Second,
+	})
+	sql.Register("fakedb-hook-timing-panic", p)
+	db, err := sql.Open("fakedb-hook-timing-panic", `{"Name":"hook-timing-panic","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec("INSERT INTO t1 (id) VALUES(?)", 1)
+	var panicErr *HookPanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("want *HookPanicError, got %v", err)
+	}
+	if panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Errorf("unexpected error: %#v", panicErr)
+	}
+}
+
+func TestProxyWithHookTiming_Replace(t *testing.T) {
+	p := NewProxyContext(fdriver, &HooksContext{}).WithHookTiming(HookTimingOptions{
+		Budget: time.Millisecond,
+	}).WithHookTiming(HookTimingOptions{
+		Budget: time.Second,
+	})
+	h, ok := p.loadHooks().(*timingHooks)
+	if !ok {
+		t.Fatalf("want *timingHooks, got %T", p.loadHooks())
+	}
+	if _, ok := h.hooks.(*timingHooks); ok {
+		t.Error("the hook set should not be measured twice")
+	}
+	if h.opt.Budget != time.Second {
+		t.Errorf("want the options to be replaced, got %#v", h.opt)
+	}
+}
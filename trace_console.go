@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// the ANSI escape sequences used by ConsoleTraceFormatter.
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+	colorDim    = "\x1b[2m"
+)
+
+// consoleIndent is the indent of the continuation lines of ConsoleTraceFormatter.
+// It is the width of the operation, the duration and the spaces between them.
+const consoleIndent = "                       "
+
+// ConsoleTraceFormatter formats a log for humans reading it on a terminal during development.
+// It is used by TraceFormatConsole.
+// The operations are color coded: the reads are green, the mutations are yellow,
+// the failed operations are red, and the others are cyan.
+// The durations are aligned, and the lines of multi-line queries are aligned with the first line.
+// The details, such as the arguments, follow on the next line.
+var ConsoleTraceFormatter TraceFormatter = TraceFormatterFunc(formatTraceConsole)
+
+func formatTraceConsole(w io.Writer, e *TraceEvent) {
+	color := consoleColor(e)
+	io.WriteString(w, color)
+	fmt.Fprintf(w, "%-8s", e.Op)
+	io.WriteString(w, colorReset)
+	fmt.Fprintf(w, " %12s", consoleDuration(e.Duration))
+
+	for i, line := range queryLines(e.Query) {
+		if i == 0 {
+			io.WriteString(w, "  ")
+		} else {
+			io.WriteString(w, "\n")
+			io.WriteString(w, consoleIndent)
+		}
+		io.WriteString(w, line)
+	}
+
+	// reuse the details of the text format, without the leading separator.
+	var buf bytes.Buffer
+	if e.hasArgs || len(e.Args) > 0 {
+		io.WriteString(&buf, "; args = [")
+		writeNamedValues(&buf, e.Args, traceValueFormatter(e))
+		io.WriteString(&buf, "]")
+	}
+	writeLabels(&buf, e.Labels)
+	writeConnID(&buf, e.Conn)
+	if e.OpID != 0 {
+		fmt.Fprintf(&buf, "; op_id = %d", e.OpID)
+	}
+	for _, field := range e.Fields {
+		fmt.Fprintf(&buf, "; %s = %v", field.Key, field.Value)
+	}
+	if e.Uses > 0 {
+		fmt.Fprintf(&buf, "; uses = %d", e.Uses)
+	}
+	if e.hasRowsAffected {
+		fmt.Fprintf(&buf, "; rows_affected = %d", e.RowsAffected)
+	}
+	if e.hasRowsRead {
+		fmt.Fprintf(&buf, "; rows = %d", e.RowsRead)
+	}
+	if buf.Len() > 0 {
+		io.WriteString(w, "\n")
+		io.WriteString(w, consoleIndent)
+		io.WriteString(w, colorDim)
+		w.Write(bytes.TrimPrefix(buf.Bytes(), []byte("; ")))
+		io.WriteString(w, colorReset)
+	}
+
+	if e.Err != nil {
+		io.WriteString(w, "\n")
+		io.WriteString(w, consoleIndent)
+		io.WriteString(w, colorRed)
+		fmt.Fprintf(w, "err = %#v", e.Err.Error())
+		io.WriteString(w, colorReset)
+	}
+	if e.Stack != "" {
+		io.WriteString(w, "\n")
+		io.WriteString(w, e.Stack)
+	}
+}
+
+// consoleColor returns the color of the operation of e.
+func consoleColor(e *TraceEvent) string {
+	if e.Err != nil {
+		return colorRed
+	}
+	switch e.Op {
+	case "Query":
+		return colorGreen
+	case "Exec", "Prepare":
+		if isReadQuery(e.Query) {
+			return colorGreen
+		}
+		return colorYellow
+	}
+	return colorCyan
+}
+
+// isReadQuery reports whether query starts with the keywords of the queries that don't modify the database.
+func isReadQuery(query string) bool {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	i := 0
+	for i < len(query) && isIdentChar(query[i]) {
+		i++
+	}
+	switch strings.ToUpper(query[:i]) {
+	case "SELECT", "WITH", "SHOW", "EXPLAIN", "DESCRIBE", "VALUES":
+		return true
+	}
+	return false
+}
+
+// consoleDuration rounds d for aligning the durations.
+func consoleDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	}
+	return d
+}
+
+// queryLines splits the query into the lines, removing the blank lines around it and the common indent.
+func queryLines(query string) []string {
+	lines := strings.Split(query, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	indent := 0
+	first := true
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if first || n < indent {
+			indent = n
+			first = false
+		}
+	}
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if len(line) >= indent {
+			line = line[indent:]
+		}
+		lines[i] = line
+	}
+	return lines
+}
@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConsoleTraceFormatter(t *testing.T) {
+	tests := []struct {
+		name string
+		ev   *TraceEvent
+		want string
+	}{
+		{
+			name: "select",
+			ev: &TraceEvent{
+				Op:       "Query",
+				Query:    "SELECT id FROM t1 WHERE id = ?",
+				hasQuery: true,
+				Args:     []driver.NamedValue{{Ordinal: 1, Value: int64(1)}},
+				hasArgs:  true,
+				Duration: 1234567 * time.Nanosecond,
+			},
+			want: "\x1b[32mQuery   \x1b[0m      1.235ms  SELECT id FROM t1 WHERE id = ?\n" +
+				"                       \x1b[2margs = [1]\x1b[0m",
+		},
+		{
+			name: "mutation",
+			ev: &TraceEvent{
+				Op:       "Exec",
+				Query:    "\n\t\tUPDATE t1\n\t\tSET name = 'foo'\n\t",
+				hasQuery: true,
+				hasArgs:  true,
+				Duration: 2 * time.Second,
+			},
+			want: "\x1b[33mExec    \x1b[0m           2s  UPDATE t1\n" +
+				"                       SET name = 'foo'\n" +
+				"                       \x1b[2margs = []\x1b[0m",
+		},
+		{
+			name: "error",
+			ev: &TraceEvent{
+				Op:       "Commit",
+				Err:      errors.New("commit failed"),
+				Duration: 500 * time.Nanosecond,
+			},
+			want: "\x1b[31mCommit  \x1b[0m        500ns\n" +
+				"                       \x1b[31merr = \"commit failed\"\x1b[0m",
+		},
+		{
+			name: "other",
+			ev: &TraceEvent{
+				Op:       "Begin",
+				Duration: time.Microsecond,
+			},
+			want: "\x1b[36mBegin   \x1b[0m          1µs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ConsoleTraceFormatter.FormatTrace(&buf, tt.ev)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQueryLines(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", nil},
+		{"SELECT 1", []string{"SELECT 1"}},
+		{"  ", nil},
+		{"SELECT id\n  FROM t1", []string{"SELECT id", "  FROM t1"}},
+		{"\n    SELECT id\n\n      FROM t1  \n", []string{"SELECT id", "", "  FROM t1"}},
+	}
+	for _, tt := range tests {
+		if got := queryLines(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("queryLines(%q): want %q, got %q", tt.query, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package proxy
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// TraceEvent is an operation logged by the tracing proxy.
+type TraceEvent struct {
+	// Op is the name of the operation, e.g. "Exec" and "Commit".
+	// The savepoint statements are named by their kinds, e.g. "Savepoint" and "Release".
+	Op string
+
+	// Conn is the connection of the operation.
+	// It is nil if Open failed.
+	Conn *Conn
+
+	// Query is the query of Prepare, Exec and Query operations, or the name of the savepoint.
+	Query string
+
+	// Args is the arguments of Exec and Query operations.
+	Args []driver.NamedValue
+
+	// Labels is the labels associated with the context by WithLabels.
+	Labels map[string]string
+
+	// OpID is the ID of the operation, see OperationIDFromContext.
+	// It is zero if TracerOptions.OperationID is false.
+	OpID int64
+
+	// Uses is the number of uses of the prepared statement.
+	// It is zero if TracerOptions.TracePrepare is false or the statement is not prepared.
+	Uses int64
+
+	// RowsAffected is the number of rows affected by Exec.
+	// It is available if TracerOptions.TraceRows is true, see HasRowsAffected.
+	RowsAffected int64
+
+	// RowsRead is the number of rows read from the rows of Query.
+	// It is available if TracerOptions.TraceRows is true, see HasRowsRead.
+	RowsRead int64
+
+	// Err is the error of the operation.
+	Err error
+
+	// Stack is the stack trace of the failed operation.
+	// It is available if TracerOptions.ErrorStackTrace is true.
+	Stack string
+
+	// Duration is the duration of the operation.
+	Duration time.Duration
+
+	// Fields is the fields returned by TracerOptions.ContextFields.
+	Fields []TraceField
+
+	// Level is the level of the log determined by TracerOptions.Levels.
+	// It is zero if TracerOptions.Levels is nil.
+	Level TraceLevel
+
+	// ValueFormatter is TracerOptions.ValueFormatter, which should format Args.
+	ValueFormatter ValueFormatter
+
+	hasRowsAffected bool
+	hasRowsRead     bool
+	hasQuery        bool      // the operation has a query, even if it is empty
+	hasArgs         bool      // the operation has arguments, even if they are empty
+	pc              uintptr   // the program counter of the caller
+	start           time.Time // the start time of the operation
+	txID            int64     // the ID of the transaction, see TxIDFromContext
+}
+
+// TraceField is a field of the logs of the tracing proxy.
+type TraceField struct {
+	Key   string
+	Value interface{}
+}
+
+// Caller returns the file name and the line number of the caller, which is selected by TracerOptions.Filter.
+func (e *TraceEvent) Caller() (file string, line int, ok bool) {
+	if e.pc == 0 {
+		return "", 0, false
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{e.pc}).Next()
+	if frame.File == "" {
+		return "", 0, false
+	}
+	return frame.File, frame.Line, true
+}
+
+// HasRowsAffected reports whether RowsAffected is available.
+func (e *TraceEvent) HasRowsAffected() bool {
+	return e.hasRowsAffected
+}
+
+// HasRowsRead reports whether RowsRead is available.
+func (e *TraceEvent) HasRowsRead() bool {
+	return e.hasRowsRead
+}
+
+// TraceFormatter formats the logs of the tracing proxy.
+type TraceFormatter interface {
+	FormatTrace(w io.Writer, e *TraceEvent)
+}
+
+// TraceFormatterFunc is an adapter to allow the use of ordinary functions as TraceFormatter.
+type TraceFormatterFunc func(w io.Writer, e *TraceEvent)
+
+// FormatTrace calls f(w, e).
+func (f TraceFormatterFunc) FormatTrace(w io.Writer, e *TraceEvent) {
+	f(w, e)
+}
+
+var (
+	// TextTraceFormatter formats a log as a line of text. It is used by TraceFormatText.
+	TextTraceFormatter TraceFormatter = TraceFormatterFunc(formatTraceText)
+
+	// JSONTraceFormatter formats a log as a JSON object. It is used by TraceFormatJSON.
+	JSONTraceFormatter TraceFormatter = TraceFormatterFunc(formatTraceJSON)
+)
+
+func formatTraceText(w io.Writer, e *TraceEvent) {
+	io.WriteString(w, e.Op)
+	if e.Conn != nil {
+		fmt.Fprintf(w, " %p", e.Conn.Conn)
+	} else {
+		io.WriteString(w, " nil")
+	}
+	if e.hasQuery || e.Query != "" {
+		io.WriteString(w, ": ")
+		io.WriteString(w, e.Query)
+	}
+	if e.hasArgs || len(e.Args) > 0 {
+		io.WriteString(w, "; args = [")
+		writeNamedValues(w, e.Args, traceValueFormatter(e))
+		io.WriteString(w, "]")
+	}
+	writeLabels(w, e.Labels)
+	writeConnID(w, e.Conn)
+	if e.OpID != 0 {
+		fmt.Fprintf(w, "; op_id = %d", e.OpID)
+	}
+	for _, field := range e.Fields {
+		fmt.Fprintf(w, "; %s = %v", field.Key, field.Value)
+	}
+	if e.Uses > 0 {
+		fmt.Fprintf(w, "; uses = %d", e.Uses)
+	}
+	if e.hasRowsAffected {
+		fmt.Fprintf(w, "; rows_affected = %d", e.RowsAffected)
+	}
+	if e.hasRowsRead {
+		fmt.Fprintf(w, "; rows = %d", e.RowsRead)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(w, "; err = %#v", e.Err.Error())
+	}
+	io.WriteString(w, " (")
+	io.WriteString(w, e.Duration.String())
+	io.WriteString(w, ")")
+	if e.Stack != "" {
+		io.WriteString(w, "\n")
+		io.WriteString(w, e.Stack)
+	}
+}
+
+// traceJSON is the JSON object of a log, which extends the versioned Event schema
+// with the fields specific to the tracing proxy.
+type traceJSON struct {
+	Event
+	Level        string                 `json:"level,omitempty"`
+	Savepoint    string                 `json:"savepoint,omitempty"`
+	Conn         string                 `json:"conn,omitempty"`
+	OpID         int64                  `json:"op_id,omitempty"`
+	Fields       map[string]interface{} `json:"fields,omitempty"`
+	Uses         int64                  `json:"uses,omitempty"`
+	RowsAffected *int64                 `json:"rows_affected,omitempty"`
+	RowsRead     *int64                 `json:"rows,omitempty"`
+	Caller       string                 `json:"caller,omitempty"`
+	Stack        string                 `json:"stack,omitempty"`
+}
+
+func formatTraceJSON(w io.Writer, e *TraceEvent) {
+	v := traceJSON{
+		Event: Event{
+			Version:  EventVersion,
+			TxID:     e.txID,
+			Query:    e.Query,
+			Start:    e.start,
+			Duration: e.Duration,
+			Labels:   e.Labels,
+		},
+		OpID: e.OpID,
+		Uses: e.Uses,
+	}
+	if err := v.Operation.UnmarshalText([]byte(e.Op)); err != nil {
+		// the savepoint statements are executed by Exec.
+		v.Operation = OpExec
+		v.Savepoint = e.Op
+	}
+	if e.Level != 0 {
+		v.Level = e.Level.String()
+	}
+	if e.Conn != nil {
+		v.Conn = fmt.Sprintf("%p", e.Conn.Conn)
+		v.ConnID = e.Conn.id
+	}
+	v.Args = newEventArgs(formatNamedValues(e.Args, traceValueFormatter(e), 0))
+	if e.hasRowsAffected {
+		v.RowsAffected = &e.RowsAffected
+	}
+	if e.hasRowsRead {
+		v.RowsRead = &e.RowsRead
+	}
+	if len(e.Fields) > 0 {
+		v.Fields = make(map[string]interface{}, len(e.Fields))
+		for _, field := range e.Fields {
+			v.Fields[field.Key] = field.Value
+		}
+	}
+	if e.Err != nil {
+		v.Error = e.Err.Error()
+	}
+	v.Stack = e.Stack
+	if file, line, ok := e.Caller(); ok {
+		v.Caller = filepath.Base(file) + ":" + strconv.Itoa(line)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+func traceValueFormatter(e *TraceEvent) ValueFormatter {
+	if e.ValueFormatter == nil {
+		return GoSyntaxValueFormatter
+	}
+	return e.ValueFormatter
+}
@@ -0,0 +1,501 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTracerOptions_Formatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-formatter", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		Formatter: TraceFormatterFunc(func(w io.Writer, e *TraceEvent) {
+			if e.Op != "Exec" {
+				return
+			}
+			fmt.Fprintf(w, "op=%s query=%q nargs=%d", e.Op, e.Query, len(e.Args))
+			if e.Err != nil {
+				fmt.Fprintf(w, " err=%q", e.Err)
+			}
+		}),
+	})))
+	db, err := sql.Open("fakedb-trace-formatter", `{"name":"trace-formatter"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	want := "op=Exec query=\"INSERT INTO t1 (id) VALUES(?)\" nargs=1\n"
+	if !bytes.Contains(buf.Bytes(), []byte(want)) {
+		t.Errorf("want %q in the log, got:\n%s", want, buf.String())
+	}
+}
+
+type traceFieldsKey struct{}
+
+func TestTracerOptions_ContextFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-context-fields", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		ContextFields: func(c context.Context) []TraceField {
+			id, ok := c.Value(traceFieldsKey{}).(string)
+			if !ok {
+				return nil
+			}
+			return []TraceField{{Key: "request_id", Value: id}}
+		},
+	})))
+	db, err := sql.Open("fakedb-trace-context-fields", `{"name":"trace-context-fields"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.WithValue(context.Background(), traceFieldsKey{}, "req-1")
+	if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("; request_id = req-1 (")) {
+		t.Errorf("want request_id in the log, got:\n%s", buf.String())
+	}
+}
+
+func TestTracerOptions_SlowQueryByOperation(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-slow-query-by-operation", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		SlowQuery: time.Hour,
+		SlowQueryByOperation: map[Operation]time.Duration{
+			OpExec: 0,
+		},
+	})))
+	db, err := sql.Open("fakedb-trace-slow-query-by-operation", `{"name":"trace-slow-query-by-operation"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("Exec ")) || bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+		t.Errorf("want only the log of Exec, got:\n%s", buf.String())
+	}
+}
+
+func TestTracerOptions_Sampler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	var fingerprints []string
+	sql.Register("fakedb-trace-sampler", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		Sampler: SamplerFunc(func(fingerprint string, d time.Duration) bool {
+			fingerprints = append(fingerprints, fingerprint)
+			return false
+		}),
+	})))
+	db, err := sql.Open("fakedb-trace-sampler", `{"name":"trace-sampler"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(1)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(2)"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("want no logs, got:\n%s", buf.String())
+	}
+	want := "insert into t1(id) values(?)"
+	if len(fingerprints) != 3 || fingerprints[1] != want || fingerprints[2] != want {
+		t.Errorf("want [Open %s %s], got %v", want, want, fingerprints)
+	}
+
+	// the errors are always logged.
+	failDB, err := sql.Open("fakedb-trace-sampler", `{"name":"trace-sampler-fail","FailExec":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer failDB.Close()
+	if _, err := failDB.Exec("INVALID QUERY"); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("INVALID QUERY")) {
+		t.Errorf("want the log of the failed query, got:\n%s", buf.String())
+	}
+}
+
+func TestTracerOptions_Lifecycle(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-lifecycle", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter:   log.New(buf, "", 0),
+		IgnoreOpen:  true,
+		IgnoreClose: true,
+		TracePing:   true,
+	})))
+	db, err := sql.Open("fakedb-trace-lifecycle", `{"name":"trace-lifecycle","ConnType":"fakeConnCtx"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if !bytes.HasPrefix(buf.Bytes(), []byte("Ping ")) || bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+		t.Errorf("want only the log of Ping, got:\n%s", buf.String())
+	}
+}
+
+func TestTracerOptions_TraceRows(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-rows", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		TraceRows: true,
+	})))
+	db, err := sql.Open("fakedb-trace-rows", `{"name":"trace-rows","NumRows":3,"RowsAffected":2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("UPDATE t1 SET name = ?", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("SELECT id FROM t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("Query ")) {
+		t.Errorf("want Query to be logged after the rows are read, got:\n%s", buf.String())
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+
+	for _, want := range []string{"; rows_affected = 2 (", "; rows = 3 ("} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("want %q in the log, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestTracerOptions_ErrorStackTrace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-stack", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		// this test is in the proxy package, so the default filter omits it.
+		Filter:          PackageFilter{"database/sql": struct{}{}},
+		ErrorStackTrace: true,
+	})))
+	db, err := sql.Open("fakedb-trace-stack", `{"name":"trace-stack"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(1)"); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("TestTracerOptions_ErrorStackTrace")) {
+		t.Errorf("want no stack traces for the succeeded operations, got:\n%s", buf.String())
+	}
+
+	failDB, err := sql.Open("fakedb-trace-stack", `{"name":"trace-stack-fail","FailExec":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer failDB.Close()
+	buf.Reset()
+	if _, err := failDB.Exec("INVALID QUERY"); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	got := buf.String()
+	if !strings.Contains(got, "proxy.TestTracerOptions_ErrorStackTrace\n\t") {
+		t.Errorf("want the stack trace of the failed operation, got:\n%s", got)
+	}
+	if strings.Contains(got, "database/sql.") {
+		t.Errorf("want the frames of database/sql to be filtered, got:\n%s", got)
+	}
+}
+
+func TestTracerOptions_DisableCaller(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-disable-caller", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter:     log.New(buf, "", 0),
+		Format:        TraceFormatJSON,
+		DisableCaller: true,
+	})))
+	db, err := sql.Open("fakedb-trace-disable-caller", `{"name":"trace-disable-caller"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(1)"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"Exec"`) {
+		t.Errorf("want the log of Exec, got:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), `"caller"`) {
+		t.Errorf("want no callers, got:\n%s", buf.String())
+	}
+}
+
+func TestTracerOptions_Now(t *testing.T) {
+	buf := &bytes.Buffer{}
+	clock := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sql.Register("fakedb-trace-now", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		Now: func() time.Time {
+			clock = clock.Add(1500 * time.Millisecond)
+			return clock
+		},
+	})))
+	db, err := sql.Open("fakedb-trace-now", `{"name":"trace-now"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(1)"); err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`(?m)^Exec 0x[0-9a-f]+: INSERT INTO t1 \(id\) VALUES\(1\); args = \[\]; conn_id = \d+ \(1\.5s\)$`)
+	if got := buf.String(); !re.MatchString(got) {
+		want := re.String()
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestTracerOptions_Now_JSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	clock := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sql.Register("fakedb-trace-now-json", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		Format:    TraceFormatJSON,
+		Now: func() time.Time {
+			return clock
+		},
+	})))
+	db, err := sql.Open("fakedb-trace-now-json", `{"name":"trace-now-json"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(1)"); err != nil {
+		t.Fatal(err)
+	}
+	// the start time is also measured by Now.
+	if want := `"start":"2020-01-01T00:00:00Z","duration":0`; !strings.Contains(buf.String(), want) {
+		t.Errorf("want %s, got %s", want, buf.String())
+	}
+}
+
+func TestTracerOptions_Now_Rows(t *testing.T) {
+	buf := &bytes.Buffer{}
+	var mu sync.Mutex
+	clock := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sql.Register("fakedb-trace-now-rows", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		Format:    TraceFormatJSON,
+		TraceRows: true,
+		Now: func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			clock = clock.Add(time.Second)
+			return clock
+		},
+	})))
+	db, err := sql.Open("fakedb-trace-now-rows", `{"name":"trace-now-rows"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query("SELECT id FROM t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+
+	// the query starts at PreQuery and ends at PostRows, both are measured by Now.
+	var query string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, `"operation":"Query"`) {
+			query = line
+		}
+	}
+	if want := `"duration":1000000000`; !strings.Contains(query, want) {
+		t.Errorf("want %s, got %s", want, buf.String())
+	}
+}
+
+func TestTracerOptions_RowsErr(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-rows-err", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		Format:    TraceFormatJSON,
+		TraceRows: true,
+	})))
+	db, err := sql.Open("fakedb-trace-rows-err", `{"name":"trace-rows-err","NumRows":2,"FailNext":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	rows, err := db.Query("SELECT id FROM t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	if rows.Err() == nil {
+		t.Fatal("want error, got nil")
+	}
+	rows.Close()
+
+	var query string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, `"operation":"Query"`) {
+			query = line
+		}
+	}
+	if want := `"rows":2`; !strings.Contains(query, want) {
+		t.Errorf("want %s, got %s", want, buf.String())
+	}
+	if want := "next failed"; !strings.Contains(query, want) {
+		t.Errorf("want %s, got %s", want, buf.String())
+	}
+}
+
+func TestTracerOptions_Labels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	labels := map[string]string{"db": "main", "role": "primary"}
+	sql.Register("fakedb-trace-static-labels", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		Labels:    labels,
+	})))
+	// the labels are copied.
+	labels["role"] = "modified"
+
+	db, err := sql.Open("fakedb-trace-static-labels", `{"name":"trace-static-labels"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(1)"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithLabels(context.Background(), map[string]string{"role": "replica", "job": "test"})
+	if _, err := db.ExecContext(ctx, "INSERT INTO t1 (id) VALUES(2)"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"VALUES(1); args = []; labels = {db=main, role=primary}",
+		"VALUES(2); args = []; labels = {db=main, job=test, role=replica}",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want %q in the log, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestTracerOptions_Labels_Tx(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-tx-labels", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: log.New(buf, "", 0),
+	})))
+	db, err := sql.Open("fakedb-trace-tx-labels", `{"name":"trace-tx-labels"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the labels are also logged with the transactions.
+	ctx := WithLabels(context.Background(), map[string]string{"job": "test"})
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []string{"Open", "Begin", "Commit"} {
+		re := regexp.MustCompile(`(?m)^` + op + ` 0x[0-9a-f]+; labels = \{job=test\}; conn_id = \d+ \(.*\)$`)
+		if !re.MatchString(buf.String()) {
+			t.Errorf("want the labels of %s, got:\n%s", op, buf.String())
+		}
+	}
+}
+
+func TestTracerOptions_OperationID(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-operation-id", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter:   log.New(buf, "", 0),
+		OperationID: true,
+	})))
+	db, err := sql.Open("fakedb-trace-operation-id", `{"name":"trace-operation-id"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(1)"); err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`(?m)^Exec 0x[0-9a-f]+: INSERT INTO t1 \(id\) VALUES\(1\); args = \[\]; conn_id = \d+; op_id = [1-9]\d* \(`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("want the operation ID in the log, got:\n%s", buf.String())
+	}
+}
+
+func TestJSONTraceFormatter_Event(t *testing.T) {
+	var buf bytes.Buffer
+	JSONTraceFormatter.FormatTrace(&buf, &TraceEvent{
+		Op:       "Release",
+		Query:    "sp1",
+		Labels:   map[string]string{"job": "test"},
+		Duration: time.Millisecond,
+		Err:      errors.New("failed"),
+		start:    time.Date(2006, time.January, 2, 15, 4, 5, 0, time.UTC),
+		txID:     42,
+	})
+
+	// the logs are compatible with the Event schema.
+	var e Event
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	want := Event{
+		Version:   EventVersion,
+		Operation: OpExec,
+		TxID:      42,
+		Query:     "sp1",
+		Start:     time.Date(2006, time.January, 2, 15, 4, 5, 0, time.UTC),
+		Duration:  time.Millisecond,
+		Error:     "failed",
+		Labels:    map[string]string{"job": "test"},
+	}
+	if !reflect.DeepEqual(e, want) {
+		t.Errorf("want %#v, got %#v", want, e)
+	}
+	if !strings.Contains(buf.String(), `"savepoint":"Release"`) {
+		t.Errorf("want the kind of the savepoint, got %s", buf.String())
+	}
+}
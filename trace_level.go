@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"fmt"
+	"time"
+)
+
+// TraceLevel is the severity level of the logs of the tracing proxy.
+type TraceLevel int
+
+const (
+	// TraceLevelDebug is the level of the operations that are normal.
+	TraceLevelDebug TraceLevel = iota + 1
+
+	// TraceLevelInfo is the level of the operations that are informative.
+	TraceLevelInfo
+
+	// TraceLevelWarn is the level of the slow operations.
+	TraceLevelWarn
+
+	// TraceLevelError is the level of the failed operations.
+	TraceLevelError
+)
+
+var traceLevelNames = [...]string{
+	TraceLevelDebug: "DEBUG",
+	TraceLevelInfo:  "INFO",
+	TraceLevelWarn:  "WARN",
+	TraceLevelError: "ERROR",
+}
+
+// String returns the name of the level, e.g. "DEBUG" and "ERROR".
+func (l TraceLevel) String() string {
+	if l <= 0 || int(l) >= len(traceLevelNames) {
+		return fmt.Sprintf("TraceLevel(%d)", int(l))
+	}
+	return traceLevelNames[l]
+}
+
+// TraceLevels maps the kinds of the operations to the levels of their logs.
+type TraceLevels struct {
+	// Normal is the level of the operations that are neither slow nor failed.
+	// The default is TraceLevelDebug.
+	Normal TraceLevel
+
+	// Slow is the level of the operations that take SlowQuery or longer.
+	// The default is TraceLevelWarn.
+	Slow TraceLevel
+
+	// SlowQuery is a threshold duration of the slow operations.
+	// If it is zero, no operations are slow.
+	SlowQuery time.Duration
+
+	// Error is the level of the failed operations.
+	// The default is TraceLevelError.
+	Error TraceLevel
+}
+
+// level returns the level of ev.
+func (l *TraceLevels) level(ev *TraceEvent) TraceLevel {
+	switch {
+	case ev.Err != nil:
+		return levelOr(l.Error, TraceLevelError)
+	case l.SlowQuery > 0 && ev.Duration >= l.SlowQuery:
+		return levelOr(l.Slow, TraceLevelWarn)
+	}
+	return levelOr(l.Normal, TraceLevelDebug)
+}
+
+func levelOr(l, def TraceLevel) TraceLevel {
+	if l == 0 {
+		return def
+	}
+	return l
+}
+
+// LevelOutputter is an Outputter that receives the levels of the logs.
+// If TracerOptions.Levels is set and the Outputter implements LevelOutputter,
+// the tracing proxy calls OutputLevel instead of Output.
+type LevelOutputter interface {
+	Outputter
+	OutputLevel(calldepth int, level TraceLevel, s string) error
+}
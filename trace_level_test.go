@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type levelRecorder struct {
+	levels []TraceLevel
+	logs   []string
+}
+
+func (r *levelRecorder) Output(calldepth int, s string) error {
+	r.levels = append(r.levels, 0)
+	r.logs = append(r.logs, s)
+	return nil
+}
+
+func (r *levelRecorder) OutputLevel(calldepth int, level TraceLevel, s string) error {
+	r.levels = append(r.levels, level)
+	r.logs = append(r.logs, s)
+	return nil
+}
+
+func TestTraceLevel_String(t *testing.T) {
+	tests := []struct {
+		level TraceLevel
+		want  string
+	}{
+		{TraceLevelDebug, "DEBUG"},
+		{TraceLevelError, "ERROR"},
+		{0, "TraceLevel(0)"},
+	}
+	for _, tt := range tests {
+		if got := tt.level.String(); got != tt.want {
+			t.Errorf("want %q, got %q", tt.want, got)
+		}
+	}
+}
+
+func TestTracerOptions_Levels(t *testing.T) {
+	r := &levelRecorder{}
+	sql.Register("fakedb-trace-levels", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter: r,
+		Levels: &TraceLevels{
+			Normal:    TraceLevelInfo,
+			SlowQuery: time.Hour,
+		},
+	})))
+
+	db, err := sql.Open("fakedb-trace-levels", `{"name":"trace-levels"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	failDB, err := sql.Open("fakedb-trace-levels", `{"name":"trace-levels-fail","FailExec":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer failDB.Close()
+	if _, err := failDB.Exec("INSERT INTO t1 (id) VALUES(?)", 1); err == nil {
+		t.Fatal("want error, got nil")
+	}
+
+	// Open, Exec, Open, failed Exec
+	want := []TraceLevel{TraceLevelInfo, TraceLevelInfo, TraceLevelInfo, TraceLevelError}
+	if len(r.levels) != len(want) {
+		t.Fatalf("want %v, got %v: %q", want, r.levels, r.logs)
+	}
+	for i := range want {
+		if r.levels[i] != want[i] {
+			t.Errorf("want %v, got %v: %q", want, r.levels, r.logs)
+			break
+		}
+	}
+}
@@ -6,6 +6,10 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"io"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sort"
 	"sync"
 	"time"
 )
@@ -22,6 +26,24 @@ type Filter interface {
 	DoOutput(packageName string) bool
 }
 
+// FileFilter is a Filter that also skips the frames by their file paths.
+// The tracing proxy checks the file paths of the frames if the Filter implements FileFilter.
+type FileFilter interface {
+	Filter
+	DoOutputFile(file string) bool
+}
+
+// doOutputFrame reports whether the frame of the function name in file is output.
+func doOutputFrame(f Filter, name, file string) bool {
+	if !f.DoOutput(packageName(name)) {
+		return false
+	}
+	if ff, ok := f.(FileFilter); ok {
+		return ff.DoOutputFile(file)
+	}
+	return true
+}
+
 // PackageFilter is an implementation of Filter.
 type PackageFilter map[string]struct{}
 
@@ -55,17 +77,178 @@ type TracerOptions struct {
 	// SlowQuery is a threshold duration to output into log.
 	// output all queries if SlowQuery is zero.
 	SlowQuery time.Duration
+
+	// SlowQueryByOperation overrides SlowQuery for each operation kind,
+	// e.g. to log Commit slower than 10ms, but Open slower than 100ms.
+	// The savepoint statements use the threshold of OpExec.
+	SlowQueryByOperation map[Operation]time.Duration
+
+	// IgnoreOpen disables to output the open of connections into log.
+	IgnoreOpen bool
+
+	// IgnoreClose disables to output the close of connections into log.
+	IgnoreClose bool
+
+	// TracePing enables to output the ping of connections into log.
+	TracePing bool
+
+	// TraceResetSession enables to output the reset of sessions into log.
+	// The connections are reset whenever they are reused from the pool.
+	TraceResetSession bool
+
+	// TraceRows enables to output the number of rows affected by Exec, and the number of rows read from Query.
+	// The logs of Query are output when the rows are exhausted or closed,
+	// and their durations include the time to read the rows.
+	TraceRows bool
+
+	// Now returns the current time, and is used to measure the start times and the durations of the operations.
+	// If it is nil, time.Now is used. It is intended for deterministic tests.
+	Now func() time.Time
+
+	// DisableCaller disables to look up the callers of the operations,
+	// which walks the stack on every log and is costly at high QPS.
+	// The logs have no caller, and the Outputter is told that the caller is the tracer itself,
+	// so the flags of log.Logger such as log.Lshortfile are not meaningful.
+	DisableCaller bool
+
+	// ErrorStackTrace enables to output the stack traces of the failed operations into log.
+	// The frames of the packages that Filter ignores are omitted.
+	ErrorStackTrace bool
+
+	// TracePrepare enables to output the prepare of statements into log.
+	// The logs of Exec and Query of prepared statements include the number of uses of the statements.
+	TracePrepare bool
+
+	// ValueFormatter formats the arguments of queries.
+	// If it is nil, GoSyntaxValueFormatter is used.
+	ValueFormatter ValueFormatter
+
+	// Format is the format of the logs.
+	// The default is TraceFormatText.
+	Format TraceFormat
+
+	// Formatter formats the logs.
+	// If it is not nil, Format is ignored.
+	Formatter TraceFormatter
+
+	// RedactArgs replaces the arguments of queries before they are formatted,
+	// e.g. to mask or hash passwords, tokens and personal information.
+	// name is the name of the argument, and is empty if the argument is not named.
+	// The number of arguments is still recorded. Use RedactAllArgs to mask all of them.
+	// The arguments passed to the driver are not modified.
+	RedactArgs func(name string, v driver.Value) driver.Value
+
+	// MaxQueryLength is the maximum length of the queries in bytes.
+	// The longer queries are elided with an ellipsis and their lengths.
+	// If it is zero, the queries are not elided.
+	MaxQueryLength int
+
+	// MaxArgLength is the maximum length of the formatted arguments in bytes.
+	// The longer arguments are elided with an ellipsis and their lengths.
+	// If it is zero, the arguments are not elided.
+	MaxArgLength int
+
+	// Fingerprint replaces the queries of Prepare, Exec and Query with their fingerprints,
+	// so the logs of logically identical queries can be grouped. See the Fingerprint function.
+	Fingerprint bool
+
+	// InterpolateParams replaces the placeholders of the queries with the arguments quoted in Dialect,
+	// instead of logging the arguments separately. It is intended for debugging.
+	// If the placeholders don't match the arguments, or Fingerprint is true, the queries are not interpolated.
+	InterpolateParams bool
+
+	// Dialect is the SQL dialect used by InterpolateParams.
+	// The default is DialectStandard.
+	Dialect Dialect
+
+	// ContextFields returns the fields extracted from the context of the operation,
+	// e.g. request IDs, trace IDs and tenant IDs, which are added to every log.
+	ContextFields func(c context.Context) []TraceField
+
+	// SampleRate is the fraction of the operations to be logged, e.g. 0.01 logs 1% of them.
+	// The failed operations and the operations that take SampleSlowQuery or longer are always logged.
+	// If it is zero, all the operations are logged.
+	SampleRate float64
+
+	// Sampler decides whether the operations are logged. If it is not nil, SampleRate is ignored.
+	// The failed operations and the operations that take SampleSlowQuery or longer are always logged.
+	Sampler Sampler
+
+	// SampleSlowQuery is a threshold duration of the operations that are logged regardless of sampling.
+	// If it is zero, all the operations that succeeded are sampled.
+	SampleSlowQuery time.Duration
+
+	// Levels maps the kinds of the operations to the levels of their logs.
+	// The levels are passed to the TraceFormatter by TraceEvent.Level, and to the Outputter if it implements LevelOutputter.
+	// If it is nil, the logs have no levels.
+	Levels *TraceLevels
+
+	// OperationID enables to output the IDs of the operations into log, see OperationIDFromContext.
+	OperationID bool
+
+	// Labels is the static labels included in every log, e.g. the name of the database,
+	// the role of the instance such as "primary" or "replica", and the shard ID,
+	// so the logs of the services that use several databases can be told apart.
+	// The labels associated with the contexts by WithLabels take precedence over them.
+	Labels map[string]string
+}
+
+// Sampler decides whether the tracing proxy logs the operations.
+type Sampler interface {
+	// Sample reports whether the operation is logged.
+	// fingerprint is the Fingerprint of the query for Prepare, Exec and Query,
+	// and the name of the operation, e.g. "Begin" and "Commit", for the others.
+	// d is the duration of the operation.
+	Sample(fingerprint string, d time.Duration) bool
+}
+
+// SamplerFunc is an adapter to allow the use of ordinary functions as Sampler.
+type SamplerFunc func(fingerprint string, d time.Duration) bool
+
+// Sample calls f(fingerprint, d).
+func (f SamplerFunc) Sample(fingerprint string, d time.Duration) bool {
+	return f(fingerprint, d)
+}
+
+// rateSampler samples the operations at the rate.
+type rateSampler float64
+
+func (r rateSampler) Sample(_ string, _ time.Duration) bool {
+	return rand.Float64() < float64(r)
 }
 
+// TraceFormat is the format of the logs of the tracing proxy.
+type TraceFormat int
+
+const (
+	// TraceFormatText formats a log as a line of text, e.g.
+	// "Exec 0xc000010000: INSERT INTO t1 (id) VALUES(?); args = [1]; conn_id = 1 (1ms)".
+	TraceFormatText TraceFormat = iota
+
+	// TraceFormatJSON formats a log as a JSON object of the versioned Event schema,
+	// whose arguments are formatted by TracerOptions.ValueFormatter,
+	// with the fields "level", "savepoint", "conn", "op_id", "fields", "uses", "rows_affected", "rows", "caller" and "stack".
+	// The savepoint statements are reported as Exec with the "savepoint" field, e.g. "Release".
+	// The empty fields are omitted.
+	TraceFormatJSON
+
+	// TraceFormatConsole formats a log for humans reading it on a terminal, with colors.
+	// It is intended for development. See ConsoleTraceFormatter.
+	TraceFormatConsole
+)
+
 // NewTraceProxy generates a proxy that logs queries.
+// If d is also a tracing proxy, the ignore lists of their filters are merged.
 func NewTraceProxy(d driver.Driver, o Outputter) *Proxy {
-	return NewProxyContext(d, NewTraceHooks(TracerOptions{
-		Outputter: o,
-	}))
+	return NewTraceProxyWithFilter(d, o, nil)
 }
 
 // NewTraceProxyWithFilter generates a proxy that logs queries.
+// If d is also a tracing proxy, the ignore lists of their filters are merged.
 func NewTraceProxyWithFilter(d driver.Driver, o Outputter, f Filter) *Proxy {
+	if filters := nestedTracerFilters(d); len(filters) > 0 {
+		f = mergeFilters(append([]Filter{f}, filters...)...)
+	}
 	return NewProxyContext(d, NewTraceHooks(TracerOptions{
 		Outputter: o,
 		Filter:    f,
@@ -82,182 +265,457 @@ func NewTraceHooks(opt TracerOptions) *HooksContext {
 	if o == nil {
 		o = logger{}
 	}
-	pool := &sync.Pool{
-		New: func() interface{} {
-			return &bytes.Buffer{}
+	now := opt.Now
+	if now == nil {
+		now = time.Now
+	}
+	// queryStarts is the start times of the queries whose rows are being read, keyed by the original rows,
+	// because the queries are logged by PostRows if TraceRows is set, which can't get the context of PreQuery.
+	var queryStarts sync.Map
+	caller := findCaller
+	if opt.DisableCaller {
+		caller = noCaller
+	}
+	vf := opt.ValueFormatter
+	if vf == nil {
+		vf = GoSyntaxValueFormatter
+	}
+	vf = truncateValueFormatter(vf, opt.MaxArgLength)
+	slowQueries := make(map[Operation]time.Duration, len(opt.SlowQueryByOperation))
+	for op, d := range opt.SlowQueryByOperation {
+		slowQueries[op] = d
+	}
+	var labels map[string]string
+	if len(opt.Labels) > 0 {
+		labels = make(map[string]string, len(opt.Labels))
+		for k, v := range opt.Labels {
+			labels[k] = v
+		}
+	}
+	slowQuery := func(op Operation) time.Duration {
+		if d, ok := slowQueries[op]; ok {
+			return d
+		}
+		return opt.SlowQuery
+	}
+	formatter := opt.Formatter
+	if formatter == nil {
+		switch opt.Format {
+		case TraceFormatJSON:
+			formatter = JSONTraceFormatter
+		case TraceFormatConsole:
+			formatter = ConsoleTraceFormatter
+		default:
+			formatter = TextTraceFormatter
+		}
+	}
+	sampler := opt.Sampler
+	if sampler == nil && opt.SampleRate > 0 {
+		sampler = rateSampler(opt.SampleRate)
+	}
+	t := &tracer{
+		o:             o,
+		vf:            vf,
+		formatter:     formatter,
+		redact:        opt.RedactArgs,
+		maxQuery:      opt.MaxQueryLength,
+		fingerprint:   opt.Fingerprint,
+		interpolate:   opt.InterpolateParams,
+		dialect:       opt.Dialect,
+		contextFields: opt.ContextFields,
+		sampler:       sampler,
+		sampleSlow:    opt.SampleSlowQuery,
+		levels:        opt.Levels,
+		filter:        f,
+		errorStack:    opt.ErrorStackTrace,
+		labels:        labels,
+		opID:          opt.OperationID,
+		now:           now,
+		pool: &sync.Pool{
+			New: func() interface{} {
+				return &bytes.Buffer{}
+			},
 		},
 	}
-	return &HooksContext{
-		PreOpen: func(_ context.Context, _ string) (interface{}, error) {
-			return time.Now(), nil
+	hooks := &HooksContext{
+		PreExec: func(_ context.Context, _ *Stmt, _ []driver.NamedValue) (interface{}, error) {
+			return now(), nil
 		},
-		PostOpen: func(_ context.Context, ctx interface{}, conn *Conn, err error) error {
-			d := time.Since(ctx.(time.Time))
-			if d < opt.SlowQuery {
+		PostExec: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, result driver.Result, err error) error {
+			d := now().Sub(ctx.(time.Time))
+			if d < slowQuery(OpExec) || stmt.Savepoint() != nil {
+				// the savepoint statements are logged by PostSavepoint.
 				return nil
 			}
-			buf := pool.Get().(*bytes.Buffer)
-			buf.Reset()
-			if conn != nil {
-				fmt.Fprintf(buf, "Open %p", conn.Conn)
-			} else {
-				fmt.Fprint(buf, "Open nil")
-			}
-			if err != nil {
-				fmt.Fprintf(buf, "; err = %#v", err.Error())
+			ev := &TraceEvent{
+				Op:       "Exec",
+				Conn:     stmt.Conn,
+				hasQuery: true,
+				Query:    stmt.QueryString,
+				hasArgs:  true,
+				Args:     args,
+				Labels:   LabelsFromContext(c),
+				Err:      err,
+				Duration: d,
 			}
-			io.WriteString(buf, " (")
-			io.WriteString(buf, d.String())
-			io.WriteString(buf, ")")
-			s := buf.String()
-			pool.Put(buf)
-			o.Output(findCaller(f), s)
-			return nil
-		},
-		PreExec: func(_ context.Context, _ *Stmt, _ []driver.NamedValue) (interface{}, error) {
-			return time.Now(), nil
-		},
-		PostExec: func(_ context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, _ driver.Result, err error) error {
-			d := time.Since(ctx.(time.Time))
-			if d < opt.SlowQuery {
-				return nil
+			if opt.TracePrepare && stmt.Prepared() {
+				ev.Uses = stmt.Uses()
 			}
-			buf := pool.Get().(*bytes.Buffer)
-			buf.Reset()
-			fmt.Fprintf(buf, "Exec %p: ", stmt.Conn.Conn)
-			io.WriteString(buf, stmt.QueryString)
-			io.WriteString(buf, "; args = [")
-			writeNamedValues(buf, args)
-			io.WriteString(buf, "]")
-			if err != nil {
-				fmt.Fprintf(buf, "; err = %#v", err.Error())
+			if opt.TraceRows && err == nil && result != nil {
+				if n, err := result.RowsAffected(); err == nil {
+					ev.RowsAffected = n
+					ev.hasRowsAffected = true
+				}
 			}
-			io.WriteString(buf, " (")
-			io.WriteString(buf, d.String())
-			io.WriteString(buf, ")")
-			s := buf.String()
-			pool.Put(buf)
-			o.Output(findCaller(f), s)
+			t.output(c, caller(f), ev)
 			return nil
 		},
 		PreQuery: func(_ context.Context, stmt *Stmt, args []driver.NamedValue) (interface{}, error) {
-			return time.Now(), nil
+			return now(), nil
 		},
-		PostQuery: func(_ context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, _ driver.Rows, err error) error {
-			d := time.Since(ctx.(time.Time))
-			if d < opt.SlowQuery {
+		PostQuery: func(c context.Context, ctx interface{}, stmt *Stmt, args []driver.NamedValue, rows driver.Rows, err error) error {
+			if opt.TraceRows && err == nil {
+				// the query is logged by PostRows, with the number of rows read.
+				if isComparable(rows) {
+					queryStarts.Store(rows, ctx)
+				}
 				return nil
 			}
-			buf := pool.Get().(*bytes.Buffer)
-			buf.Reset()
-			fmt.Fprintf(buf, "Query %p: ", stmt.Conn.Conn)
-			io.WriteString(buf, stmt.QueryString)
-			io.WriteString(buf, "; args = [")
-			writeNamedValues(buf, args)
-			io.WriteString(buf, "]")
-			if err != nil {
-				fmt.Fprintf(buf, "; err = %#v", err.Error())
+			d := now().Sub(ctx.(time.Time))
+			if d < slowQuery(OpQuery) {
+				return nil
+			}
+			ev := &TraceEvent{
+				Op:       "Query",
+				Conn:     stmt.Conn,
+				hasQuery: true,
+				Query:    stmt.QueryString,
+				hasArgs:  true,
+				Args:     args,
+				Labels:   LabelsFromContext(c),
+				Err:      err,
+				Duration: d,
+			}
+			if opt.TracePrepare && stmt.Prepared() {
+				ev.Uses = stmt.Uses()
 			}
-			io.WriteString(buf, " (")
-			io.WriteString(buf, d.String())
-			io.WriteString(buf, ")")
-			s := buf.String()
-			pool.Put(buf)
-			o.Output(findCaller(f), s)
+			t.output(c, caller(f), ev)
 			return nil
 		},
 		PreBegin: func(_ context.Context, _ *Conn) (interface{}, error) {
-			return time.Now(), nil
+			return now(), nil
 		},
-		PostBegin: func(_ context.Context, ctx interface{}, conn *Conn, err error) error {
-			d := time.Since(ctx.(time.Time))
-			if d < opt.SlowQuery {
+		PostBegin: func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			d := now().Sub(ctx.(time.Time))
+			if d < slowQuery(OpBegin) {
 				return nil
 			}
-			buf := pool.Get().(*bytes.Buffer)
-			buf.Reset()
-			fmt.Fprintf(buf, "Begin %p", conn.Conn)
-			if err != nil {
-				fmt.Fprintf(buf, "; err = %#v", err.Error())
-			}
-			io.WriteString(buf, " (")
-			io.WriteString(buf, d.String())
-			io.WriteString(buf, ")")
-			s := buf.String()
-			pool.Put(buf)
-			o.Output(findCaller(f), s)
+			t.output(c, caller(f), &TraceEvent{
+				Op:       "Begin",
+				Conn:     conn,
+				Labels:   LabelsFromContext(c),
+				Err:      err,
+				Duration: d,
+			})
 			return nil
 		},
 		PreCommit: func(_ context.Context, _ *Tx) (interface{}, error) {
-			return time.Now(), nil
+			return now(), nil
 		},
-		PostCommit: func(_ context.Context, ctx interface{}, tx *Tx, err error) error {
-			d := time.Since(ctx.(time.Time))
-			if d < opt.SlowQuery {
+		PostCommit: func(c context.Context, ctx interface{}, tx *Tx, err error) error {
+			d := now().Sub(ctx.(time.Time))
+			if d < slowQuery(OpCommit) {
 				return nil
 			}
-			buf := pool.Get().(*bytes.Buffer)
-			buf.Reset()
-			fmt.Fprintf(buf, "Commit %p", tx.Conn.Conn)
-			if err != nil {
-				fmt.Fprintf(buf, "; err = %#v", err.Error())
-			}
-			io.WriteString(buf, " (")
-			io.WriteString(buf, d.String())
-			io.WriteString(buf, ")")
-			s := buf.String()
-			pool.Put(buf)
-			o.Output(findCaller(f), s)
+			t.output(c, caller(f), &TraceEvent{
+				Op:       "Commit",
+				Conn:     tx.Conn,
+				Labels:   LabelsFromContext(c),
+				Err:      err,
+				Duration: d,
+			})
 			return nil
 		},
 		PreRollback: func(_ context.Context, _ *Tx) (interface{}, error) {
-			return time.Now(), nil
+			return now(), nil
 		},
-		PostRollback: func(_ context.Context, ctx interface{}, tx *Tx, err error) error {
-			d := time.Since(ctx.(time.Time))
-			if d < opt.SlowQuery {
+		PostRollback: func(c context.Context, ctx interface{}, tx *Tx, err error) error {
+			d := now().Sub(ctx.(time.Time))
+			if d < slowQuery(OpRollback) {
 				return nil
 			}
-			buf := pool.Get().(*bytes.Buffer)
-			buf.Reset()
-			fmt.Fprintf(buf, "Rollback %p", tx.Conn.Conn)
-			if err != nil {
-				fmt.Fprintf(buf, "; err = %#v", err.Error())
-			}
-			io.WriteString(buf, " (")
-			io.WriteString(buf, d.String())
-			io.WriteString(buf, ")")
-			s := buf.String()
-			pool.Put(buf)
-			o.Output(findCaller(f), s)
+			t.output(c, caller(f), &TraceEvent{
+				Op:       "Rollback",
+				Conn:     tx.Conn,
+				Labels:   LabelsFromContext(c),
+				Err:      err,
+				Duration: d,
+			})
 			return nil
 		},
-		PreClose: func(_ context.Context, _ *Conn) (interface{}, error) {
-			return time.Now(), nil
+		PreSavepoint: func(_ context.Context, _ *Stmt, _ *Savepoint) (interface{}, error) {
+			return now(), nil
 		},
-		PostClose: func(_ context.Context, ctx interface{}, conn *Conn, err error) error {
-			d := time.Since(ctx.(time.Time))
-			if d < opt.SlowQuery {
+		PostSavepoint: func(c context.Context, ctx interface{}, stmt *Stmt, sp *Savepoint, err error) error {
+			d := now().Sub(ctx.(time.Time))
+			if d < slowQuery(OpExec) {
 				return nil
 			}
-			buf := pool.Get().(*bytes.Buffer)
-			buf.Reset()
-			fmt.Fprintf(buf, "Close %p", conn.Conn)
-			if err != nil {
-				fmt.Fprintf(buf, "; err = %#v", err.Error())
-			}
-			io.WriteString(buf, " (")
-			io.WriteString(buf, d.String())
-			io.WriteString(buf, ")")
-			s := buf.String()
-			pool.Put(buf)
-			o.Output(findCaller(f), s)
+			t.output(c, caller(f), &TraceEvent{
+				Op:       sp.Kind.String(),
+				Conn:     stmt.Conn,
+				hasQuery: true,
+				Query:    sp.Name,
+				Labels:   LabelsFromContext(c),
+				Err:      err,
+				Duration: d,
+			})
 			return nil
 		},
 	}
+	if !opt.IgnoreOpen {
+		hooks.PreOpen = func(_ context.Context, _ string) (interface{}, error) {
+			return now(), nil
+		}
+		hooks.PostOpen = func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			d := now().Sub(ctx.(time.Time))
+			if d < slowQuery(OpOpen) {
+				return nil
+			}
+			t.output(c, caller(f), &TraceEvent{
+				Op:       "Open",
+				Conn:     conn,
+				Labels:   LabelsFromContext(c),
+				Err:      err,
+				Duration: d,
+			})
+			return nil
+		}
+	}
+	if !opt.IgnoreClose {
+		hooks.PreClose = func(_ context.Context, _ *Conn) (interface{}, error) {
+			return now(), nil
+		}
+		hooks.PostClose = func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			d := now().Sub(ctx.(time.Time))
+			if d < slowQuery(OpClose) {
+				return nil
+			}
+			t.output(c, caller(f), &TraceEvent{
+				Op:       "Close",
+				Conn:     conn,
+				Labels:   LabelsFromContext(c),
+				Err:      err,
+				Duration: d,
+			})
+			return nil
+		}
+	}
+	if opt.TracePing {
+		hooks.PrePing = func(_ context.Context, _ *Conn) (interface{}, error) {
+			return now(), nil
+		}
+		hooks.PostPing = func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			d := now().Sub(ctx.(time.Time))
+			if d < slowQuery(OpPing) {
+				return nil
+			}
+			t.output(c, caller(f), &TraceEvent{
+				Op:       "Ping",
+				Conn:     conn,
+				Labels:   LabelsFromContext(c),
+				Err:      err,
+				Duration: d,
+			})
+			return nil
+		}
+	}
+	if opt.TraceResetSession {
+		hooks.PreResetSession = func(_ context.Context, _ *Conn) (interface{}, error) {
+			return now(), nil
+		}
+		hooks.PostResetSession = func(c context.Context, ctx interface{}, conn *Conn, err error) error {
+			d := now().Sub(ctx.(time.Time))
+			if d < slowQuery(OpResetSession) {
+				return nil
+			}
+			t.output(c, caller(f), &TraceEvent{
+				Op:       "ResetSession",
+				Conn:     conn,
+				Labels:   LabelsFromContext(c),
+				Err:      err,
+				Duration: d,
+			})
+			return nil
+		}
+	}
+	if opt.TraceRows {
+		hooks.PostRows = func(c context.Context, rows *Rows, count int64, _ time.Duration) error {
+			d := rows.Elapsed()
+			if isComparable(rows.Rows) {
+				if start, ok := queryStarts.Load(rows.Rows); ok {
+					queryStarts.Delete(rows.Rows)
+					d = now().Sub(start.(time.Time))
+				}
+			}
+			if d < slowQuery(OpQuery) {
+				return nil
+			}
+			stmt := rows.Stmt
+			ev := &TraceEvent{
+				Op:          "Query",
+				Conn:        stmt.Conn,
+				hasQuery:    true,
+				Query:       stmt.QueryString,
+				hasArgs:     true,
+				Args:        rows.args,
+				Labels:      LabelsFromContext(c),
+				Err:         rows.Err(),
+				Duration:    d,
+				RowsRead:    count,
+				hasRowsRead: true,
+			}
+			if opt.TracePrepare && stmt.Prepared() {
+				ev.Uses = stmt.Uses()
+			}
+			t.output(c, caller(f), ev)
+			return nil
+		}
+	}
+	if opt.TracePrepare {
+		hooks.PrePrepare = func(_ context.Context, _ *Stmt) (interface{}, error) {
+			return now(), nil
+		}
+		hooks.PostPrepare = func(c context.Context, ctx interface{}, stmt *Stmt, err error) error {
+			d := now().Sub(ctx.(time.Time))
+			if d < slowQuery(OpPrepare) {
+				return nil
+			}
+			t.output(c, caller(f), &TraceEvent{
+				Op:       "Prepare",
+				Conn:     stmt.Conn,
+				hasQuery: true,
+				Query:    stmt.QueryString,
+				Labels:   LabelsFromContext(c),
+				Err:      err,
+				Duration: d,
+			})
+			return nil
+		}
+	}
+	hooks.tracerFilter = f
+	return hooks
+}
+
+// tracer outputs the logs of the hooks created by NewTraceHooks.
+type tracer struct {
+	o             Outputter
+	vf            ValueFormatter
+	formatter     TraceFormatter
+	redact        func(name string, v driver.Value) driver.Value
+	maxQuery      int
+	fingerprint   bool
+	interpolate   bool
+	dialect       Dialect
+	contextFields func(c context.Context) []TraceField
+	sampler       Sampler
+	sampleSlow    time.Duration
+	levels        *TraceLevels
+	filter        Filter
+	errorStack    bool
+	labels        map[string]string
+	opID          bool
+	now           func() time.Time
+	pool          *sync.Pool
+}
+
+// noCaller is used instead of findCaller when TracerOptions.DisableCaller is true.
+func noCaller(f Filter) int {
+	return 0
+}
+
+// output formats ev and outputs it.
+// c is the context of the operation, and calldepth is the depth of the caller from the hook, returned by findCaller.
+// calldepth is zero if the caller is not looked up.
+func (t *tracer) output(c context.Context, calldepth int, ev *TraceEvent) {
+	if !t.sample(ev) {
+		return
+	}
+	ev.ValueFormatter = t.vf
+	ev.start = t.now().Add(-ev.Duration)
+	ev.txID, _ = TxIDFromContext(c)
+	if t.levels != nil {
+		ev.Level = t.levels.level(ev)
+	}
+	if t.labels != nil {
+		ev.Labels = mergeLabels(t.labels, ev.Labels)
+	}
+	if t.opID {
+		ev.OpID, _ = OperationIDFromContext(c)
+	}
+	if t.contextFields != nil {
+		ev.Fields = t.contextFields(c)
+	}
+	if t.redact != nil {
+		ev.Args = redactArgs(ev.Args, t.redact)
+	}
+	if t.interpolate && !t.fingerprint && ev.hasArgs {
+		if q, ok := Interpolate(ev.Query, ev.Args, t.dialect); ok {
+			ev.Query = q
+			ev.Args = nil
+			ev.hasArgs = false
+		}
+	}
+	if t.fingerprint {
+		switch ev.Op {
+		case "Prepare", "Exec", "Query":
+			ev.Query = Fingerprint(ev.Query)
+		}
+	}
+	ev.Query = truncate(ev.Query, t.maxQuery)
+	if calldepth > 0 {
+		var pc [1]uintptr
+		if runtime.Callers(calldepth, pc[:]) > 0 {
+			ev.pc = pc[0]
+		}
+	}
+	if t.errorStack && ev.Err != nil {
+		skip := calldepth
+		if skip == 0 {
+			// 1: output, 2: the hook
+			skip = 2
+		}
+		ev.Stack = stackTrace(skip, t.filter)
+	}
+
+	buf := t.pool.Get().(*bytes.Buffer)
+	buf.Reset()
+	t.formatter.FormatTrace(buf, ev)
+	s := buf.String()
+	t.pool.Put(buf)
+
+	// +1 for the output method itself.
+	if lo, ok := t.o.(LevelOutputter); ok && ev.Level != 0 {
+		lo.OutputLevel(calldepth+1, ev.Level, s)
+		return
+	}
+	t.o.Output(calldepth+1, s)
 }
 
-func writeNamedValues(w io.Writer, args []driver.NamedValue) {
+// sample reports whether ev is logged.
+func (t *tracer) sample(ev *TraceEvent) bool {
+	if t.sampler == nil || ev.Err != nil || (t.sampleSlow > 0 && ev.Duration >= t.sampleSlow) {
+		return true
+	}
+	fingerprint := ev.Op
+	switch ev.Op {
+	case "Prepare", "Exec", "Query":
+		fingerprint = Fingerprint(ev.Query)
+	}
+	return t.sampler.Sample(fingerprint, ev.Duration)
+}
+
+func writeNamedValues(w io.Writer, args []driver.NamedValue, vf ValueFormatter) {
 	for i, arg := range args {
 		if i != 0 {
 			io.WriteString(w, ", ")
@@ -266,6 +724,54 @@ func writeNamedValues(w io.Writer, args []driver.NamedValue) {
 			io.WriteString(w, arg.Name)
 			io.WriteString(w, ":")
 		}
-		fmt.Fprintf(w, "%#v", arg.Value)
+		vf.FormatValue(w, arg.Value)
+	}
+}
+
+func writeConnID(w io.Writer, conn *Conn) {
+	if conn == nil || conn.id == 0 {
+		return
+	}
+	fmt.Fprintf(w, "; conn_id = %d", conn.id)
+}
+
+// mergeLabels returns the labels of base overridden by labels.
+func mergeLabels(base, labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(labels))
+	for k, v := range base {
+		merged[k] = v
 	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	return merged
+}
+
+func writeLabels(w io.Writer, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	io.WriteString(w, "; labels = {")
+	for i, k := range keys {
+		if i != 0 {
+			io.WriteString(w, ", ")
+		}
+		io.WriteString(w, k)
+		io.WriteString(w, "=")
+		io.WriteString(w, labels[k])
+	}
+	io.WriteString(w, "}")
+}
+
+// isComparable reports whether v can be a key of maps.
+func isComparable(v interface{}) bool {
+	return v != nil && reflect.TypeOf(v).Comparable()
 }
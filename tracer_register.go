@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"log"
 	"strings"
 )
@@ -10,17 +11,79 @@ import (
 // and registers the proxies as sql driver.
 // The proxies' names have suffix ":trace".
 func RegisterTracer() {
-	for _, driver := range sql.Drivers() {
-		if strings.HasSuffix(driver, ":trace") || strings.HasSuffix(driver, ":proxy") {
+	RegisterTracerWithOptions(RegisterOptions{})
+}
+
+// RegisterOptions holds the options of RegisterTracerWithOptions.
+type RegisterOptions struct {
+	// Drivers is the names of the sql drivers to be traced.
+	// If it is empty, all the sql drivers already registered are traced.
+	Drivers []string
+
+	// Suffix is the suffix of the names of the proxies.
+	// If it is empty, ":trace" is used.
+	Suffix string
+
+	// TracerOptions is the options of the tracing proxies.
+	TracerOptions TracerOptions
+}
+
+// RegisterTracerWithOptions creates proxies that log queries from the sql drivers already registered,
+// and registers the proxies as sql driver, in the same way as RegisterTracer.
+// opts controls which drivers are traced, the names of the proxies, and how they log queries.
+// The underlying drivers are resolved on first use, see NewProxyByName.
+// If a driver turns out to trace queries by itself, its proxy doesn't log them again.
+func RegisterTracerWithOptions(opts RegisterOptions) {
+	suffix := opts.Suffix
+	if suffix == "" {
+		suffix = ":trace"
+	}
+	var allowed map[string]bool
+	if len(opts.Drivers) > 0 {
+		allowed = make(map[string]bool, len(opts.Drivers))
+		for _, name := range opts.Drivers {
+			allowed[name] = true
+		}
+	}
+
+	for _, name := range sql.Drivers() {
+		if strings.HasSuffix(name, ":trace") || strings.HasSuffix(name, ":proxy") || strings.HasSuffix(name, suffix) {
 			continue
 		}
-		db, err := sql.Open(driver, "")
-		if err != nil {
+		if allowed != nil && !allowed[name] {
 			continue
 		}
-		defer db.Close()
-		sql.Register(driver+":trace", NewTraceProxy(db.Driver(), logger{}))
+		p := NewProxyByName(name, NewTraceHooks(opts.TracerOptions))
+		p.Driver.(*lazyDriver).onResolve = func(d driver.Driver) {
+			if len(nestedTracerFilters(d)) > 0 {
+				// the driver already traces queries.
+				p.SetHooks()
+			}
+		}
+		sql.Register(name+suffix, p)
+	}
+}
+
+// RegisterTracerFor creates a proxy that logs queries from the sql driver registered as driverName,
+// and registers the proxy as the sql driver named newName.
+// If the driver is also a tracing proxy, the ignore lists of their filters are merged.
+// The underlying driver is resolved on first use, see NewProxyByName.
+// It returns an error if driverName is not registered.
+// Like sql.Register, it panics if newName is already registered.
+func RegisterTracerFor(driverName, newName string, opt TracerOptions) error {
+	if !isRegistered(driverName) {
+		return unknownDriverError(driverName)
+	}
+	p := NewProxyByName(driverName, NewTraceHooks(opt))
+	p.Driver.(*lazyDriver).onResolve = func(d driver.Driver) {
+		if filters := nestedTracerFilters(d); len(filters) > 0 {
+			opt := opt
+			opt.Filter = mergeFilters(append([]Filter{opt.Filter}, filters...)...)
+			p.SetHooks(NewTraceHooks(opt))
+		}
 	}
+	sql.Register(newName, p)
+	return nil
 }
 
 type logger struct{}
@@ -70,17 +70,18 @@ func TestTraceProxy(t *testing.T) {
 	}
 
 	timeComponent := `\(\d+(?:\.\d+)?[^\)]+\)`
+	connID := `; conn_id = \d+`
 	expected := []*regexp.Regexp{
 		// Fake time component with (\d+\.\d+[^\)]+)
-		regexp.MustCompile(`tracer_test.go:28: Open 0x[0-9a-f]+ ` + timeComponent),
-		regexp.MustCompile(`tracer_test.go:28: Exec 0x[0-9a-f]+: CREATE TABLE t1 \(id INTEGER PRIMARY KEY\); args = \[\] ` + timeComponent),
-		regexp.MustCompile(`tracer_test.go:35: Begin 0x[0-9a-f]+ ` + timeComponent),
-		regexp.MustCompile(`tracer_test.go:40: Exec 0x[0-9a-f]+: INSERT INTO t1 \(id\) VALUES\(\?\); args = \[1\] ` + timeComponent),
-		regexp.MustCompile(`tracer_test.go:43: Query 0x[0-9a-f]+: SELECT id FROM t1 WHERE id = \?; args = \[1\] ` + timeComponent),
-		regexp.MustCompile(`tracer_test.go:50: Commit 0x[0-9a-f]+ ` + timeComponent),
-		regexp.MustCompile(`tracer_test.go:58: Begin 0x[0-9a-f]+ ` + timeComponent),
-		regexp.MustCompile(`tracer_test.go:62: Rollback 0x[0-9a-f]+ ` + timeComponent),
-		regexp.MustCompile(`.*:\d+: Close 0x[0-9a-f]+ ` + timeComponent),
+		regexp.MustCompile(`tracer_test.go:28: Open 0x[0-9a-f]+` + connID + ` ` + timeComponent),
+		regexp.MustCompile(`tracer_test.go:28: Exec 0x[0-9a-f]+: CREATE TABLE t1 \(id INTEGER PRIMARY KEY\); args = \[\]` + connID + ` ` + timeComponent),
+		regexp.MustCompile(`tracer_test.go:35: Begin 0x[0-9a-f]+` + connID + ` ` + timeComponent),
+		regexp.MustCompile(`tracer_test.go:40: Exec 0x[0-9a-f]+: INSERT INTO t1 \(id\) VALUES\(\?\); args = \[1\]` + connID + ` ` + timeComponent),
+		regexp.MustCompile(`tracer_test.go:43: Query 0x[0-9a-f]+: SELECT id FROM t1 WHERE id = \?; args = \[1\]` + connID + ` ` + timeComponent),
+		regexp.MustCompile(`tracer_test.go:50: Commit 0x[0-9a-f]+` + connID + ` ` + timeComponent),
+		regexp.MustCompile(`tracer_test.go:58: Begin 0x[0-9a-f]+` + connID + ` ` + timeComponent),
+		regexp.MustCompile(`tracer_test.go:62: Rollback 0x[0-9a-f]+` + connID + ` ` + timeComponent),
+		regexp.MustCompile(`.*:\d+: Close 0x[0-9a-f]+` + connID + ` ` + timeComponent),
 	}
 
 	scanner := bufio.NewScanner(buf)
@@ -99,3 +100,172 @@ func TestTraceProxy(t *testing.T) {
 		i++
 	}
 }
+
+func TestTraceProxy_TracePrepare(t *testing.T) {
+	origin, err := sql.Open("fakedb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer origin.Close()
+
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb:trace-prepare", proxy.NewProxyContext(origin.Driver(), proxy.NewTraceHooks(proxy.TracerOptions{
+		Outputter:    log.New(buf, "", 0),
+		TracePrepare: true,
+	})))
+	db, err := sql.Open("fakedb:trace-prepare", `{"name":"trace-prepare"}`)
+	if err != nil {
+		t.Fatalf("Open filed: %v", err)
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("INSERT INTO t1 (id) VALUES(?)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := stmt.Exec(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stmt.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	timeComponent := `\(\d+(?:\.\d+)?[^\)]+\)`
+	connID := `; conn_id = \d+`
+	expected := []*regexp.Regexp{
+		regexp.MustCompile(`^Open 0x[0-9a-f]+` + connID + ` ` + timeComponent),
+		regexp.MustCompile(`^Prepare 0x[0-9a-f]+: INSERT INTO t1 \(id\) VALUES\(\?\)` + connID + ` ` + timeComponent),
+		regexp.MustCompile(`^Exec 0x[0-9a-f]+: INSERT INTO t1 \(id\) VALUES\(\?\); args = \[0\]` + connID + `; uses = 1 ` + timeComponent),
+		regexp.MustCompile(`^Exec 0x[0-9a-f]+: INSERT INTO t1 \(id\) VALUES\(\?\); args = \[1\]` + connID + `; uses = 2 ` + timeComponent),
+	}
+	scanner := bufio.NewScanner(buf)
+	i := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i >= len(expected) {
+			t.Errorf("Got more lines than expected (%s)", line)
+			break
+		}
+		if !expected[i].MatchString(line) {
+			t.Errorf("\ngot: %s\nwant: %s", line, expected[i])
+		}
+		i++
+	}
+	if i != len(expected) {
+		t.Errorf("want %d lines, got %d", len(expected), i)
+	}
+}
+
+func TestTraceProxy_ValueFormatter(t *testing.T) {
+	origin, err := sql.Open("fakedb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer origin.Close()
+
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb:trace-value-formatter", proxy.NewProxyContext(origin.Driver(), proxy.NewTraceHooks(proxy.TracerOptions{
+		Outputter:      log.New(buf, "", 0),
+		ValueFormatter: proxy.SQLLiteralValueFormatter,
+	})))
+	db, err := sql.Open("fakedb:trace-value-formatter", `{"name":"trace-value-formatter"}`)
+	if err != nil {
+		t.Fatalf("Open filed: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id, name) VALUES(?, ?)", 1, "it's"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := regexp.MustCompile(`(?m)^Exec 0x[0-9a-f]+: INSERT INTO t1 \(id, name\) VALUES\(\?, \?\); args = \[1, 'it''s'\]; conn_id = \d+ `)
+	if !want.MatchString(buf.String()) {
+		t.Errorf("unexpected log:\n%s", buf.String())
+	}
+}
+
+func TestRegisterTracerFor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := proxy.RegisterTracerFor("fakedb", "fakedb-trace-for", proxy.TracerOptions{
+		Outputter: log.New(buf, "", 0),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("fakedb-trace-for", `{"name":"trace-for"}`)
+	if err != nil {
+		t.Fatalf("Open filed: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE t1 (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	want := regexp.MustCompile(`(?m)^Exec 0x[0-9a-f]+: CREATE TABLE t1 \(id INTEGER PRIMARY KEY\); args = \[\]; conn_id = \d+ `)
+	if !want.MatchString(buf.String()) {
+		t.Errorf("unexpected log:\n%s", buf.String())
+	}
+
+	if err := proxy.RegisterTracerFor("unknown-driver", "unknown-driver-trace-for", proxy.TracerOptions{}); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestRegisterTracerWithOptions(t *testing.T) {
+	buf := &bytes.Buffer{}
+	proxy.RegisterTracerWithOptions(proxy.RegisterOptions{
+		Drivers: []string{"fakedb"},
+		Suffix:  ":trace-with-options",
+		TracerOptions: proxy.TracerOptions{
+			Outputter: log.New(buf, "", 0),
+			SlowQuery: 1<<63 - 1, // log no queries
+		},
+	})
+	for _, name := range sql.Drivers() {
+		if name == "fakedbctx:trace-with-options" {
+			t.Errorf("%s is not in the allowlist, but registered", name)
+		}
+	}
+
+	db, err := sql.Open("fakedb:trace-with-options", `{"name":"trace-with-options"}`)
+	if err != nil {
+		t.Fatalf("Open filed: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE t1 (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("want no logs of fast queries, got:\n%s", buf.String())
+	}
+}
+
+func TestTraceProxy_JSON(t *testing.T) {
+	origin, err := sql.Open("fakedb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer origin.Close()
+
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb:trace-json", proxy.NewProxyContext(origin.Driver(), proxy.NewTraceHooks(proxy.TracerOptions{
+		Outputter: log.New(buf, "", 0),
+		Format:    proxy.TraceFormatJSON,
+	})))
+	db, err := sql.Open("fakedb:trace-json", `{"name":"trace-json"}`)
+	if err != nil {
+		t.Fatalf("Open filed: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id, name) VALUES(?, ?)", 1, "<name>"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := regexp.MustCompile(`(?m)^\{"version":1,"operation":"Exec","conn_id":\d+,"query":"INSERT INTO t1 \(id, name\) VALUES\(\?, \?\)","args":\[\{"ordinal":1,"value":"1"\},\{"ordinal":2,"value":"\\"<name>\\""\}\],"start":"[^"]+","duration":\d+,"conn":"0x[0-9a-f]+","caller":"tracer_test\.go:\d+"\}$`)
+	if !want.MatchString(buf.String()) {
+		t.Errorf("unexpected log:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,34 @@
+package proxy
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// truncate elides s if it is longer than max bytes.
+// The elided string ends with an ellipsis and the length of s, e.g. "SELECT * FR... (1024 bytes)".
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	n := max
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "... (" + strconv.Itoa(len(s)) + " bytes)"
+}
+
+// truncateValueFormatter returns a ValueFormatter that elides the outputs of vf longer than max bytes.
+func truncateValueFormatter(vf ValueFormatter, max int) ValueFormatter {
+	if max <= 0 {
+		return vf
+	}
+	return ValueFormatterFunc(func(w io.Writer, v driver.Value) {
+		var buf bytes.Buffer
+		vf.FormatValue(&buf, v)
+		io.WriteString(w, truncate(buf.String(), max))
+	})
+}
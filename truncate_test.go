@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"bytes"
+	"database/sql"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"SELECT 1", 0, "SELECT 1"},
+		{"SELECT 1", 8, "SELECT 1"},
+		{"SELECT 1", 6, "SELECT... (8 bytes)"},
+		{"あいう", 4, "あ... (9 bytes)"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.in, tt.max); got != tt.want {
+			t.Errorf("truncate(%q, %d): want %q, got %q", tt.in, tt.max, tt.want, got)
+		}
+	}
+}
+
+func TestTracerOptions_MaxLength(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sql.Register("fakedb-trace-max-length", NewProxyContext(fdriver, NewTraceHooks(TracerOptions{
+		Outputter:      log.New(buf, "", 0),
+		MaxQueryLength: 11,
+		MaxArgLength:   5,
+	})))
+	db, err := sql.Open("fakedb-trace-max-length", `{"name":"trace-max-length"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO t1 (id, name) VALUES(?, ?)", 1, strings.Repeat("a", 100)); err != nil {
+		t.Fatal(err)
+	}
+	want := `: INSERT INTO... (38 bytes); args = [1, "aaaa... (102 bytes)]`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("want %q in the log, got:\n%s", want, buf.String())
+	}
+}
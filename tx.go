@@ -3,6 +3,7 @@ package proxy
 import (
 	"context"
 	"database/sql/driver"
+	"time"
 )
 
 // Tx adds hook points into "database/sql/driver".Tx.
@@ -11,17 +12,35 @@ type Tx struct {
 	Proxy *Proxy
 	Conn  *Conn
 	ctx   context.Context
+	id    int64
+	start time.Time // the start time of BeginTx
+}
+
+// StartTime returns the time when the transaction was started by BeginTx.
+func (tx *Tx) StartTime() time.Time {
+	return tx.start
+}
+
+// Elapsed returns the duration since the transaction was started.
+// In the Commit and Rollback hooks, it is the age of the transaction.
+func (tx *Tx) Elapsed() time.Duration {
+	return time.Since(tx.start)
 }
 
 // Commit commits the transaction.
 // It will trigger PreCommit, Commit, PostCommit hooks.
-func (tx *Tx) Commit() error {
-	var err error
+func (tx *Tx) Commit() (err error) {
 	var ctx interface{}
-	hooks := tx.Proxy.getHooks(tx.ctx)
+	defer tx.finish()
+	start := time.Now()
+	defer tx.Proxy.stats.observe(OpCommit, start, &err)
+	defer tx.Proxy.wrapError(&err, OpCommit, tx.Conn, "", nil, start)
+	c := tx.ctx
+	hooks := tx.Proxy.getHooks(c, OpCommit, tx.Conn.connHooks())
 	if hooks != nil {
-		defer func() { hooks.postCommit(tx.ctx, ctx, tx, err) }()
-		if ctx, err = hooks.preCommit(tx.ctx, tx); err != nil {
+		c = withNewOpID(c)
+		defer func() { tx.Proxy.postError(&err, hooks.postCommit(withDuration(c, start), ctx, tx, err)) }()
+		if ctx, err = hooks.preCommit(c, tx); err != nil {
 			return err
 		}
 	}
@@ -31,20 +50,25 @@ func (tx *Tx) Commit() error {
 	}
 
 	if hooks != nil {
-		return hooks.commit(tx.ctx, ctx, tx)
+		return hooks.commit(c, ctx, tx)
 	}
 	return nil
 }
 
 // Rollback rollbacks the transaction.
 // It will trigger PreRollback, Rollback, PostRollback hooks.
-func (tx *Tx) Rollback() error {
-	var err error
+func (tx *Tx) Rollback() (err error) {
 	var ctx interface{}
-	hooks := tx.Proxy.getHooks(tx.ctx)
+	defer tx.finish()
+	start := time.Now()
+	defer tx.Proxy.stats.observe(OpRollback, start, &err)
+	defer tx.Proxy.wrapError(&err, OpRollback, tx.Conn, "", nil, start)
+	c := tx.ctx
+	hooks := tx.Proxy.getHooks(c, OpRollback, tx.Conn.connHooks())
 	if hooks != nil {
-		defer func() { hooks.postRollback(tx.ctx, ctx, tx, err) }()
-		if ctx, err = hooks.preRollback(tx.ctx, tx); err != nil {
+		c = withNewOpID(c)
+		defer func() { tx.Proxy.postError(&err, hooks.postRollback(withDuration(c, start), ctx, tx, err)) }()
+		if ctx, err = hooks.preRollback(c, tx); err != nil {
 			return err
 		}
 	}
@@ -54,7 +78,14 @@ func (tx *Tx) Rollback() error {
 	}
 
 	if hooks != nil {
-		return hooks.rollback(tx.ctx, ctx, tx)
+		return hooks.rollback(c, ctx, tx)
 	}
 	return nil
 }
+
+// finish marks the transaction as finished.
+func (tx *Tx) finish() {
+	if tx.Conn != nil && tx.Conn.txID == tx.id {
+		tx.Conn.txID = 0
+	}
+}
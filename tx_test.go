@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+var _ driver.Tx = (*Tx)(nil)
+
+func TestTxElapsed(t *testing.T) {
+	var age time.Duration
+	var startTime time.Time
+	p := NewProxyContext(fdriver, &HooksContext{
+		PostCommit: func(c context.Context, ctx interface{}, tx *Tx, err error) error {
+			age = tx.Elapsed()
+			startTime = tx.StartTime()
+			return nil
+		},
+	})
+	conn := newConn(&fakeConnCtx{db: &fakeDB{log: &bytes.Buffer{}}, opt: &fakeConnOption{}}, p)
+
+	before := time.Now()
+	tx, err := conn.BeginTx(context.Background(), driver.TxOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if startTime.Before(before) {
+		t.Errorf("want the start time after %s, got %s", before, startTime)
+	}
+	if age < 10*time.Millisecond {
+		t.Errorf("want the age including the sleep, got %s", age)
+	}
+}
@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValueFormatter formats the arguments of queries.
+// It is used by every output that writes the arguments, e.g. the tracing proxy.
+type ValueFormatter interface {
+	FormatValue(w io.Writer, v driver.Value)
+}
+
+// ValueFormatterFunc is an adapter to allow the use of ordinary functions as ValueFormatter.
+type ValueFormatterFunc func(w io.Writer, v driver.Value)
+
+// FormatValue calls f(w, v).
+func (f ValueFormatterFunc) FormatValue(w io.Writer, v driver.Value) {
+	f(w, v)
+}
+
+var (
+	// GoSyntaxValueFormatter formats values in the Go syntax, like fmt's %#v verb.
+	// It is the default ValueFormatter.
+	GoSyntaxValueFormatter ValueFormatter = ValueFormatterFunc(formatGoSyntax)
+
+	// CompactValueFormatter formats values in short forms.
+	// Strings are quoted, and byte slices are summarized by their length.
+	CompactValueFormatter ValueFormatter = ValueFormatterFunc(formatCompact)
+
+	// SQLLiteralValueFormatter formats values as SQL literals.
+	SQLLiteralValueFormatter ValueFormatter = ValueFormatterFunc(formatSQLLiteral)
+
+	// RedactedValueFormatter hides values.
+	RedactedValueFormatter ValueFormatter = ValueFormatterFunc(formatRedacted)
+)
+
+func formatGoSyntax(w io.Writer, v driver.Value) {
+	fmt.Fprintf(w, "%#v", v)
+}
+
+func formatCompact(w io.Writer, v driver.Value) {
+	switch v := v.(type) {
+	case nil:
+		io.WriteString(w, "nil")
+	case string:
+		io.WriteString(w, strconv.Quote(v))
+	case []byte:
+		fmt.Fprintf(w, "[%d bytes]", len(v))
+	case time.Time:
+		io.WriteString(w, v.Format(time.RFC3339Nano))
+	default:
+		fmt.Fprintf(w, "%v", v)
+	}
+}
+
+func formatSQLLiteral(w io.Writer, v driver.Value) {
+	switch v := v.(type) {
+	case nil:
+		io.WriteString(w, "NULL")
+	case string:
+		io.WriteString(w, "'")
+		io.WriteString(w, strings.Replace(v, "'", "''", -1))
+		io.WriteString(w, "'")
+	case []byte:
+		io.WriteString(w, "X'")
+		io.WriteString(w, hex.EncodeToString(v))
+		io.WriteString(w, "'")
+	case bool:
+		if v {
+			io.WriteString(w, "TRUE")
+		} else {
+			io.WriteString(w, "FALSE")
+		}
+	case time.Time:
+		io.WriteString(w, "'")
+		io.WriteString(w, v.Format("2006-01-02 15:04:05.999999999"))
+		io.WriteString(w, "'")
+	default:
+		fmt.Fprintf(w, "%v", v)
+	}
+}
+
+func formatRedacted(w io.Writer, _ driver.Value) {
+	io.WriteString(w, "<redacted>")
+}
+
+// formatNamedValues returns a copy of args whose values are replaced with the strings formatted by vf,
+// which are elided if they are longer than max bytes.
+// If vf is nil, GoSyntaxValueFormatter is used. If vf is nil and max is zero, the values are copied as is.
+func formatNamedValues(args []driver.NamedValue, vf ValueFormatter, max int) []driver.NamedValue {
+	if len(args) == 0 {
+		return nil
+	}
+	ret := make([]driver.NamedValue, len(args))
+	copy(ret, args)
+	if vf == nil && max <= 0 {
+		return ret
+	}
+	if vf == nil {
+		vf = GoSyntaxValueFormatter
+	}
+	vf = truncateValueFormatter(vf, max)
+	var buf strings.Builder
+	for i := range ret {
+		buf.Reset()
+		vf.FormatValue(&buf, ret[i].Value)
+		ret[i].Value = buf.String()
+	}
+	return ret
+}
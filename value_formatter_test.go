@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+func TestValueFormatter(t *testing.T) {
+	ts := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name      string
+		formatter ValueFormatter
+		value     driver.Value
+		want      string
+	}{
+		{"go-syntax string", GoSyntaxValueFormatter, "foo", `"foo"`},
+		{"go-syntax int", GoSyntaxValueFormatter, int64(1), `1`},
+		{"compact nil", CompactValueFormatter, nil, `nil`},
+		{"compact string", CompactValueFormatter, "foo", `"foo"`},
+		{"compact bytes", CompactValueFormatter, []byte("foo"), `[3 bytes]`},
+		{"compact time", CompactValueFormatter, ts, `2006-01-02T15:04:05Z`},
+		{"sql nil", SQLLiteralValueFormatter, nil, `NULL`},
+		{"sql string", SQLLiteralValueFormatter, "it's", `'it''s'`},
+		{"sql bytes", SQLLiteralValueFormatter, []byte("foo"), `X'666f6f'`},
+		{"sql bool", SQLLiteralValueFormatter, true, `TRUE`},
+		{"sql float", SQLLiteralValueFormatter, 1.5, `1.5`},
+		{"sql time", SQLLiteralValueFormatter, ts, `'2006-01-02 15:04:05'`},
+		{"redacted", RedactedValueFormatter, "secret", `<redacted>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.formatter.FormatValue(&buf, tt.value)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("want %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/shogo82148/go-sql-proxy/zapproxy
+
+go 1.25.0
+
+require (
+	github.com/shogo82148/go-sql-proxy v0.8.0 // placeholder: needs an unreleased version of the root module, see README
+	go.uber.org/zap v1.28.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
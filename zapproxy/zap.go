@@ -0,0 +1,115 @@
+// Package zapproxy integrates go-sql-proxy with go.uber.org/zap.
+package zapproxy
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Options holds the options of NewHooks.
+type Options struct {
+	// Level is the level of the entries of the operations.
+	// The default is zapcore.InfoLevel, which is the zero value.
+	Level zapcore.Level
+
+	// SlowQuery is a threshold duration of slow operations.
+	// The entries of the operations that take SlowQuery or longer are logged at zapcore.WarnLevel.
+	// If it is zero, no operations are slow.
+	SlowQuery time.Duration
+
+	// OmitArgs omits the arguments of queries from the entries.
+	OmitArgs bool
+}
+
+// NewHooks creates new HooksContext which logs the operations to logger as structured entries.
+// The entries have the fields "query", "args", "duration", "conn_id", "tx_id", "error" and "labels",
+// and are omitted if they are empty.
+// The failed operations are logged at zapcore.ErrorLevel.
+// If logger is nil, zap.L() is used.
+func NewHooks(logger *zap.Logger, opt Options) *proxy.HooksContext {
+	return proxy.NewEventHooks(func(c context.Context, e *proxy.Event) {
+		l := logger
+		if l == nil {
+			l = zap.L()
+		}
+		lv := opt.Level
+		if e.Error != "" {
+			lv = zapcore.ErrorLevel
+		} else if opt.SlowQuery > 0 && e.Duration >= opt.SlowQuery {
+			lv = zapcore.WarnLevel
+		}
+		ce := l.Check(lv, e.Operation.String())
+		if ce == nil {
+			return
+		}
+
+		fields := make([]zap.Field, 0, 7)
+		if e.Query != "" {
+			fields = append(fields, zap.String("query", e.Query))
+		}
+		if len(e.Args) > 0 && !opt.OmitArgs {
+			fields = append(fields, zap.Array("args", args(e.Args)))
+		}
+		fields = append(fields, zap.Duration("duration", e.Duration))
+		if e.ConnID != 0 {
+			fields = append(fields, zap.Int64("conn_id", e.ConnID))
+		}
+		if e.TxID != 0 {
+			fields = append(fields, zap.Int64("tx_id", e.TxID))
+		}
+		if e.Error != "" {
+			fields = append(fields, zap.String("error", e.Error))
+		}
+		if len(e.Labels) > 0 {
+			fields = append(fields, zap.Object("labels", labels(e.Labels)))
+		}
+		ce.Write(fields...)
+	})
+}
+
+// args encodes the arguments of queries with their own types.
+type args []proxy.EventArg
+
+func (a args) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, arg := range a {
+		switch v := arg.Value.(type) {
+		case int64:
+			enc.AppendInt64(v)
+		case float64:
+			enc.AppendFloat64(v)
+		case bool:
+			enc.AppendBool(v)
+		case string:
+			enc.AppendString(v)
+		case []byte:
+			enc.AppendByteString(v)
+		case time.Time:
+			enc.AppendTime(v)
+		default:
+			if err := enc.AppendReflected(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// labels encodes the labels in the order of their keys.
+type labels map[string]string
+
+func (l labels) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		enc.AddString(k, l[k])
+	}
+	return nil
+}
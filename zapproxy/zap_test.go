@@ -0,0 +1,85 @@
+package zapproxy
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	proxy "github.com/shogo82148/go-sql-proxy"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func exec(t *testing.T, h *proxy.HooksContext, c context.Context, args []driver.NamedValue, err error) {
+	t.Helper()
+	stmt := &proxy.Stmt{QueryString: "INSERT INTO t1 (id, name) VALUES(?, ?)"}
+	ctx, e := h.PreExec(c, stmt, args)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e := h.PostExec(c, ctx, stmt, args, nil, err); e != nil {
+		t.Fatal(e)
+	}
+}
+
+func TestNewHooks(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	h := NewHooks(zap.New(core), Options{Level: zapcore.DebugLevel})
+
+	c := proxy.WithLabels(context.Background(), map[string]string{"job": "test"})
+	args := []driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: "foo"},
+	}
+	exec(t, h, c, args, nil)
+	exec(t, h, c, args, errors.New("exec failed"))
+
+	entries := logs.AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, got %d", len(entries))
+	}
+
+	e := entries[0]
+	if e.Level != zapcore.DebugLevel || e.Message != "Exec" {
+		t.Errorf("unexpected entry: %+v", e.Entry)
+	}
+	fields := e.ContextMap()
+	if fields["query"] != "INSERT INTO t1 (id, name) VALUES(?, ?)" {
+		t.Errorf("unexpected query: %v", fields["query"])
+	}
+	if got, ok := fields["args"].([]interface{}); !ok || len(got) != 2 || got[0] != int64(1) || got[1] != "foo" {
+		t.Errorf("unexpected args: %#v", fields["args"])
+	}
+	if got, ok := fields["labels"].(map[string]interface{}); !ok || got["job"] != "test" {
+		t.Errorf("unexpected labels: %#v", fields["labels"])
+	}
+	if _, ok := fields["error"]; ok {
+		t.Errorf("want no error, got %v", fields["error"])
+	}
+
+	e = entries[1]
+	if e.Level != zapcore.ErrorLevel || e.ContextMap()["error"] != "exec failed" {
+		t.Errorf("unexpected entry: %+v %v", e.Entry, e.ContextMap())
+	}
+}
+
+func TestNewHooks_Level(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	h := NewHooks(zap.New(core), Options{Level: zapcore.DebugLevel, OmitArgs: true})
+
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+	exec(t, h, context.Background(), args, nil)
+	if logs.Len() != 0 {
+		t.Errorf("want no entries below the level of the logger, got %d", logs.Len())
+	}
+	exec(t, h, context.Background(), args, errors.New("exec failed"))
+	entries := logs.AllUntimed()
+	if len(entries) != 1 {
+		t.Fatalf("want 1 entry, got %d", len(entries))
+	}
+	if _, ok := entries[0].ContextMap()["args"]; ok {
+		t.Errorf("want no args, got %v", entries[0].ContextMap()["args"])
+	}
+}
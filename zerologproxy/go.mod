@@ -0,0 +1,14 @@
+module github.com/shogo82148/go-sql-proxy/zerologproxy
+
+go 1.25.0
+
+require (
+	github.com/rs/zerolog v1.35.1
+	github.com/shogo82148/go-sql-proxy v0.8.0 // placeholder: needs an unreleased version of the root module, see README
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+)
@@ -0,0 +1,112 @@
+// Package zerologproxy integrates go-sql-proxy with github.com/rs/zerolog.
+package zerologproxy
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	proxy "github.com/shogo82148/go-sql-proxy"
+)
+
+// Options holds the options of NewHooks.
+type Options struct {
+	// Level is the level of the events of the operations.
+	// The default is zerolog.DebugLevel, which is the zero value.
+	Level zerolog.Level
+
+	// SlowQuery is a threshold duration of slow operations.
+	// The events of the operations that take SlowQuery or longer are logged at zerolog.WarnLevel.
+	// If it is zero, no operations are slow.
+	SlowQuery time.Duration
+
+	// OmitArgs omits the arguments of queries from the events.
+	OmitArgs bool
+}
+
+// NewHooks creates new HooksContext which logs the operations to logger as zerolog events.
+// The events have the fields "query", "args", "duration", "conn_id", "tx_id", "error" and "labels",
+// and are omitted if they are empty.
+// The failed operations are logged at zerolog.ErrorLevel.
+// The level and the sampler of logger are honored.
+// If logger is nil, the global logger of github.com/rs/zerolog/log is used.
+func NewHooks(logger *zerolog.Logger, opt Options) *proxy.HooksContext {
+	return proxy.NewEventHooks(func(c context.Context, e *proxy.Event) {
+		l := logger
+		if l == nil {
+			l = &log.Logger
+		}
+		lv := opt.Level
+		if e.Error != "" {
+			lv = zerolog.ErrorLevel
+		} else if opt.SlowQuery > 0 && e.Duration >= opt.SlowQuery {
+			lv = zerolog.WarnLevel
+		}
+		ev := l.WithLevel(lv)
+		if ev == nil {
+			// the event is disabled or sampled out.
+			return
+		}
+
+		if e.Query != "" {
+			ev.Str("query", e.Query)
+		}
+		if len(e.Args) > 0 && !opt.OmitArgs {
+			ev.Array("args", args(e.Args))
+		}
+		ev.Dur("duration", e.Duration)
+		if e.ConnID != 0 {
+			ev.Int64("conn_id", e.ConnID)
+		}
+		if e.TxID != 0 {
+			ev.Int64("tx_id", e.TxID)
+		}
+		if e.Error != "" {
+			ev.Str("error", e.Error)
+		}
+		if len(e.Labels) > 0 {
+			ev.Dict("labels", labels(e.Labels))
+		}
+		ev.Msg(e.Operation.String())
+	})
+}
+
+// args encodes the arguments of queries with their own types.
+type args []proxy.EventArg
+
+func (a args) MarshalZerologArray(arr *zerolog.Array) {
+	for _, arg := range a {
+		switch v := arg.Value.(type) {
+		case int64:
+			arr.Int64(v)
+		case float64:
+			arr.Float64(v)
+		case bool:
+			arr.Bool(v)
+		case string:
+			arr.Str(v)
+		case []byte:
+			arr.Bytes(v)
+		case time.Time:
+			arr.Time(v)
+		default:
+			arr.Interface(v)
+		}
+	}
+}
+
+// labels returns the dictionary of the labels in the order of their keys.
+func labels(l map[string]string) *zerolog.Event {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	dict := zerolog.Dict()
+	for _, k := range keys {
+		dict.Str(k, l[k])
+	}
+	return dict
+}
@@ -0,0 +1,105 @@
+package zerologproxy
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	proxy "github.com/shogo82148/go-sql-proxy"
+)
+
+func exec(t *testing.T, h *proxy.HooksContext, c context.Context, args []driver.NamedValue, err error) {
+	t.Helper()
+	stmt := &proxy.Stmt{QueryString: "INSERT INTO t1 (id, name) VALUES(?, ?)"}
+	ctx, e := h.PreExec(c, stmt, args)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e := h.PostExec(c, ctx, stmt, args, nil, err); e != nil {
+		t.Fatal(e)
+	}
+}
+
+type event struct {
+	Level  string
+	Msg    string `json:"message"`
+	Query  string
+	Args   []interface{}
+	Error  string
+	Labels map[string]string
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) []event {
+	t.Helper()
+	var events []event
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var e event
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestNewHooks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+	h := NewHooks(&logger, Options{})
+
+	c := proxy.WithLabels(context.Background(), map[string]string{"job": "test"})
+	args := []driver.NamedValue{
+		{Ordinal: 1, Value: int64(1)},
+		{Ordinal: 2, Value: "foo"},
+	}
+	exec(t, h, c, args, nil)
+	exec(t, h, c, args, errors.New("exec failed"))
+
+	events := decode(t, buf)
+	if len(events) != 2 {
+		t.Fatalf("want 2 events, got %d", len(events))
+	}
+	e := events[0]
+	if e.Level != "debug" || e.Msg != "Exec" || e.Query != "INSERT INTO t1 (id, name) VALUES(?, ?)" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if len(e.Args) != 2 || e.Args[0] != float64(1) || e.Args[1] != "foo" {
+		t.Errorf("unexpected args: %#v", e.Args)
+	}
+	if e.Labels["job"] != "test" {
+		t.Errorf("unexpected labels: %v", e.Labels)
+	}
+	if e := events[1]; e.Level != "error" || e.Error != "exec failed" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
+
+func TestNewHooks_LevelAndSampling(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf).Level(zerolog.InfoLevel)
+	h := NewHooks(&logger, Options{OmitArgs: true})
+
+	args := []driver.NamedValue{{Ordinal: 1, Value: int64(1)}}
+	exec(t, h, context.Background(), args, nil)
+	if buf.Len() != 0 {
+		t.Errorf("want no events below the level of the logger, got %s", buf.String())
+	}
+
+	sampled := logger.Sample(&zerolog.BasicSampler{N: 2})
+	h = NewHooks(&sampled, Options{OmitArgs: true})
+	for i := 0; i < 4; i++ {
+		exec(t, h, context.Background(), args, errors.New("exec failed"))
+	}
+	events := decode(t, buf)
+	if len(events) != 2 {
+		t.Fatalf("want 2 sampled events, got %d", len(events))
+	}
+	if events[0].Args != nil {
+		t.Errorf("want no args, got %v", events[0].Args)
+	}
+}